@@ -1,18 +1,34 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"expvar"
 	"fmt"
 	"log"
 	"log/slog"
+	"net"
+	nethttp "net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
-	_ "sample-project/docs"
+	"sample-project/docs"
 	"sample-project/internal/config"
 	"sample-project/internal/config/cache"
+	"sample-project/internal/config/health"
+	"sample-project/internal/config/logger"
+	"sample-project/internal/config/storage"
+	"sample-project/internal/delivery/graphql"
+	grpcdelivery "sample-project/internal/delivery/grpc"
 	http "sample-project/internal/delivery/http"
+	"sample-project/internal/delivery/http/middleware"
+	"sample-project/internal/entity"
 	"sample-project/internal/repository"
 	"sample-project/internal/usecase"
+	"sample-project/internal/validation"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -24,11 +40,16 @@ import (
 // @title           Sample Project API
 // @version         1.0
 // @description     This is a sample project API using Golang, Prisma, Postgres, and JWT authentication.
+// @description     Responses follow the Accept header or a ?format=json|msgpack|xml|csv override, request bodies are read by their Content-Type.
+// @description     Every user and subject belongs to a school. Requests are scoped to the school of their access token, X-API-Key header or host, and never see another school's data.
+// @description     Error messages are in the language of the Accept-Language header, English or Lao. The code of an error never changes with the language, clients can localize from it on their own.
 
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
 func main() {
+	logger.Setup()
+
 	// Load environment variables from .env file
 	err := godotenv.Load()
 	if err != nil {
@@ -40,47 +61,269 @@ func main() {
 		port = "8080"
 	}
 
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "9090"
+	}
+
 	// Connect to Postgres
 	client, err := config.ConnectDB()
 	if err != nil {
 		slog.Error("Failed to connect to database", "error", err)
 		os.Exit(1)
 	}
+	// the batch endpoint writes through its own pool, it needs an interactive transaction
+	pool, err := config.ConnectPool(context.Background())
+	if err != nil {
+		slog.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
+	}
 
 	// Connect to Redis
 	cache.ConnectRedis()
 	redisClient := cache.GetRedisClient()
 
-	router := gin.Default()
+	// Connect to RabbitMQ, the API keeps serving without it and reports itself degraded
+	mqConn, err := config.RabbitMQConnection()
+	if err != nil {
+		slog.Warn("RabbitMQ unavailable", "error", err)
+	}
+
+	// Configure file storage
+	fileStorage, err := storage.NewStorage()
+	if err != nil {
+		slog.Error("Failed to configure file storage", "error", err)
+		os.Exit(1)
+	}
+
+	// Checks requests and responses against the swagger spec, OPENAPI_VALIDATION=report|enforce turns it on in dev and test
+	openAPIValidation, err := middleware.OpenAPIValidation([]byte(docs.SwaggerInfo.ReadDoc()), os.Getenv("OPENAPI_VALIDATION"))
+	if err != nil {
+		slog.Error("Failed to load the OpenAPI spec", "error", err)
+		os.Exit(1)
+	}
+
+	// Schools are resolved before anything reads or caches their data, see middleware.Tenant
+	tenantRepo := repository.NewTenantRepository(client)
+	tenantUsecase := usecase.NewTenantUseCase(tenantRepo)
+
+	router := gin.New()
+	// lets request scoped values (request id, user id, tenant, language) reach usecases that receive the gin context
+	router.ContextWithFallback = true
+	router.Use(middleware.RequestID(), middleware.AccessLog())
+
+	// CORS and security headers come before every middleware that can abort, so browsers get to see its error responses
+	// Configure CORS, see config.CORSConfig for the CORS_* variables
+	corsConfig := config.CORSConfig()
+	router.Use(cors.New(corsConfig))
 
-	// Configure CORS
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},                                       // Allows all origins (adjust as needed)
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}, // Allowed methods
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"}, // Allowed headers
-		ExposeHeaders:    []string{"Content-Length"},                          // Expose custom headers
-		AllowCredentials: true,                                                // Whether to allow cookies
-		MaxAge:           12 * time.Hour,                                      // Caching for preflight request
-	}))
+	// Security headers, the swagger UI needs inline scripts and styles. HSTS_MAX_AGE overrides the HSTS lifetime
+	hsts := fmt.Sprintf("max-age=%d; includeSubDomains", int(config.EnvDuration("HSTS_MAX_AGE", 365*24*time.Hour).Seconds()))
+	router.Use(middleware.SecurityHeaders(
+		middleware.SecurityPolicy{
+			Prefix:                "/swagger/",
+			HSTS:                  hsts,
+			ContentSecurityPolicy: "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; font-src 'self' data:; frame-ancestors 'self'",
+			FrameOptions:          "SAMEORIGIN",
+			ReferrerPolicy:        "strict-origin-when-cross-origin",
+		},
+		middleware.SecurityPolicy{
+			Prefix:                "/",
+			HSTS:                  hsts,
+			ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+			FrameOptions:          "DENY",
+			ReferrerPolicy:        "no-referrer",
+		},
+	))
+
+	// Rate limits, the first matching policy wins. Override with RATE_LIMIT_<NAME>=limit/window
+	rateLimit := middleware.RateLimit(cache.NewRateLimiter(),
+		middleware.RateLimitPolicy{Name: "login", Prefix: "/api/v1/auth/login", Limit: 10, Window: time.Minute, Key: middleware.ByIP},
+		middleware.RateLimitPolicy{Name: "login", Prefix: "/api/v2/auth/login", Limit: 10, Window: time.Minute, Key: middleware.ByIP},
+		middleware.RateLimitPolicy{Name: "refresh", Prefix: "/api/v1/auth/refresh", Limit: 30, Window: time.Minute, Key: middleware.ByIP},
+		middleware.RateLimitPolicy{Name: "refresh", Prefix: "/api/v2/auth/refresh", Limit: 30, Window: time.Minute, Key: middleware.ByIP},
+		middleware.RateLimitPolicy{Name: "graphql", Prefix: "/graphql", Limit: 120, Window: time.Minute, Key: middleware.ByIdentity},
+		middleware.RateLimitPolicy{Name: "write", Prefix: "/api/", Methods: []string{"POST", "PUT", "PATCH", "DELETE"}, Limit: 60, Window: time.Minute, Key: middleware.ByIdentity},
+		middleware.RateLimitPolicy{Name: "read", Prefix: "/api/", Limit: 300, Window: time.Minute, Key: middleware.ByIdentity},
+	)
+
+	// The access token is read once, the tenant, the rate limits and the handlers take the caller from the context.
+	// Limits apply before Idempotency so a refused attempt is not replayed to the retries of its key
+	router.Use(gin.Recovery(), middleware.Locale(), middleware.Authenticate("/api/", "/graphql"), middleware.Tenant(tenantUsecase.ResolveTenant, "/api/", "/graphql"), rateLimit, middleware.Idempotency(), openAPIValidation, middleware.ErrorHandler(), middleware.ContentNegotiation("/api/", "application/zip", "text/event-stream"))
+	// Deadline of every request but the change streams, REQUEST_TIMEOUT overrides it
+	router.Use(middleware.Timeout(config.EnvDuration("REQUEST_TIMEOUT", 20*time.Second), "/api/v1/events/", "/api/v2/events/"))
+
+	// v1 is deprecated since v2 shipped and keeps working until its sunset, API_V1_DEPRECATED and API_V1_SUNSET (YYYY-MM-DD) move the dates
+	v1Deprecated := config.EnvDate("API_V1_DEPRECATED", time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC))
+	v1Sunset := config.EnvDate("API_V1_SUNSET", time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC))
+	router.Use(middleware.Deprecation("/api/v1/", "/api/v2", v1Deprecated, v1Sunset))
 
 	// Initialize Repositories
 	userRepo := repository.NewUserRepository(client, redisClient)
 	subjectRepo := repository.NewSubjectRepository(client, redisClient)
+	privacyRepo := repository.NewPrivacyRepository(client)
+	eventRepo := repository.NewEventRepository()
+	batchRepo := repository.NewBatchRepository(client, pool)
+
+	// Register validation rules that need the database
+	validation.Register(userRepo, subjectRepo)
 
 	// Initialize Usecases
-	userUsecase := usecase.NewUserUsecase(userRepo)
+	userUsecase := usecase.NewUserUsecase(userRepo, fileStorage)
 	authUsecase := usecase.NewAuthUsecase(userRepo)
 	subjectUsecase := usecase.NewSubjectUseCase(subjectRepo)
+	privacyUsecase := usecase.NewPrivacyUseCase(userRepo, subjectRepo, privacyRepo, fileStorage)
+	eventUsecase := usecase.NewEventUseCase(eventRepo)
+	batchUsecase := usecase.NewBatchUseCase(batchRepo)
+
+	// Cancelled on SIGINT / SIGTERM, which starts the shutdown
+	shutdownCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Background jobs finish their current run before the database goes away
+	var jobs sync.WaitGroup
+
+	// Reactivate suspended users once their reactivate_at has passed, school by school
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-shutdownCtx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			tenants, err := tenantUsecase.GetAllTenants(context.Background())
+			if err != nil {
+				slog.Error("Failed to list tenants", "error", err)
+				continue
+			}
+
+			for _, tenant := range tenants {
+				count, err := userUsecase.ReactivateDueUsers(entity.WithTenant(context.Background(), tenant.ID))
+				if err != nil {
+					slog.Error("Failed to reactivate users", "tenant", tenant.Slug, "error", err)
+					continue
+				}
+				if count > 0 {
+					slog.Info("Reactivated suspended users", "tenant", tenant.Slug, "count", count)
+				}
+			}
+		}
+	}()
+
+	// Fan out the changes of every instance to the local event streams, they end on shutdown
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+
+		eventUsecase.Run(shutdownCtx)
+	}()
 
 	// Initialize Handlers
 	http.NewUserHandler(router, userUsecase)
 	http.NewAuthHandler(router, authUsecase)
 	http.NewSubjectHandler(router, subjectUsecase)
+	http.NewPrivacyHandler(router, privacyUsecase)
+	http.NewBatchHandler(router, batchUsecase)
+	graphql.NewGraphQLHandler(router, userUsecase, subjectUsecase)
+	http.NewEventHandler(router, eventUsecase, func(origin string) bool {
+		return config.AllowsOrigin(corsConfig, origin)
+	})
+
+	// Serve uploaded files when they are stored locally
+	if local, ok := fileStorage.(*storage.LocalStorage); ok {
+		router.Static(local.MountPath(), local.Dir)
+	}
+
+	// Liveness and readiness probes, postgres is the only dependency the API cannot serve without
+	healthChecker := health.NewChecker(config.EnvDuration("HEALTH_CHECK_TIMEOUT", time.Second),
+		health.Check{Name: "postgres", Critical: true, Ping: health.Postgres(client)},
+		health.Check{Name: "redis", Ping: health.Redis(redisClient)},
+		health.Check{Name: "rabbitmq", Ping: health.RabbitMQ(mqConn)},
+	)
+	http.NewHealthHandler(router, healthChecker)
 
 	// Swagger
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	fmt.Printf("☄️  Swagger UI available at: http://localhost:%s/swagger/index.html ☄️\n\n", port)
 
-	router.Run(":" + port)
+	server := config.NewServer(":"+port, router)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, nethttp.ErrServerClosed) {
+			slog.Error("Server stopped unexpectedly", "error", err)
+			stop()
+		}
+	}()
+
+	// Runtime metrics, including calls to deprecated routes. They name the command line and memory of the process,
+	// so they are served on their own listener, DEBUG_ADDR (default loopback only) is never exposed publicly
+	debugAddr := os.Getenv("DEBUG_ADDR")
+	if debugAddr == "" {
+		debugAddr = "127.0.0.1:6060"
+	}
+	debugMux := nethttp.NewServeMux()
+	debugMux.Handle("GET /debug/vars", expvar.Handler())
+	debugServer := config.NewServer(debugAddr, debugMux)
+	go func() {
+		if err := debugServer.ListenAndServe(); err != nil && !errors.Is(err, nethttp.ErrServerClosed) {
+			slog.Error("Debug server stopped unexpectedly", "addr", debugAddr, "error", err)
+		}
+	}()
+
+	// gRPC for the other services, same usecases and health as the HTTP API
+	grpcServer := grpcdelivery.NewServer(healthChecker, config.EnvDuration("GRPC_HEALTH_INTERVAL", 10*time.Second), userUsecase, subjectUsecase, authUsecase, tenantUsecase)
+	grpcListener, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		slog.Error("Failed to listen for gRPC", "error", err)
+		os.Exit(1)
+	}
+	go func() {
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			slog.Error("gRPC server stopped unexpectedly", "error", err)
+			stop()
+		}
+	}()
+	slog.Info("gRPC server listening", "port", grpcPort)
+
+	<-shutdownCtx.Done()
+	slog.Info("Shutting down, draining in-flight requests")
+
+	// Report not ready first and keep serving while load balancers take the instance out
+	healthChecker.SetDraining()
+	time.Sleep(config.EnvDuration("SHUTDOWN_DELAY", 5*time.Second))
+
+	// Stop accepting connections and wait for the requests in flight
+	drainCtx, cancel := context.WithTimeout(context.Background(), config.EnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second))
+	defer cancel()
+	if err := server.Shutdown(drainCtx); err != nil {
+		slog.Error("Failed to drain in-flight requests", "error", err)
+	}
+	grpcServer.Shutdown(drainCtx)
+	if err := debugServer.Shutdown(drainCtx); err != nil {
+		slog.Error("Failed to stop the debug server", "error", err)
+	}
+	jobs.Wait()
+
+	// Close the stores last, nothing uses them anymore
+	if err := client.Prisma.Disconnect(); err != nil {
+		slog.Error("Failed to disconnect from database", "error", err)
+	}
+	pool.Close()
+	if err := cache.CloseRedis(); err != nil {
+		slog.Error("Failed to disconnect from Redis", "error", err)
+	}
+	if mqConn != nil {
+		if err := mqConn.Close(); err != nil {
+			slog.Error("Failed to disconnect from RabbitMQ", "error", err)
+		}
+	}
+
+	slog.Info("Server stopped")
 }
@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"context"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// every event is appended to this stream, which is what clients resume from
+	EVENT_STREAM_KEY = "events:stream"
+	// and published on this channel, which is how every instance hears about it
+	EVENT_CHANNEL = "events"
+)
+
+// events kept for resuming, older ones are trimmed
+var EVENT_HISTORY_SIZE = 10000
+
+// the stream append and the publish happen together, so a subscriber never sees an
+// event that cannot be resumed from. The message is "<stream id> <payload>".
+var publishEventScript = redis.NewScript(`
+local id = redis.call("XADD", KEYS[1], "MAXLEN", "~", ARGV[1], "*", "event", ARGV[2])
+redis.call("PUBLISH", ARGV[3], id .. " " .. ARGV[2])
+return id
+`)
+
+// NOTE - appends the payload to the event history and publishes it, returns its id
+func PublishEvent(ctx context.Context, payload []byte) (string, error) {
+	return publishEventScript.Run(ctx, redisClient, []string{EVENT_STREAM_KEY}, EVENT_HISTORY_SIZE, payload, EVENT_CHANNEL).Text()
+}
+
+// NOTE - payloads of up to count events after lastID, oldest first
+func EventsSince(ctx context.Context, lastID string, count int64) ([]redis.XMessage, error) {
+	return redisClient.XRangeN(ctx, EVENT_STREAM_KEY, "("+lastID, "+", count).Result()
+}
+
+// NOTE - subscription to the event channel, close it when done
+func SubscribeEvents(ctx context.Context) *redis.PubSub {
+	return redisClient.Subscribe(ctx, EVENT_CHANNEL)
+}
+
+// NOTE - splits a published message into the event id and payload
+func SplitEventMessage(message string) (string, string, bool) {
+	id, payload, found := strings.Cut(message, " ")
+	return id, payload, found
+}
@@ -0,0 +1,201 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const RATE_LIMIT_KEY = "ratelimit:"
+
+// RateLimitResult is the outcome of one attempt against a sliding window.
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// time until the oldest counted request leaves the window
+	Reset time.Duration
+}
+
+// RateLimiter counts requests per key in a sliding window of the given size.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error)
+}
+
+// NOTE - picks the limiter store from RATE_LIMIT_STORE, redis (default) shares limits across instances and
+// counts in memory while redis fails
+func NewRateLimiter() RateLimiter {
+	if os.Getenv("RATE_LIMIT_STORE") == "memory" {
+		return NewMemoryRateLimiter()
+	}
+	return NewFallbackRateLimiter(NewRedisRateLimiter(redisClient), NewMemoryRateLimiter())
+}
+
+type fallbackRateLimiter struct {
+	primary  RateLimiter
+	fallback RateLimiter
+}
+
+// NOTE - limiter counting in primary, and in fallback for every attempt primary fails on. Limits then
+// hold per instance instead of across them, which still beats letting every request through
+func NewFallbackRateLimiter(primary, fallback RateLimiter) RateLimiter {
+	return &fallbackRateLimiter{primary: primary, fallback: fallback}
+}
+
+func (l *fallbackRateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	result, err := l.primary.Allow(ctx, key, limit, window)
+	if err == nil {
+		return result, nil
+	}
+
+	slog.WarnContext(ctx, "Rate limiter store unavailable, counting in memory", "error", err)
+	return l.fallback.Allow(ctx, key, limit, window)
+}
+
+// sliding window log kept in a sorted set scored by milliseconds. The redis clock is
+// used so instances with drifting clocks still agree on the window.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+redis.call("ZREMRANGEBYSCORE", key, 0, now - window)
+local count = redis.call("ZCARD", key)
+
+local allowed = 0
+if count < limit then
+	redis.call("ZADD", key, now, now .. "-" .. time[2] .. "-" .. math.random(1000000))
+	count = count + 1
+	allowed = 1
+end
+redis.call("PEXPIRE", key, window)
+
+local oldest = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+local reset = window
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+
+return {allowed, count, reset}
+`)
+
+type redisRateLimiter struct {
+	client *redis.Client
+}
+
+// NOTE - limiter shared by every instance through redis
+func NewRedisRateLimiter(client *redis.Client) RateLimiter {
+	return &redisRateLimiter{client: client}
+}
+
+func (l *redisRateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	values, err := slidingWindowScript.Run(ctx, l.client, []string{RATE_LIMIT_KEY + key}, limit, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	return RateLimitResult{
+		Allowed:   values[0] == 1,
+		Limit:     limit,
+		Remaining: max(limit-int(values[1]), 0),
+		Reset:     time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
+
+type memoryWindow struct {
+	hits   []time.Time
+	window time.Duration
+}
+
+type memoryRateLimiter struct {
+	mu        sync.Mutex
+	windows   map[string]*memoryWindow
+	lastSweep time.Time
+}
+
+// NOTE - in process limiter for single node deployments and tests
+func NewMemoryRateLimiter() RateLimiter {
+	return &memoryRateLimiter{windows: map[string]*memoryWindow{}, lastSweep: time.Now()}
+}
+
+func (l *memoryRateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	w, ok := l.windows[key]
+	if !ok {
+		w = &memoryWindow{}
+		l.windows[key] = w
+	}
+	w.window = window
+
+	cutoff := now.Add(-window)
+	i := 0
+	for i < len(w.hits) && !w.hits[i].After(cutoff) {
+		i++
+	}
+	w.hits = w.hits[i:]
+
+	allowed := len(w.hits) < limit
+	if allowed {
+		w.hits = append(w.hits, now)
+	}
+
+	reset := window
+	if len(w.hits) > 0 {
+		reset = w.hits[0].Add(window).Sub(now)
+	}
+
+	return RateLimitResult{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: max(limit-len(w.hits), 0),
+		Reset:     reset,
+	}, nil
+}
+
+// NOTE - drops keys whose window has fully expired, at most once a minute
+func (l *memoryRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for key, w := range l.windows {
+		if len(w.hits) == 0 || !w.hits[len(w.hits)-1].After(now.Add(-w.window)) {
+			delete(l.windows, key)
+		}
+	}
+}
+
+// NOTE - parses a "limit/window" value such as "100/1m", ok is false when it is missing or malformed
+func ParseRateLimit(value string) (int, time.Duration, bool) {
+	rawLimit, rawWindow, found := strings.Cut(value, "/")
+	if !found {
+		return 0, 0, false
+	}
+
+	limit, err := strconv.Atoi(rawLimit)
+	if err != nil || limit <= 0 {
+		return 0, 0, false
+	}
+
+	window, err := time.ParseDuration(rawWindow)
+	if err != nil || window <= 0 {
+		return 0, 0, false
+	}
+
+	return limit, window, true
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// NOTE - limiter on an in-memory redis whose clock the test moves
+func testRedisLimiter(t *testing.T) (RateLimiter, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisRateLimiter(client), server
+}
+
+// NOTE - attempts key against a limit of 2 per window and reports which were allowed
+func attempt(t *testing.T, limiter RateLimiter, window time.Duration, n int) []bool {
+	t.Helper()
+
+	allowed := make([]bool, n)
+	for i := range allowed {
+		result, err := limiter.Allow(context.Background(), "read:ip:1", 2, window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		allowed[i] = result.Allowed
+	}
+	return allowed
+}
+
+func TestRedisRateLimiterSlidesTheWindow(t *testing.T) {
+	limiter, server := testRedisLimiter(t)
+	start := time.Date(2026, time.October, 19, 8, 0, 0, 0, time.UTC)
+
+	server.SetTime(start)
+	attempt(t, limiter, time.Minute, 1)
+	server.SetTime(start.Add(40 * time.Second))
+	if got := attempt(t, limiter, time.Minute, 2); !got[0] || got[1] {
+		t.Fatalf("second and third request allowed %v, want the third refused", got)
+	}
+
+	// the first request left the window, the second still counts
+	server.SetTime(start.Add(61 * time.Second))
+	if got := attempt(t, limiter, time.Minute, 2); !got[0] || got[1] {
+		t.Errorf("after the first request expired allowed %v, want one more", got)
+	}
+}
+
+func TestRedisRateLimiterReportsRemainingAndReset(t *testing.T) {
+	limiter, server := testRedisLimiter(t)
+	start := time.Date(2026, time.October, 19, 8, 0, 0, 0, time.UTC)
+	server.SetTime(start)
+
+	first, err := limiter.Allow(context.Background(), "read:ip:1", 2, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.Allowed || first.Limit != 2 || first.Remaining != 1 || first.Reset != time.Minute {
+		t.Errorf("first request: %+v", first)
+	}
+
+	server.SetTime(start.Add(20 * time.Second))
+	limiter.Allow(context.Background(), "read:ip:1", 2, time.Minute)
+	refused, err := limiter.Allow(context.Background(), "read:ip:1", 2, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a slot frees up when the oldest request leaves the window
+	if refused.Allowed || refused.Remaining != 0 || refused.Reset != 40*time.Second {
+		t.Errorf("refused request: %+v", refused)
+	}
+}
+
+func TestMemoryRateLimiterSlidesTheWindow(t *testing.T) {
+	const window = 200 * time.Millisecond
+	limiter := NewMemoryRateLimiter()
+
+	attempt(t, limiter, window, 1)
+	time.Sleep(window / 2)
+	if got := attempt(t, limiter, window, 2); !got[0] || got[1] {
+		t.Fatalf("second and third request allowed %v, want the third refused", got)
+	}
+
+	time.Sleep(window/2 + 20*time.Millisecond)
+	if got := attempt(t, limiter, window, 2); !got[0] || got[1] {
+		t.Errorf("after the first request expired allowed %v, want one more", got)
+	}
+}
+
+func TestFallbackRateLimiterCountsInMemoryWhileRedisFails(t *testing.T) {
+	redisLimiter, server := testRedisLimiter(t)
+	limiter := NewFallbackRateLimiter(redisLimiter, NewMemoryRateLimiter())
+
+	server.Close()
+	if got := attempt(t, limiter, time.Minute, 3); !got[0] || !got[1] || got[2] {
+		t.Errorf("allowed %v with redis down, want the limit kept in memory", got)
+	}
+}
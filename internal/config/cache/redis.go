@@ -9,13 +9,22 @@ import (
 )
 
 const (
-	USER_CACHE_KEY    = "users:"
-	SUBJECT_CACHE_KEY = "subjects:"
+	USER_CACHE_KEY       = "users:"
+	SUBJECT_CACHE_KEY    = "subjects:"
+	IDEMPOTENCY_KEY      = "idempotency:"
+	ETAG_SUFFIX          = ":etag"
+	PROJECTION_SEPARATOR = "|"
+	PERSISTED_QUERY_KEY  = "graphql:apq:"
+	// tenants looked up by host and API key, shared by every tenant
+	TENANT_CACHE_KEY = "tenants:"
+	// prefix of everything cached for one tenant, see tenantKey
+	TENANT_NAMESPACE = "tenant:"
 )
 
 var (
 	USER_CACHE_KEY_TTL    = 3600
 	SUBJECT_CACHE_KEY_TTL = 3600
+	TENANT_CACHE_KEY_TTL  = 300
 )
 
 var redisClient *redis.Client
@@ -36,6 +45,11 @@ func ConnectRedis() {
 	log.Println("Connected to Redis successfully")
 }
 
+// NOTE - closes the redis connections, call it once nothing uses the cache anymore
+func CloseRedis() error {
+	return redisClient.Close()
+}
+
 func GetRedisClient() *redis.Client {
 	return redisClient
 }
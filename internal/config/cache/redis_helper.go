@@ -2,13 +2,26 @@ package cache
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"sample-project/internal/entity"
+	"sample-project/internal/utils"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// NOTE - key as stored in redis. Inside a tenant it is namespaced to the tenant, so no
+// tenant ever reads, overwrites or purges what another one cached under the same key
+func tenantKey(ctx context.Context, key string) string {
+	if id, ok := entity.TenantID(ctx); ok {
+		return fmt.Sprintf("%s%d:%s", TENANT_NAMESPACE, id, key)
+	}
+	return key
+}
+
 func Get(ctx context.Context, key string) (string, error) {
-	value, err := redisClient.Get(ctx, key).Result()
+	value, err := redisClient.Get(ctx, tenantKey(ctx, key)).Result()
 	if err == redis.Nil {
 		return "", nil
 	} else if err != nil {
@@ -18,6 +31,7 @@ func Get(ctx context.Context, key string) (string, error) {
 }
 
 func Set(ctx context.Context, key string, value string, ttl int) error {
+	key = tenantKey(ctx, key)
 	if ttl > 0 {
 		return redisClient.Set(ctx, key, value, 0).Err()
 	}
@@ -25,11 +39,28 @@ func Set(ctx context.Context, key string, value string, ttl int) error {
 }
 
 func Del(ctx context.Context, key string) error {
-	return redisClient.Del(ctx, key).Err()
+	key = tenantKey(ctx, key)
+	return redisClient.Del(ctx, key, key+ETAG_SUFFIX).Err()
+}
+
+// NOTE - key a projected read is cached under, the key itself when nothing was projected
+func ProjectedKey(key, projection string) string {
+	if projection == "" {
+		return key
+	}
+	return key + PROJECTION_SEPARATOR + projection
+}
+
+// NOTE - removes a cached resource together with every projection of it
+func DelWithProjections(ctx context.Context, key string) error {
+	if err := Del(ctx, key); err != nil {
+		return err
+	}
+	return DelWithPattern(ctx, key+PROJECTION_SEPARATOR+"*")
 }
 
 func DelWithPattern(ctx context.Context, pattern string) error {
-	iter := redisClient.Scan(ctx, 0, pattern, 0).Iterator()
+	iter := redisClient.Scan(ctx, 0, tenantKey(ctx, pattern), 0).Iterator()
 	for iter.Next(ctx) {
 		err := redisClient.Del(ctx, iter.Val()).Err()
 		if err != nil {
@@ -43,3 +74,26 @@ func DelWithPattern(ctx context.Context, pattern string) error {
 
 	return nil
 }
+
+func SetWithTTL(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return redisClient.Set(ctx, tenantKey(ctx, key), value, ttl).Err()
+}
+
+func SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	return redisClient.SetNX(ctx, tenantKey(ctx, key), value, ttl).Result()
+}
+
+// NOTE - caches value together with its entity tag, so conditional requests can be answered from redis alone
+func SetWithETag(ctx context.Context, key string, value string, ttl time.Duration) error {
+	key = tenantKey(ctx, key)
+	pipe := redisClient.TxPipeline()
+	pipe.Set(ctx, key, value, ttl)
+	pipe.Set(ctx, key+ETAG_SUFFIX, utils.ContentETag([]byte(value)), ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// NOTE - entity tag of a cached value, empty when the value is not cached
+func GetETag(ctx context.Context, key string) (string, error) {
+	return Get(ctx, key+ETAG_SUFFIX)
+}
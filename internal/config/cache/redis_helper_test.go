@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"sample-project/internal/entity"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestDelWithProjectionsDropsEveryProjectionOfOneKey(t *testing.T) {
+	server := miniredis.RunT(t)
+	t.Setenv("REDIS_URL", "redis://"+server.Addr())
+	ConnectRedis()
+	t.Cleanup(func() { CloseRedis() })
+
+	ctx := entity.WithTenant(context.Background(), 1)
+	key := USER_CACHE_KEY + "1"
+	kept := []string{USER_CACHE_KEY + "10", ProjectedKey(USER_CACHE_KEY+"10", "fields=id")}
+	for _, k := range append([]string{key, ProjectedKey(key, "fields=id,name"), ProjectedKey(key, "include=subject")}, kept...) {
+		if err := Set(ctx, k, "{}", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the same user id of another school
+	if err := Set(entity.WithTenant(context.Background(), 2), ProjectedKey(key, "fields=id"), "{}", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if ProjectedKey(key, "") != key {
+		t.Errorf("a read of every field is cached under %q", ProjectedKey(key, ""))
+	}
+	if err := DelWithProjections(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(server.Keys()); got != len(kept)+1 {
+		t.Errorf("left keys %v, want the ones of user 10 and of the other school", server.Keys())
+	}
+	for _, k := range kept {
+		if value, _ := Get(ctx, k); value == "" {
+			t.Errorf("%s was removed", k)
+		}
+	}
+}
@@ -1,11 +1,14 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
 	"sample-project/prisma/db"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 )
 
@@ -33,4 +36,47 @@ func ConnectDB() (*db.PrismaClient, error) {
 
 }
 
+// NOTE - pool of plain Postgres connections to DATABASE_URL, for the writes that need an interactive
+// transaction prisma does not have. Call it after ConnectDB, which loads the .env file
+func ConnectPool(ctx context.Context) (*pgxpool.Pool, error) {
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		return nil, ErrMissingDatabaseURL
+	}
+
+	poolURL, err := poolURL(dbURL)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.New(ctx, poolURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
+}
+
+// NOTE - DATABASE_URL without the parameters only prisma understands, Postgres would refuse them as
+// settings. The prisma schema parameter becomes the search_path
+func poolURL(dbURL string) (string, error) {
+	u, err := url.Parse(dbURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid DATABASE_URL: %w", err)
+	}
+
+	query := u.Query()
+	if schema := query.Get("schema"); schema != "" {
+		query.Set("search_path", schema)
+	}
+	for _, param := range []string{"schema", "connection_limit", "pool_timeout", "socket_timeout", "pgbouncer", "statement_cache_size"} {
+		query.Del(param)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
 var ErrMissingDatabaseURL = fmt.Errorf("DATABASE_URL is required but not set")
@@ -0,0 +1,21 @@
+package config
+
+import "testing"
+
+func TestPoolURLDropsThePrismaParameters(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "postgresql://app:secret@db:5432/school?schema=public", want: "postgresql://app:secret@db:5432/school?search_path=public"},
+		{url: "postgresql://app@db/school?connection_limit=5&pool_timeout=10&sslmode=disable", want: "postgresql://app@db/school?sslmode=disable"},
+		{url: "postgresql://app@db/school", want: "postgresql://app@db/school"},
+	}
+
+	for _, tt := range tests {
+		got, err := poolURL(tt.url)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %q, %v, want %q", tt.url, got, err, tt.want)
+		}
+	}
+}
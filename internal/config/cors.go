@@ -0,0 +1,89 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+)
+
+// headers the API sends that browsers have to be allowed to read
+var corsExposeHeaders = []string{
+	"Content-Length", "ETag", "Last-Modified", "X-Request-ID", "Retry-After",
+	"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy",
+	"Idempotent-Replayed", "Deprecation", "Sunset", "Link",
+}
+
+// NOTE - CORS policy from the environment:
+// CORS_ALLOW_ORIGINS (comma separated, `https://*.example.com` allows every subdomain, `*` allows any origin),
+// CORS_ALLOW_METHODS, CORS_ALLOW_HEADERS, CORS_ALLOW_CREDENTIALS and CORS_MAX_AGE.
+func CORSConfig() cors.Config {
+	config := cors.Config{
+		AllowMethods:     envList("CORS_ALLOW_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
+		AllowHeaders:     envList("CORS_ALLOW_HEADERS", []string{"Origin", "Content-Type", "Authorization", "If-Match", "If-None-Match", "If-Modified-Since", "X-Request-ID", "X-API-Key", "Idempotency-Key", "Last-Event-ID"}),
+		ExposeHeaders:    corsExposeHeaders,
+		AllowCredentials: true,
+		AllowWildcard:    true,
+		MaxAge:           EnvDuration("CORS_MAX_AGE", 12*time.Hour),
+	}
+
+	if raw := os.Getenv("CORS_ALLOW_CREDENTIALS"); raw != "" {
+		if allow, err := strconv.ParseBool(raw); err == nil {
+			config.AllowCredentials = allow
+		}
+	}
+
+	origins := envList("CORS_ALLOW_ORIGINS", []string{"*"})
+	for _, origin := range origins {
+		if origin != "*" {
+			continue
+		}
+
+		// browsers reject credentials with a wildcard origin, so any origin means no credentials
+		if config.AllowCredentials && os.Getenv("CORS_ALLOW_CREDENTIALS") != "" {
+			slog.Warn("CORS_ALLOW_ORIGINS allows any origin, credentials are disabled")
+		}
+		config.AllowAllOrigins = true
+		config.AllowCredentials = false
+		return config
+	}
+
+	config.AllowOrigins = origins
+	return config
+}
+
+// NOTE - reports whether the policy allows origin, for requests the cors middleware does not see like WebSocket upgrades
+func AllowsOrigin(config cors.Config, origin string) bool {
+	if config.AllowAllOrigins {
+		return true
+	}
+
+	for _, allowed := range config.AllowOrigins {
+		if allowed == origin {
+			return true
+		}
+		if prefix, suffix, found := strings.Cut(allowed, "*"); found && config.AllowWildcard &&
+			len(origin) >= len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// NOTE - comma separated list from the environment, fallback when it is unset
+func envList(key string, fallback []string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	if len(values) == 0 {
+		return fallback
+	}
+	return values
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSConfigFromTheEnvironment(t *testing.T) {
+	tests := []struct {
+		name        string
+		origins     string
+		credentials string
+		all         bool
+		allowed     []string
+		withCreds   bool
+	}{
+		{name: "default", all: true},
+		{name: "any origin asks for credentials", origins: "*", credentials: "true", all: true},
+		{name: "listed origins", origins: "https://app.example.com, https://*.school.la", allowed: []string{"https://app.example.com", "https://*.school.la"}, withCreds: true},
+		{name: "listed origins without credentials", origins: "https://app.example.com", credentials: "false", allowed: []string{"https://app.example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("CORS_ALLOW_ORIGINS", tt.origins)
+		t.Setenv("CORS_ALLOW_CREDENTIALS", tt.credentials)
+		config := CORSConfig()
+
+		if config.AllowAllOrigins != tt.all || config.AllowCredentials != tt.withCreds {
+			t.Errorf("%s: all origins %v, credentials %v", tt.name, config.AllowAllOrigins, config.AllowCredentials)
+		}
+		if len(config.AllowOrigins) != len(tt.allowed) {
+			t.Errorf("%s: origins are %v, want %v", tt.name, config.AllowOrigins, tt.allowed)
+			continue
+		}
+		for i := range tt.allowed {
+			if config.AllowOrigins[i] != tt.allowed[i] {
+				t.Errorf("%s: origins are %v, want %v", tt.name, config.AllowOrigins, tt.allowed)
+			}
+		}
+		if err := config.Validate(); err != nil {
+			t.Errorf("%s: cors refuses the policy: %v", tt.name, err)
+		}
+	}
+}
+
+func TestWildcardSubdomainsOnlyMatchSubdomains(t *testing.T) {
+	t.Setenv("CORS_ALLOW_ORIGINS", "https://*.school.la,https://admin.example.com")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "")
+	config := CORSConfig()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(cors.New(config))
+	router.GET("/api/v2/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{origin: "https://a.school.la", allowed: true},
+		{origin: "https://b.c.school.la", allowed: true},
+		{origin: "https://admin.example.com", allowed: true},
+		{origin: "https://school.la"},
+		{origin: "http://a.school.la"},
+		{origin: "https://evilschool.la"},
+		{origin: "https://a.school.la.evil.com"},
+		{origin: "https://example.com"},
+	}
+
+	for _, tt := range tests {
+		if got := AllowsOrigin(config, tt.origin); got != tt.allowed {
+			t.Errorf("%s: AllowsOrigin is %v, want %v", tt.origin, got, tt.allowed)
+		}
+
+		req := httptest.NewRequest(http.MethodOptions, "/api/v2/users", nil)
+		req.Header.Add("Origin", tt.origin)
+		req.Header.Add("Access-Control-Request-Method", http.MethodGet)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		allowOrigin := w.Header().Get("Access-Control-Allow-Origin")
+		if tt.allowed && (allowOrigin != tt.origin || w.Header().Get("Access-Control-Allow-Credentials") != "true") {
+			t.Errorf("%s: preflight got %d with headers %v", tt.origin, w.Code, w.Header())
+		}
+		if !tt.allowed && allowOrigin != "" {
+			t.Errorf("%s: preflight allowed %q", tt.origin, allowOrigin)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sample-project/internal/entity"
+	"sample-project/prisma/db"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/redis/go-redis/v9"
+)
+
+// Check pings one dependency, Critical ones decide whether the service is ready.
+type Check struct {
+	Name     string
+	Critical bool
+	Ping     func(ctx context.Context) error
+}
+
+// Checker runs the dependency checks for the readiness probe.
+type Checker struct {
+	checks   []Check
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+// NOTE - checker pinging every dependency within timeout
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, timeout: timeout}
+}
+
+// NOTE - marks the service as shutting down, it reports not ready from now on
+func (h *Checker) SetDraining() {
+	h.draining.Store(true)
+}
+
+// NOTE - pings every dependency concurrently, ready is false when draining or a critical dependency is down
+func (h *Checker) Ready(ctx context.Context) (entity.HealthReport, bool) {
+	if h.draining.Load() {
+		return entity.HealthReport{Status: entity.HealthDraining}, false
+	}
+
+	dependencies := make([]entity.DependencyHealth, len(h.checks))
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dependencies[i] = h.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := entity.HealthReport{Status: entity.HealthOK, Dependencies: dependencies}
+	for _, dependency := range dependencies {
+		if dependency.Status == "up" {
+			continue
+		}
+		if dependency.Critical {
+			report.Status = entity.HealthUnavailable
+			return report, false
+		}
+		report.Status = entity.HealthDegraded
+	}
+	return report, true
+}
+
+func (h *Checker) run(ctx context.Context, check Check) entity.DependencyHealth {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Ping(ctx)
+
+	result := entity.DependencyHealth{
+		Name:      check.Name,
+		Critical:  check.Critical,
+		Status:    "up",
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = "down"
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// NOTE - runs a trivial query through the prisma query engine
+func Postgres(client *db.PrismaClient) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var result []map[string]interface{}
+		return client.Prisma.QueryRaw("SELECT 1").Exec(ctx, &result)
+	}
+}
+
+// NOTE - PING against redis
+func Redis(client *redis.Client) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}
+}
+
+// NOTE - opens and closes a channel, which needs a round trip to the broker. conn is nil when it never connected
+func RabbitMQ(conn *amqp091.Connection) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if conn == nil {
+			return errors.New("not connected")
+		}
+		if conn.IsClosed() {
+			return errors.New("connection closed")
+		}
+
+		// amqp calls take no context, so the timeout is enforced around them
+		done := make(chan error, 1)
+		go func() {
+			channel, err := conn.Channel()
+			if err == nil {
+				err = channel.Close()
+			}
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
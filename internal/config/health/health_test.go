@@ -0,0 +1,93 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sample-project/internal/entity"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func up(ctx context.Context) error { return nil }
+
+func down(ctx context.Context) error { return errors.New("connection refused") }
+
+// NOTE - a dependency that only answers once the check gave up on it
+func hanging(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestReadyTellsCriticalFromDegradedDependencies(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []Check
+		status string
+		ready  bool
+	}{
+		{name: "all up", checks: []Check{{Name: "postgres", Critical: true, Ping: up}, {Name: "redis", Ping: up}}, status: entity.HealthOK, ready: true},
+		{name: "cache down", checks: []Check{{Name: "postgres", Critical: true, Ping: up}, {Name: "redis", Ping: down}}, status: entity.HealthDegraded, ready: true},
+		{name: "database down", checks: []Check{{Name: "postgres", Critical: true, Ping: down}, {Name: "redis", Ping: up}}, status: entity.HealthUnavailable},
+		{name: "database hangs", checks: []Check{{Name: "postgres", Critical: true, Ping: hanging}}, status: entity.HealthUnavailable},
+		{name: "no dependencies", status: entity.HealthOK, ready: true},
+	}
+
+	for _, tt := range tests {
+		start := time.Now()
+		report, ready := NewChecker(20*time.Millisecond, tt.checks...).Ready(context.Background())
+
+		if report.Status != tt.status || ready != tt.ready {
+			t.Errorf("%s: got %s, ready %v, want %s, ready %v", tt.name, report.Status, ready, tt.status, tt.ready)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("%s: took %s, the timeout is 20ms", tt.name, elapsed)
+		}
+		if len(report.Dependencies) != len(tt.checks) {
+			t.Errorf("%s: reported %+v", tt.name, report.Dependencies)
+			continue
+		}
+		for i, dependency := range report.Dependencies {
+			if dependency.Name != tt.checks[i].Name || dependency.Critical != tt.checks[i].Critical || dependency.LatencyMS < 0 {
+				t.Errorf("%s: dependency %d is %+v", tt.name, i, dependency)
+			}
+			if (dependency.Status == "down") != (dependency.Error != "") {
+				t.Errorf("%s: %s is %s with error %q", tt.name, dependency.Name, dependency.Status, dependency.Error)
+			}
+		}
+	}
+}
+
+func TestDrainingIsNeverReady(t *testing.T) {
+	checker := NewChecker(time.Second, Check{Name: "postgres", Critical: true, Ping: up})
+	if _, ready := checker.Ready(context.Background()); !ready {
+		t.Fatal("not ready before draining")
+	}
+
+	checker.SetDraining()
+	report, ready := checker.Ready(context.Background())
+	if ready || report.Status != entity.HealthDraining || len(report.Dependencies) != 0 {
+		t.Errorf("got %+v, ready %v while draining", report, ready)
+	}
+}
+
+func TestDependencyPings(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	ctx := context.Background()
+
+	if err := Redis(client)(ctx); err != nil {
+		t.Errorf("redis is up: %v", err)
+	}
+	server.Close()
+	if err := Redis(client)(ctx); err == nil {
+		t.Error("redis is down but the ping succeeded")
+	}
+
+	if err := RabbitMQ(nil)(ctx); err == nil {
+		t.Error("rabbitmq never connected but the ping succeeded")
+	}
+}
@@ -0,0 +1,61 @@
+package locale
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+//go:embed emails/*.tmpl
+var emailTemplates embed.FS
+
+// layouts of the emails, their text comes from the catalogs. The placeholder t is replaced per rendering
+var emails = template.Must(template.New("emails").Funcs(template.FuncMap{
+	"t": func(string) (string, error) { return "", nil },
+}).ParseFS(emailTemplates, "emails/*.tmpl"))
+
+// Email is an email rendered in one language, ready for a mailer.
+type Email struct {
+	Subject string
+	Text    string
+}
+
+// NOTE - renders the email template emails/<name>.tmpl in the language of ctx. Subject and text come from the
+// catalogs under email.<name>, filled in with data like the error messages, and a Count in data picks the
+// plural forms. A message missing in the language falls back to English, one missing in every catalog is an error
+func RenderEmail(ctx context.Context, name string, data map[string]interface{}) (*Email, error) {
+	layout := emails.Lookup(name + ".tmpl")
+	if layout == nil {
+		return nil, fmt.Errorf("locale: no email template %s", name)
+	}
+
+	translate := func(id string) (string, error) {
+		message := Localize(ctx, id, data, "")
+		if message == "" {
+			return "", fmt.Errorf("locale: no catalog has the message %s", id)
+		}
+		return message, nil
+	}
+
+	// a clone per rendering, so concurrent ones translate into their own language
+	tmpl, err := layout.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("locale: email %s: %w", name, err)
+	}
+	tmpl.Funcs(template.FuncMap{"t": translate})
+
+	subject, err := translate("email." + name + ".subject")
+	if err != nil {
+		return nil, err
+	}
+
+	var text bytes.Buffer
+	if err := tmpl.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("locale: email %s: %w", name, err)
+	}
+
+	return &Email{Subject: subject, Text: strings.TrimSpace(text.String()) + "\n"}, nil
+}
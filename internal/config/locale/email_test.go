@@ -0,0 +1,62 @@
+package locale
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestRenderEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		language language.Tag
+		data     map[string]interface{}
+		subject  string
+		text     string
+	}{
+		{
+			name:     "english with reason and plural",
+			language: language.English,
+			data:     map[string]interface{}{"Name": "Noy", "School": "Lincoln", "Reason": "unpaid fees", "Count": 3},
+			subject:  "Your account was suspended",
+			text:     "Hello Noy,\n\nYour account has been suspended, you cannot log in for now.\nReason: unpaid fees\nIt will be reactivated in 3 days.\n\nThe Lincoln team\n",
+		},
+		{
+			name:     "english singular without reason",
+			language: language.English,
+			data:     map[string]interface{}{"Name": "Noy", "School": "Lincoln", "Count": 1},
+			subject:  "Your account was suspended",
+			text:     "Hello Noy,\n\nYour account has been suspended, you cannot log in for now.\nIt will be reactivated in 1 day.\n\nThe Lincoln team\n",
+		},
+		{
+			name:     "lao",
+			language: language.Lao,
+			data:     map[string]interface{}{"Name": "Noy", "School": "Lincoln"},
+			subject:  "ບັນຊີຂອງທ່ານຖືກລະງັບ",
+			text:     "ສະບາຍດີ Noy,\n\nບັນຊີຂອງທ່ານຖືກລະງັບ, ທ່ານບໍ່ສາມາດເຂົ້າສູ່ລະບົບໄດ້ໃນຕອນນີ້.\n\nທີມງານ Lincoln\n",
+		},
+		{
+			name:     "language without a catalog falls back to english",
+			language: language.French,
+			data:     map[string]interface{}{"Name": "Noy", "School": "Lincoln"},
+			subject:  "Your account was suspended",
+			text:     "Hello Noy,\n\nYour account has been suspended, you cannot log in for now.\n\nThe Lincoln team\n",
+		},
+	}
+
+	for _, tt := range tests {
+		email, err := RenderEmail(WithLanguage(context.Background(), tt.language), "account_suspended", tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if email.Subject != tt.subject || email.Text != tt.text {
+			t.Errorf("%s: got %q\n%q", tt.name, email.Subject, email.Text)
+		}
+	}
+
+	if _, err := RenderEmail(context.Background(), "welcome", nil); err == nil {
+		t.Error("rendered an email without a template")
+	}
+}
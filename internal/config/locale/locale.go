@@ -0,0 +1,118 @@
+// Package locale holds the message catalogs of the API and picks the language of a request.
+// Catalogs live in locales/<language>.json and are keyed by error code, so a client that localizes
+// on its own can use the same keys. Emails are laid out in emails/<name>.tmpl and take their text from the same catalogs.
+package locale
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"sample-project/internal/entity"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+)
+
+//go:embed locales/*.json
+var catalogs embed.FS
+
+// languages with a catalog, the first is the fallback and the language the code is written in
+var supported = []language.Tag{language.English, language.Lao}
+
+var (
+	bundle     = newBundle()
+	matcher    = language.NewMatcher(supported)
+	localizers = newLocalizers()
+)
+
+func newBundle() *i18n.Bundle {
+	bundle := i18n.NewBundle(supported[0])
+	bundle.RegisterUnmarshalFunc("json", json.Unmarshal)
+	for _, tag := range supported {
+		if _, err := bundle.LoadMessageFileFS(catalogs, "locales/"+tag.String()+".json"); err != nil {
+			panic(err)
+		}
+	}
+	return bundle
+}
+
+// a message missing in a catalog falls back to the English one
+func newLocalizers() map[language.Tag]*i18n.Localizer {
+	localizers := make(map[language.Tag]*i18n.Localizer, len(supported))
+	for _, tag := range supported {
+		localizers[tag] = i18n.NewLocalizer(bundle, tag.String(), supported[0].String())
+	}
+	return localizers
+}
+
+type languageKey struct{}
+
+// NOTE - best supported language for an Accept-Language header, English when none of them is supported
+func Negotiate(acceptLanguage string) language.Tag {
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return supported[0]
+	}
+	return supported[index]
+}
+
+// NOTE - returns a copy of ctx carrying the language messages are localized to
+func WithLanguage(ctx context.Context, tag language.Tag) context.Context {
+	return context.WithValue(ctx, languageKey{}, tag)
+}
+
+// NOTE - language stored in ctx, English outside of a request that asked for another one
+func Language(ctx context.Context) language.Tag {
+	if tag, ok := ctx.Value(languageKey{}).(language.Tag); ok {
+		return tag
+	}
+	return supported[0]
+}
+
+// NOTE - message id of the catalogs in the language of ctx, filled in with params. A Count param also picks
+// the plural form. fallback is returned when no catalog has the message
+func Localize(ctx context.Context, id string, params map[string]interface{}, fallback string) string {
+	config := &i18n.LocalizeConfig{MessageID: id, TemplateData: params}
+	if count, ok := params["Count"]; ok {
+		config.PluralCount = count
+	}
+
+	localizer, ok := localizers[Language(ctx)]
+	if !ok {
+		localizer = localizers[supported[0]]
+	}
+
+	message, err := localizer.Localize(config)
+	if err != nil && config.PluralCount != nil {
+		// a message without plural forms has no "one", it is localized without the count picking a form
+		config.PluralCount = nil
+		message, err = localizer.Localize(config)
+	}
+	if err != nil {
+		return fallback
+	}
+	return message
+}
+
+// NOTE - message of a domain error in the language of ctx, its English message when no catalog has it
+func Error(ctx context.Context, err *entity.Error) string {
+	return Localize(ctx, err.LocalizationID(), err.Params, err.Message)
+}
+
+// NOTE - copy of the field errors with their messages in the language of ctx. Field errors without
+// a catalog message keep theirs
+func Fields(ctx context.Context, fields []entity.FieldError) []entity.FieldError {
+	if len(fields) == 0 {
+		return fields
+	}
+
+	localized := make([]entity.FieldError, len(fields))
+	for i, field := range fields {
+		if field.MessageID != "" {
+			field.Message = Localize(ctx, field.MessageID, field.Params, field.Message)
+		}
+		localized[i] = field
+	}
+	return localized
+}
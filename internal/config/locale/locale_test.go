@@ -0,0 +1,20 @@
+package locale
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestLocalizeCountWithoutPluralForms(t *testing.T) {
+	ctx := WithLanguage(context.Background(), language.Lao)
+	params := map[string]interface{}{"Count": 1}
+
+	if got := Localize(ctx, "validation.min", params, "fallback"); got != "ຕ້ອງບໍ່ໜ້ອຍກວ່າ 1" {
+		t.Errorf("got %q", got)
+	}
+	if got := Localize(WithLanguage(ctx, language.English), "validation.min.length", params, "fallback"); got != "must be at least 1 character long" {
+		t.Errorf("got %q", got)
+	}
+}
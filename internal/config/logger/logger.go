@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sample-project/internal/entity"
+	"strings"
+)
+
+type contextKey string
+
+const (
+	requestIDKey contextKey = "request_id"
+	userIDKey    contextKey = "user_id"
+)
+
+// NOTE - installs the JSON logger as the slog default, LOG_LEVEL picks the minimum level (default info)
+func Setup() {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.ToUpper(os.Getenv("LOG_LEVEL")))); err != nil {
+		level = slog.LevelInfo
+	}
+
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})
+	slog.SetDefault(slog.New(&contextHandler{Handler: handler}))
+}
+
+// NOTE - returns a copy of ctx carrying the request id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// NOTE - request id stored in ctx, empty outside of a request
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// NOTE - returns a copy of ctx carrying the authenticated user id
+func WithUserID(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, userIDKey, id)
+}
+
+// NOTE - authenticated user id stored in ctx
+func UserID(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(userIDKey).(int)
+	return id, ok
+}
+
+// contextHandler adds the request, user and tenant id of the context to every record,
+// so the *Context slog calls in usecases and repositories can be traced back to a request.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if id, ok := UserID(ctx); ok {
+		record.AddAttrs(slog.Int("user_id", id))
+	}
+	if id, ok := entity.TenantID(ctx); ok {
+		record.AddAttrs(slog.Int("tenant_id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sample-project/internal/entity"
+	"testing"
+)
+
+func TestRecordsCarryTheIDsOfTheirContext(t *testing.T) {
+	var out bytes.Buffer
+	log := slog.New(&contextHandler{Handler: slog.NewJSONHandler(&out, nil)}).With("component", "test")
+
+	ctx := WithUserID(WithRequestID(context.Background(), "req-1"), 7)
+	ctx = entity.WithTenant(ctx, 3)
+	log.InfoContext(ctx, "user updated")
+	log.InfoContext(context.Background(), "job ran")
+
+	decoder := json.NewDecoder(&out)
+	var inRequest, outside map[string]interface{}
+	if err := decoder.Decode(&inRequest); err != nil {
+		t.Fatal(err)
+	}
+	if err := decoder.Decode(&outside); err != nil {
+		t.Fatal(err)
+	}
+
+	if inRequest["request_id"] != "req-1" || inRequest["user_id"] != float64(7) || inRequest["tenant_id"] != float64(3) || inRequest["component"] != "test" {
+		t.Errorf("record in a request is %v", inRequest)
+	}
+	for _, key := range []string{"request_id", "user_id", "tenant_id"} {
+		if _, ok := outside[key]; ok {
+			t.Errorf("record outside of a request has %s: %v", key, outside)
+		}
+	}
+}
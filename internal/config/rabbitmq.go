@@ -2,7 +2,6 @@ package config
 
 import (
 	"errors"
-	"log"
 	"log/slog"
 	"os"
 
@@ -17,16 +16,16 @@ func RabbitMQConnection() (*amqp091.Connection, error) {
 
 	mqUrl := os.Getenv("MQ_HOST")
 	if mqUrl == "" {
-		log.Fatalf("MQ_HOST environment variable is not set")
-		return nil, errors.New("MQ_HOST environment variable is not set")
+		return nil, ErrMissingMQHost
 	}
 
 	conn, err := amqp091.Dial(mqUrl)
 	if err != nil {
-		log.Fatalf("Failed to connect to RabbitMQ: %v", err)
 		return nil, err
 	}
-	log.Println("Connected to RabbitMQ successfully")
+	slog.Info("Connected to RabbitMQ successfully")
 
 	return conn, nil
 }
+
+var ErrMissingMQHost = errors.New("MQ_HOST environment variable is not set")
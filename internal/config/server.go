@@ -0,0 +1,38 @@
+package config
+
+import (
+	"net/http"
+	"os"
+	"time"
+)
+
+// NOTE - http server for the API, HTTP_READ_TIMEOUT, HTTP_WRITE_TIMEOUT and HTTP_IDLE_TIMEOUT override the timeouts
+func NewServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       EnvDuration("HTTP_READ_TIMEOUT", 15*time.Second),
+		// has to outlast REQUEST_TIMEOUT so a timed out request can still write its error
+		WriteTimeout: EnvDuration("HTTP_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:  EnvDuration("HTTP_IDLE_TIMEOUT", 60*time.Second),
+	}
+}
+
+// NOTE - duration such as "30s" from the environment, fallback when it is missing or malformed
+func EnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// NOTE - date such as "2027-06-30" from the environment, midnight UTC. fallback when it is missing or malformed
+func EnvDate(key string, fallback time.Time) time.Time {
+	value, err := time.Parse(time.DateOnly, os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
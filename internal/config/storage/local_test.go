@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStoragePutAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	s := NewLocalStorage(dir, "/uploads/")
+	ctx := context.Background()
+
+	if err := s.Put(ctx, "avatars/1/64.jpg", strings.NewReader("jpeg"), 4, "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "avatars", "1", "64.jpg"))
+	if err != nil || string(data) != "jpeg" {
+		t.Fatalf("stored %q, %v", data, err)
+	}
+
+	// no temp file is left next to the upload
+	entries, _ := os.ReadDir(filepath.Join(dir, "avatars", "1"))
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the upload", len(entries))
+	}
+
+	if err := s.Delete(ctx, "avatars/1/64.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "avatars", "1", "64.jpg")); !os.IsNotExist(err) {
+		t.Errorf("upload still exists after Delete")
+	}
+	if err := s.Delete(ctx, "avatars/1/64.jpg"); err != nil {
+		t.Errorf("deleting a missing file failed: %v", err)
+	}
+}
+
+func TestLocalStorageURL(t *testing.T) {
+	s := NewLocalStorage(t.TempDir(), "https://api.example.com/uploads/")
+	if got, want := s.URL("avatars/1/original.jpg"), "https://api.example.com/uploads/avatars/1/original.jpg"; got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+	if got := s.MountPath(); got != "/uploads" {
+		t.Errorf("MountPath() = %q, want /uploads", got)
+	}
+}
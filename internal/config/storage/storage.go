@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// NOTE - storage backend for user uploaded files
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// NOTE - picks the storage backend from STORAGE_DRIVER (local or s3)
+func NewStorage() (Storage, error) {
+	switch driver := os.Getenv("STORAGE_DRIVER"); driver {
+	case "", "local":
+		return NewLocalStorage(getEnv("STORAGE_LOCAL_DIR", "uploads"), getEnv("STORAGE_PUBLIC_URL", "/uploads")), nil
+	case "s3":
+		return NewS3Storage(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Region:    os.Getenv("S3_REGION"),
+			UseSSL:    os.Getenv("S3_USE_SSL") != "false",
+			PublicURL: os.Getenv("STORAGE_PUBLIC_URL"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER: %s", driver)
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,88 @@
+package graphql
+
+import (
+	"fmt"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// list fields without a limit argument are assumed to hold this many items
+const estimatedListSize = 20
+
+// analyzer checks a query against the schema before it is executed, so
+// expensive queries are rejected without touching the database.
+type analyzer struct {
+	schema        *ast.Schema
+	maxComplexity int
+}
+
+func newAnalyzer(schema string, maxComplexity int) *analyzer {
+	return &analyzer{
+		schema:        gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphql", Input: schema}),
+		maxComplexity: maxComplexity,
+	}
+}
+
+// NOTE - operation that will run, nil when the query is invalid so the executor reports why
+func (a *analyzer) operation(query, operationName string) (*ast.OperationDefinition, *ast.QueryDocument) {
+	doc, errs := gqlparser.LoadQuery(a.schema, query)
+	if len(errs) > 0 {
+		return nil, nil
+	}
+	return doc.Operations.ForName(operationName), doc
+}
+
+// NOTE - rejects operations that cost more than maxComplexity
+func (a *analyzer) checkComplexity(op *ast.OperationDefinition, variables map[string]interface{}) error {
+	if cost := complexity(op.SelectionSet, variables, 1, 0); cost > a.maxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the limit of %d", cost, a.maxComplexity)
+	}
+	return nil
+}
+
+// every field costs 1 per time it is resolved, a list resolves its children once per item.
+// A limit argument sizes the next list below it, which is how the page types work.
+func complexity(set ast.SelectionSet, variables map[string]interface{}, multiplier, limit int) int {
+	cost := 0
+	for _, selection := range set {
+		switch s := selection.(type) {
+		case *ast.Field:
+			cost += multiplier
+
+			size, next := 1, limit
+			if n := intArgument(s.ArgumentMap(variables)["limit"]); n > 0 {
+				next = min(n, maxPageSize)
+			}
+			if s.Definition != nil && s.Definition.Type.Elem != nil {
+				size, next = estimatedListSize, 0
+				if limit > 0 {
+					size = limit
+				}
+			}
+			cost += complexity(s.SelectionSet, variables, multiplier*size, next)
+		case *ast.InlineFragment:
+			cost += complexity(s.SelectionSet, variables, multiplier, limit)
+		case *ast.FragmentSpread:
+			if s.Definition != nil {
+				cost += complexity(s.Definition.SelectionSet, variables, multiplier, limit)
+			}
+		}
+	}
+	return cost
+}
+
+// literals come as int64, variables decoded from JSON as float64
+func intArgument(value interface{}) int {
+	switch n := value.(type) {
+	case int64:
+		return int(n)
+	case int32:
+		return int(n)
+	case int:
+		return n
+	case float64:
+		return int(n)
+	}
+	return 0
+}
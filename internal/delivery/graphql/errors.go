@@ -0,0 +1,54 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sample-project/internal/config/locale"
+	"sample-project/internal/entity"
+)
+
+// resolverError carries the domain error code to the client in the GraphQL error extensions.
+type resolverError struct {
+	message    string
+	extensions map[string]interface{}
+}
+
+func (e *resolverError) Error() string {
+	return e.message
+}
+
+func (e *resolverError) Extensions() map[string]interface{} {
+	return e.extensions
+}
+
+// NOTE - GraphQL error for err, with the same code and field errors the REST envelope has
+func toGraphQLError(ctx context.Context, err error) error {
+	var domainErr *entity.Error
+	if errors.As(err, &domainErr) {
+		extensions := map[string]interface{}{"code": domainErr.Code}
+		if len(domainErr.Fields) > 0 {
+			extensions["fields"] = locale.Fields(ctx, domainErr.Fields)
+		}
+		return &resolverError{message: locale.Error(ctx, domainErr), extensions: extensions}
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		message := locale.Localize(ctx, "request_timeout", nil, "the request took too long, please try again later")
+		return &resolverError{message: message, extensions: map[string]interface{}{"code": "request_timeout"}}
+	}
+
+	slog.ErrorContext(ctx, "Unhandled GraphQL error", "error", err)
+	message := locale.Localize(ctx, "internal_error", nil, "Something went wrong, please try again later")
+	return &resolverError{message: message, extensions: map[string]interface{}{"code": "internal_error"}}
+}
+
+// NOTE - error of a request that could not be executed, in the GraphQL response shape
+func requestError(code, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"errors": []map[string]interface{}{{
+			"message":    message,
+			"extensions": map[string]interface{}{"code": code},
+		}},
+	}
+}
@@ -0,0 +1,187 @@
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"sample-project/internal/config"
+	"sample-project/internal/config/cache"
+	"sample-project/internal/usecase"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const maxRequestSize = 1 << 20
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// request is a GraphQL request, extensions.persistedQuery follows the Apollo APQ protocol
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    struct {
+		PersistedQuery *struct {
+			Version    int    `json:"version"`
+			SHA256Hash string `json:"sha256Hash"`
+		} `json:"persistedQuery"`
+	} `json:"extensions"`
+}
+
+// NOTE - graphql handler struct
+type GraphQLHandler struct {
+	schema            *graphqlgo.Schema
+	analyzer          *analyzer
+	userUseCase       usecase.UserUseCase
+	subjectUseCase    usecase.SubjectUsecase
+	persistedQueryTTL time.Duration
+}
+
+// NOTE - new graphql handler. GRAPHQL_MAX_DEPTH, GRAPHQL_MAX_COMPLEXITY and
+// GRAPHQL_PERSISTED_QUERY_TTL override the limits
+func NewGraphQLHandler(router *gin.Engine, userUseCase usecase.UserUseCase, subjectUseCase usecase.SubjectUsecase) {
+	root := &resolver{userUseCase: userUseCase, subjectUseCase: subjectUseCase}
+
+	handler := &GraphQLHandler{
+		schema:            graphqlgo.MustParseSchema(schemaSDL, root, graphqlgo.MaxDepth(envInt("GRAPHQL_MAX_DEPTH", 8))),
+		analyzer:          newAnalyzer(schemaSDL, envInt("GRAPHQL_MAX_COMPLEXITY", 5000)),
+		userUseCase:       userUseCase,
+		subjectUseCase:    subjectUseCase,
+		persistedQueryTTL: config.EnvDuration("GRAPHQL_PERSISTED_QUERY_TTL", 7*24*time.Hour),
+	}
+
+	router.GET("/graphql", handler.Serve)
+	router.POST("/graphql", handler.Serve)
+}
+
+// NOTE - graphql endpoint
+// @Summary GraphQL endpoint
+// @Description Runs a GraphQL query or mutation over users and subjects, see internal/delivery/graphql/schema.graphql.
+// @Description Queries are limited in depth and complexity. Persisted queries follow the Apollo APQ protocol:
+// @Description send extensions.persistedQuery.sha256Hash alone and retry with the query on PersistedQueryNotFound.
+// @Description GET only runs queries, mutations must be sent with POST.
+// @Tags graphql
+// @Accept json
+// @Produce json
+// @Param query query string false "GraphQL document (GET)"
+// @Param operationName query string false "Operation to run (GET)"
+// @Param variables query string false "Variables as a JSON object (GET)"
+// @Param extensions query string false "Extensions as a JSON object (GET)"
+// @Param request body object false "{query, operationName, variables, extensions} (POST)"
+// @Success 200 {object} object "GraphQL response, errors are reported in the errors member"
+// @Failure 400 {object} object
+// @Failure 405 {object} object
+// @Router /graphql [get]
+// @Router /graphql [post]
+func (h *GraphQLHandler) Serve(c *gin.Context) {
+	req, ok := h.readRequest(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	if !h.resolvePersistedQuery(ctx, c, &req) {
+		return
+	}
+
+	if op, _ := h.analyzer.operation(req.Query, req.OperationName); op != nil {
+		if c.Request.Method == http.MethodGet && op.Operation != ast.Query {
+			c.Header("Allow", http.MethodPost)
+			c.JSON(http.StatusMethodNotAllowed, requestError("method_not_allowed", "mutations must be sent with POST"))
+			return
+		}
+		if err := h.analyzer.checkComplexity(op, req.Variables); err != nil {
+			c.JSON(http.StatusBadRequest, requestError("query_too_complex", err.Error()))
+			return
+		}
+	}
+
+	ctx = withLoaders(ctx, newLoaders(h.userUseCase, h.subjectUseCase))
+	response := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	c.JSON(http.StatusOK, response)
+}
+
+// NOTE - request from the JSON body of a POST or the query string of a GET
+func (h *GraphQLHandler) readRequest(c *gin.Context) (request, bool) {
+	var req request
+
+	if c.Request.Method == http.MethodPost {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRequestSize)
+		if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+			c.JSON(http.StatusBadRequest, requestError("invalid_body", "request body must be a JSON GraphQL request"))
+			return req, false
+		}
+		return req, true
+	}
+
+	req.Query = c.Query("query")
+	req.OperationName = c.Query("operationName")
+	if variables := c.Query("variables"); variables != "" {
+		if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+			c.JSON(http.StatusBadRequest, requestError("invalid_variables", "variables must be a JSON object"))
+			return req, false
+		}
+	}
+	if extensions := c.Query("extensions"); extensions != "" {
+		if err := json.Unmarshal([]byte(extensions), &req.Extensions); err != nil {
+			c.JSON(http.StatusBadRequest, requestError("invalid_extensions", "extensions must be a JSON object"))
+			return req, false
+		}
+	}
+	return req, true
+}
+
+// NOTE - fills in the query of a persisted query hash, or remembers the query under its hash
+func (h *GraphQLHandler) resolvePersistedQuery(ctx context.Context, c *gin.Context, req *request) bool {
+	persisted := req.Extensions.PersistedQuery
+	if persisted == nil {
+		return true
+	}
+
+	key := cache.PERSISTED_QUERY_KEY + persisted.SHA256Hash
+
+	if req.Query == "" {
+		query, err := cache.Get(ctx, key)
+		if err != nil {
+			c.Error(err)
+			return false
+		}
+		if query == "" {
+			c.JSON(http.StatusOK, requestError("PERSISTED_QUERY_NOT_FOUND", "PersistedQueryNotFound"))
+			return false
+		}
+		req.Query = query
+		return true
+	}
+
+	sum := sha256.Sum256([]byte(req.Query))
+	if hex.EncodeToString(sum[:]) != persisted.SHA256Hash {
+		c.JSON(http.StatusBadRequest, requestError("INVALID_PERSISTED_QUERY", "provided sha256Hash does not match the query"))
+		return false
+	}
+
+	// the query still runs when it cannot be remembered, the client sends it again next time
+	if err := cache.SetWithTTL(ctx, key, req.Query, h.persistedQueryTTL); err != nil {
+		slog.WarnContext(ctx, "Failed to persist GraphQL query", "error", err)
+	}
+	return true
+}
+
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,110 @@
+package graphql
+
+import (
+	"context"
+	"sample-project/internal/entity"
+	"sample-project/internal/usecase"
+	"strconv"
+
+	"github.com/graph-gophers/dataloader"
+)
+
+type loadersKey struct{}
+
+// loaders batch the nested relations of one request, so a page of users loads its
+// subjects in one query and a page of subjects loads its members in one query.
+type loaders struct {
+	subjectByID      *dataloader.Loader
+	usersBySubjectID *dataloader.Loader
+}
+
+// NOTE - fresh loaders for one request, results are cached for the request only
+func newLoaders(userUseCase usecase.UserUseCase, subjectUseCase usecase.SubjectUsecase) *loaders {
+	return &loaders{
+		subjectByID: dataloader.NewBatchedLoader(func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+			subjects, err := subjectUseCase.GetSubjectsByIDs(ctx, keyIDs(keys))
+			if err != nil {
+				return failAll(keys, err)
+			}
+
+			byID := make(map[int]entity.Subject, len(subjects))
+			for _, subject := range subjects {
+				byID[subject.ID] = subject
+			}
+
+			results := make([]*dataloader.Result, len(keys))
+			for i, id := range keyIDs(keys) {
+				if subject, ok := byID[id]; ok {
+					results[i] = &dataloader.Result{Data: &subject}
+				} else {
+					results[i] = &dataloader.Result{Data: (*entity.Subject)(nil)}
+				}
+			}
+			return results
+		}),
+		usersBySubjectID: dataloader.NewBatchedLoader(func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+			users, err := userUseCase.GetUsersBySubjectIDs(ctx, keyIDs(keys))
+			if err != nil {
+				return failAll(keys, err)
+			}
+
+			bySubject := make(map[int][]entity.User, len(keys))
+			for _, user := range users {
+				bySubject[user.SubjectID] = append(bySubject[user.SubjectID], user)
+			}
+
+			results := make([]*dataloader.Result, len(keys))
+			for i, id := range keyIDs(keys) {
+				results[i] = &dataloader.Result{Data: bySubject[id]}
+			}
+			return results
+		}),
+	}
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// NOTE - subject by id, batched with every other subject requested in the same tick
+func (l *loaders) subject(ctx context.Context, id int) (*entity.Subject, error) {
+	data, err := l.subjectByID.Load(ctx, idKey(id))()
+	if err != nil {
+		return nil, err
+	}
+	return data.(*entity.Subject), nil
+}
+
+// NOTE - members of a subject, batched with every other subject requested in the same tick
+func (l *loaders) members(ctx context.Context, subjectID int) ([]entity.User, error) {
+	data, err := l.usersBySubjectID.Load(ctx, idKey(subjectID))()
+	if err != nil {
+		return nil, err
+	}
+	users, _ := data.([]entity.User)
+	return users, nil
+}
+
+func idKey(id int) dataloader.Key {
+	return dataloader.StringKey(strconv.Itoa(id))
+}
+
+func keyIDs(keys dataloader.Keys) []int {
+	ids := make([]int, len(keys))
+	for i, key := range keys {
+		ids[i], _ = strconv.Atoi(key.String())
+	}
+	return ids
+}
+
+func failAll(keys dataloader.Keys, err error) []*dataloader.Result {
+	results := make([]*dataloader.Result, len(keys))
+	for i := range keys {
+		results[i] = &dataloader.Result{Error: err}
+	}
+	return results
+}
@@ -0,0 +1,270 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"sample-project/internal/entity"
+	"sample-project/internal/usecase"
+	"sample-project/internal/validation"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+)
+
+// pages never hold more than this, which also bounds the cost of nested relations
+const maxPageSize = 100
+
+// resolver is the root of the schema, queries and mutations map onto the usecases.
+type resolver struct {
+	userUseCase    usecase.UserUseCase
+	subjectUseCase usecase.SubjectUsecase
+}
+
+type userFilter struct {
+	Name        *string
+	State       *string
+	CreatedFrom *string
+	CreatedTo   *string
+}
+
+type subjectFilter struct {
+	Name   *string
+	Status *bool
+}
+
+// NOTE - page and limit within bounds
+func pageBounds(pageArg, limitArg int32) (int, int) {
+	page, limit := int(pageArg), int(limitArg)
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 15
+	}
+	return page, min(limit, maxPageSize)
+}
+
+// NOTE - user query, null when the user does not exist
+func (r *resolver) User(ctx context.Context, args struct{ ID int32 }) (*userResolver, error) {
+	user, err := r.userUseCase.GetUserByID(ctx, int(args.ID), entity.Projection{})
+	if errors.Is(err, entity.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+	return &userResolver{user: *user}, nil
+}
+
+// NOTE - users query, same filters as GET /api/v2/users
+func (r *resolver) Users(ctx context.Context, args struct {
+	Filter *userFilter
+	Page   int32
+	Limit  int32
+}) (*userPageResolver, error) {
+	page, limit := pageBounds(args.Page, args.Limit)
+
+	var name, startDate, endDate string
+	var state entity.UserState
+	if f := args.Filter; f != nil {
+		name, startDate, endDate = deref(f.Name), deref(f.CreatedFrom), deref(f.CreatedTo)
+		state = entity.UserState(deref(f.State))
+	}
+
+	users, total, err := r.userUseCase.GetUsers(ctx, page, limit, name, startDate, endDate, state, entity.Projection{})
+	if err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+
+	return &userPageResolver{
+		nodes:    userResolvers(users),
+		pageInfo: &pageInfoResolver{page: page, limit: limit, total: total},
+	}, nil
+}
+
+// NOTE - subject query, null when the subject does not exist
+func (r *resolver) Subject(ctx context.Context, args struct{ ID int32 }) (*subjectResolver, error) {
+	subject, err := r.subjectUseCase.GetSubjectByID(ctx, int(args.ID), entity.Projection{})
+	if errors.Is(err, entity.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+	return &subjectResolver{subject: *subject}, nil
+}
+
+// NOTE - subjects query, filtered and paged by the database
+func (r *resolver) Subjects(ctx context.Context, args struct {
+	Filter *subjectFilter
+	Page   int32
+	Limit  int32
+}) (*subjectPageResolver, error) {
+	page, limit := pageBounds(args.Page, args.Limit)
+
+	var name string
+	var status *bool
+	if f := args.Filter; f != nil {
+		name, status = deref(f.Name), f.Status
+	}
+
+	subjects, total, err := r.subjectUseCase.GetSubjectsPage(ctx, page, limit, name, status)
+	if err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+
+	nodes := make([]*subjectResolver, 0, len(subjects))
+	for _, subject := range subjects {
+		nodes = append(nodes, &subjectResolver{subject: subject})
+	}
+
+	return &subjectPageResolver{
+		nodes:    nodes,
+		pageInfo: &pageInfoResolver{page: page, limit: limit, total: total},
+	}, nil
+}
+
+type createUserInput struct {
+	Name      string
+	Email     string
+	Password  string
+	SubjectID *int32
+}
+
+// NOTE - create user mutation, validated with the same rules as POST /api/v2/users
+func (r *resolver) CreateUser(ctx context.Context, args struct{ Input createUserInput }) (*userResolver, error) {
+	req := entity.CreateUserRequest{
+		Name:      args.Input.Name,
+		Email:     args.Input.Email,
+		Password:  args.Input.Password,
+		SubjectID: int(deref(args.Input.SubjectID)),
+	}
+	if err := validation.Struct(ctx, &req); err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+
+	user, err := r.userUseCase.CreateUser(ctx, entity.User{
+		Name:      req.Name,
+		Email:     req.Email,
+		Password:  req.Password,
+		SubjectID: req.SubjectID,
+		Status:    true,
+	})
+	if err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+	return &userResolver{user: *user}, nil
+}
+
+type updateUserInput struct {
+	Name      *string
+	Email     *string
+	Password  *string
+	SubjectID graphqlgo.NullInt
+	Status    *bool
+}
+
+// NOTE - update user mutation, version plays the part of If-Match
+func (r *resolver) UpdateUser(ctx context.Context, args struct {
+	ID      int32
+	Version int32
+	Input   updateUserInput
+}) (*userResolver, error) {
+	var patch entity.UserPatch
+	if args.Input.Name != nil {
+		patch.Name = entity.NewNullable(*args.Input.Name)
+	}
+	if args.Input.Email != nil {
+		patch.Email = entity.NewNullable(*args.Input.Email)
+	}
+	if args.Input.Password != nil {
+		patch.Password = entity.NewNullable(*args.Input.Password)
+	}
+	if args.Input.SubjectID.Set {
+		if args.Input.SubjectID.Value == nil {
+			patch.SubjectID = entity.Nullable[int]{Present: true, Null: true}
+		} else {
+			patch.SubjectID = entity.NewNullable(int(*args.Input.SubjectID.Value))
+		}
+	}
+	if args.Input.Status != nil {
+		patch.Status = entity.NewNullable(*args.Input.Status)
+	}
+
+	if err := validation.Struct(ctx, &patch); err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+
+	user, err := r.userUseCase.UpdateUser(ctx, int(args.ID), []int{int(args.Version)}, patch)
+	if err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+	return &userResolver{user: *user}, nil
+}
+
+// NOTE - delete user mutation, version plays the part of If-Match
+func (r *resolver) DeleteUser(ctx context.Context, args struct {
+	ID      int32
+	Version int32
+}) (bool, error) {
+	if err := r.userUseCase.DeleteUser(ctx, int(args.ID), []int{int(args.Version)}); err != nil {
+		return false, toGraphQLError(ctx, err)
+	}
+	return true, nil
+}
+
+// NOTE - create subject mutation
+func (r *resolver) CreateSubject(ctx context.Context, args struct{ Input struct{ Name string } }) (*subjectResolver, error) {
+	req := entity.CreateSubjectRequest{Name: args.Input.Name}
+	if err := validation.Struct(ctx, &req); err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+
+	subject, err := r.subjectUseCase.CreateSubject(ctx, entity.Subject{Name: req.Name})
+	if err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+	return &subjectResolver{subject: *subject}, nil
+}
+
+// NOTE - update subject mutation
+func (r *resolver) UpdateSubject(ctx context.Context, args struct {
+	ID    int32
+	Input struct {
+		Name   *string
+		Status *bool
+	}
+}) (*subjectResolver, error) {
+	var patch entity.SubjectPatch
+	if args.Input.Name != nil {
+		patch.Name = entity.NewNullable(*args.Input.Name)
+	}
+	if args.Input.Status != nil {
+		patch.Status = entity.NewNullable(*args.Input.Status)
+	}
+
+	if err := validation.Struct(ctx, &patch); err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+
+	subject, err := r.subjectUseCase.UpdateSubject(ctx, int(args.ID), patch)
+	if err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+	return &subjectResolver{subject: *subject}, nil
+}
+
+// NOTE - delete subject mutation
+func (r *resolver) DeleteSubject(ctx context.Context, args struct{ ID int32 }) (bool, error) {
+	if err := r.subjectUseCase.DeleteSubject(ctx, int(args.ID)); err != nil {
+		return false, toGraphQLError(ctx, err)
+	}
+	return true, nil
+}
+
+func deref[T any](value *T) T {
+	var zero T
+	if value == nil {
+		return zero
+	}
+	return *value
+}
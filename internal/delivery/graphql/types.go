@@ -0,0 +1,115 @@
+package graphql
+
+import (
+	"context"
+	"sample-project/internal/entity"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+)
+
+type userResolver struct {
+	user entity.User
+}
+
+func (r *userResolver) ID() int32      { return int32(r.user.ID) }
+func (r *userResolver) Name() string   { return r.user.Name }
+func (r *userResolver) Email() string  { return r.user.Email }
+func (r *userResolver) Status() bool   { return r.user.Status }
+func (r *userResolver) State() string  { return string(r.user.State) }
+func (r *userResolver) Version() int32 { return int32(r.user.Version) }
+
+func (r *userResolver) AvatarURL() *string       { return optionalString(r.user.AvatarURL) }
+func (r *userResolver) SuspendedReason() *string { return optionalString(r.user.SuspendedReason) }
+
+func (r *userResolver) ReactivateAt() *graphqlgo.Time {
+	if r.user.ReactivateAt == nil {
+		return nil
+	}
+	return &graphqlgo.Time{Time: *r.user.ReactivateAt}
+}
+
+func (r *userResolver) CreatedAt() graphqlgo.Time { return graphqlgo.Time{Time: r.user.CreatedAt} }
+func (r *userResolver) UpdatedAt() graphqlgo.Time { return graphqlgo.Time{Time: r.user.UpdatedAt} }
+
+// NOTE - subject of the user, loaded in one batch for every user of the response
+func (r *userResolver) Subject(ctx context.Context) (*subjectResolver, error) {
+	if r.user.SubjectID == 0 {
+		return nil, nil
+	}
+
+	subject, err := loadersFrom(ctx).subject(ctx, r.user.SubjectID)
+	if err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+	if subject == nil {
+		return nil, nil
+	}
+	return &subjectResolver{subject: *subject}, nil
+}
+
+type subjectResolver struct {
+	subject entity.Subject
+}
+
+func (r *subjectResolver) ID() int32    { return int32(r.subject.ID) }
+func (r *subjectResolver) Name() string { return r.subject.Name }
+func (r *subjectResolver) Status() bool { return r.subject.Status }
+
+func (r *subjectResolver) CreatedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: r.subject.CreatedAt}
+}
+func (r *subjectResolver) UpdatedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: r.subject.UpdatedAt}
+}
+
+// NOTE - members of the subject, loaded in one batch for every subject of the response
+func (r *subjectResolver) Members(ctx context.Context) ([]*userResolver, error) {
+	users, err := loadersFrom(ctx).members(ctx, r.subject.ID)
+	if err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+	return userResolvers(users), nil
+}
+
+type pageInfoResolver struct {
+	page, limit, total int
+}
+
+func (r *pageInfoResolver) Page() int32  { return int32(r.page) }
+func (r *pageInfoResolver) Limit() int32 { return int32(r.limit) }
+func (r *pageInfoResolver) Total() int32 { return int32(r.total) }
+
+func (r *pageInfoResolver) TotalPages() int32 {
+	return int32((r.total + r.limit - 1) / r.limit)
+}
+
+type userPageResolver struct {
+	nodes    []*userResolver
+	pageInfo *pageInfoResolver
+}
+
+func (r *userPageResolver) Nodes() []*userResolver      { return r.nodes }
+func (r *userPageResolver) PageInfo() *pageInfoResolver { return r.pageInfo }
+
+type subjectPageResolver struct {
+	nodes    []*subjectResolver
+	pageInfo *pageInfoResolver
+}
+
+func (r *subjectPageResolver) Nodes() []*subjectResolver   { return r.nodes }
+func (r *subjectPageResolver) PageInfo() *pageInfoResolver { return r.pageInfo }
+
+func userResolvers(users []entity.User) []*userResolver {
+	resolvers := make([]*userResolver, len(users))
+	for i, user := range users {
+		resolvers[i] = &userResolver{user: user}
+	}
+	return resolvers
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,45 @@
+package grpc
+
+import (
+	"context"
+	"sample-project/internal/entity"
+	"sample-project/internal/usecase"
+	"sample-project/internal/validation"
+	samplev1 "sample-project/pkg/pb/sample/v1"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// NOTE - auth grpc server struct
+type AuthServer struct {
+	samplev1.UnimplementedAuthServiceServer
+	useCase usecase.AuthUseCase
+}
+
+// NOTE - new auth grpc server
+func NewAuthServer(useCase usecase.AuthUseCase) *AuthServer {
+	return &AuthServer{useCase: useCase}
+}
+
+// NOTE - login rpc, the only rpc that does not need a token
+func (s *AuthServer) Login(ctx context.Context, req *samplev1.LoginRequest) (*samplev1.LoginResponse, error) {
+	login := entity.LoginRequest{Name: req.GetName(), Password: req.GetPassword()}
+	if err := validation.Struct(ctx, &login); err != nil {
+		return nil, err
+	}
+
+	accessToken, refreshToken, err := s.useCase.Login(ctx, login.Name, login.Password)
+	if err != nil {
+		return nil, err
+	}
+	return &samplev1.LoginResponse{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// NOTE - profile of the caller rpc
+func (s *AuthServer) GetProfile(ctx context.Context, _ *emptypb.Empty) (*samplev1.User, error) {
+	user, err := s.useCase.GetUserProfile(ctx, tokenFrom(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return toUser(*user), nil
+}
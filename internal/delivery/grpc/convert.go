@@ -0,0 +1,82 @@
+package grpc
+
+import (
+	"sample-project/internal/entity"
+	samplev1 "sample-project/pkg/pb/sample/v1"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const userStatePrefix = "USER_STATE_"
+
+func toUserState(state entity.UserState) samplev1.UserState {
+	return samplev1.UserState(samplev1.UserState_value[userStatePrefix+string(state)])
+}
+
+// NOTE - empty for USER_STATE_UNSPECIFIED, the usecases treat that as no state
+func fromUserState(state samplev1.UserState) entity.UserState {
+	if state == samplev1.UserState_USER_STATE_UNSPECIFIED {
+		return ""
+	}
+	return entity.UserState(strings.TrimPrefix(state.String(), userStatePrefix))
+}
+
+func toTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func toUser(user entity.User) *samplev1.User {
+	return &samplev1.User{
+		Id:              int32(user.ID),
+		Name:            user.Name,
+		Email:           user.Email,
+		AvatarUrl:       user.AvatarURL,
+		SubjectId:       int32(user.SubjectID),
+		Status:          user.Status,
+		State:           toUserState(user.State),
+		SuspendedReason: user.SuspendedReason,
+		ReactivateAt:    toTimestamp(user.ReactivateAt),
+		Version:         int32(user.Version),
+		CreatedAt:       timestamppb.New(user.CreatedAt),
+		UpdatedAt:       timestamppb.New(user.UpdatedAt),
+	}
+}
+
+func toUsers(users []entity.User) []*samplev1.User {
+	result := make([]*samplev1.User, 0, len(users))
+	for _, user := range users {
+		result = append(result, toUser(user))
+	}
+	return result
+}
+
+func toSubject(subject entity.Subject) *samplev1.Subject {
+	return &samplev1.Subject{
+		Id:        int32(subject.ID),
+		Name:      subject.Name,
+		Status:    subject.Status,
+		CreatedAt: timestamppb.New(subject.CreatedAt),
+		UpdatedAt: timestamppb.New(subject.UpdatedAt),
+		Users:     toUsers(subject.User),
+	}
+}
+
+func toStateTransition(transition entity.UserStateTransition) *samplev1.StateTransition {
+	result := &samplev1.StateTransition{
+		Id:        int32(transition.ID),
+		UserId:    int32(transition.UserID),
+		FromState: toUserState(transition.FromState),
+		ToState:   toUserState(transition.ToState),
+		Reason:    transition.Reason,
+		CreatedAt: timestamppb.New(transition.CreatedAt),
+	}
+	if transition.ActorID != nil {
+		result.ActorId = int32(*transition.ActorID)
+	}
+	return result
+}
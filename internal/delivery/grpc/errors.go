@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sample-project/internal/config/locale"
+	"sample-project/internal/entity"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// errorDomain tags the ErrorInfo detail, its reason is the code the REST envelope has
+const errorDomain = "sample-project"
+
+// status code for every error kind, anything not listed is Internal
+var errorCode = map[error]codes.Code{
+	entity.ErrBadRequest:           codes.InvalidArgument,
+	entity.ErrValidation:           codes.InvalidArgument,
+	entity.ErrUnauthorized:         codes.Unauthenticated,
+	entity.ErrForbidden:            codes.PermissionDenied,
+	entity.ErrNotFound:             codes.NotFound,
+	entity.ErrConflict:             codes.AlreadyExists,
+	entity.ErrPreconditionFailed:   codes.Aborted,
+	entity.ErrPreconditionRequired: codes.FailedPrecondition,
+	entity.ErrNotAcceptable:        codes.InvalidArgument,
+	entity.ErrUnsupportedMediaType: codes.InvalidArgument,
+	entity.ErrPayloadTooLarge:      codes.ResourceExhausted,
+	entity.ErrTooManyRequests:      codes.ResourceExhausted,
+	entity.ErrTimeout:              codes.DeadlineExceeded,
+	entity.ErrUnavailable:          codes.Unavailable,
+}
+
+// NOTE - status for err, with the domain code as ErrorInfo and field errors as BadRequest details
+func toStatus(ctx context.Context, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	// whatever the store reported, a finished request context is the cause
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return status.Error(codes.DeadlineExceeded, locale.Localize(ctx, "request_timeout", nil, "the request took too long, please try again later"))
+	case context.Canceled:
+		return status.Error(codes.Canceled, locale.Localize(ctx, "request_cancelled", nil, "the request was cancelled"))
+	}
+
+	var domainErr *entity.Error
+	if errors.As(err, &domainErr) {
+		if code, ok := errorCode[domainErr.Kind]; ok {
+			// a conflict that is not about uniqueness is a request the current state does not allow
+			if errors.Is(err, entity.ErrInvalidStateTransition) || errors.Is(err, entity.ErrAlreadyErased) {
+				code = codes.FailedPrecondition
+			}
+			return withDetails(ctx, status.New(code, locale.Error(ctx, domainErr)), domainErr)
+		}
+	}
+
+	slog.ErrorContext(ctx, "Unhandled gRPC error", "error", err)
+	return status.Error(codes.Internal, locale.Localize(ctx, "internal_error", nil, "Something went wrong, please try again later"))
+}
+
+func withDetails(ctx context.Context, st *status.Status, domainErr *entity.Error) error {
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: domainErr.Code, Domain: errorDomain}}
+	if len(domainErr.Fields) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(domainErr.Fields))
+		for _, field := range locale.Fields(ctx, domainErr.Fields) {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: field.Field, Description: field.Message})
+		}
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
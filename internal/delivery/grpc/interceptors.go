@@ -0,0 +1,248 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"runtime/debug"
+	"sample-project/internal/config/locale"
+	"sample-project/internal/config/logger"
+	"sample-project/internal/entity"
+	"sample-project/internal/usecase"
+	"sample-project/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	grpcgo "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	requestIDMetadata      = "x-request-id"
+	apiKeyMetadata         = "x-api-key"
+	acceptLanguageMetadata = "accept-language"
+)
+
+// client supplied ids are only trusted when they are short and cannot break a log line
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+type tokenKey struct{}
+
+// NOTE - access token the call was authenticated with
+func tokenFrom(ctx context.Context) string {
+	token, _ := ctx.Value(tokenKey{}).(string)
+	return token
+}
+
+// NOTE - turns a panic into an Internal status instead of taking the server down
+func recoverUnary(ctx context.Context, req interface{}, info *grpcgo.UnaryServerInfo, handler grpcgo.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(ctx, "Panic in gRPC handler", "method", info.FullMethod, "panic", r, "stack", string(debug.Stack()))
+			err = status.Error(codes.Internal, "Something went wrong, please try again later")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func recoverStream(srv interface{}, ss grpcgo.ServerStream, info *grpcgo.StreamServerInfo, handler grpcgo.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(ss.Context(), "Panic in gRPC handler", "method", info.FullMethod, "panic", r, "stack", string(debug.Stack()))
+			err = status.Error(codes.Internal, "Something went wrong, please try again later")
+		}
+	}()
+	return handler(srv, ss)
+}
+
+// NOTE - accepts the caller's x-request-id or generates one, echoes it back and writes one log line per call
+func accessLogUnary(ctx context.Context, req interface{}, info *grpcgo.UnaryServerInfo, handler grpcgo.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	ctx = withRequestID(ctx)
+
+	resp, err := handler(ctx, req)
+	logCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+func accessLogStream(srv interface{}, ss grpcgo.ServerStream, info *grpcgo.StreamServerInfo, handler grpcgo.StreamHandler) error {
+	start := time.Now()
+	ctx := withRequestID(ss.Context())
+
+	err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	logCall(ctx, info.FullMethod, start, err)
+	return err
+}
+
+func withRequestID(ctx context.Context) context.Context {
+	var id string
+	if values := metadata.ValueFromIncomingContext(ctx, requestIDMetadata); len(values) > 0 {
+		id = values[0]
+	}
+	if !validRequestID.MatchString(id) {
+		id = uuid.NewString()
+	}
+
+	grpcgo.SetHeader(ctx, metadata.Pairs(requestIDMetadata, id))
+	return logger.WithRequestID(ctx, id)
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK:
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+
+	// the context also carries the user id once the caller was authenticated
+	slog.LogAttrs(ctx, level, "rpc",
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+	)
+}
+
+// NOTE - maps the domain errors of the handlers to gRPC status codes, with messages in the language of accept-language
+func errorsUnary(ctx context.Context, req interface{}, info *grpcgo.UnaryServerInfo, handler grpcgo.UnaryHandler) (interface{}, error) {
+	ctx = withLanguage(ctx)
+
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return resp, nil
+}
+
+func errorsStream(srv interface{}, ss grpcgo.ServerStream, info *grpcgo.StreamServerInfo, handler grpcgo.StreamHandler) error {
+	ctx := withLanguage(ss.Context())
+
+	if err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx}); err != nil {
+		return toStatus(ctx, err)
+	}
+	return nil
+}
+
+func withLanguage(ctx context.Context) context.Context {
+	tag := locale.Negotiate(strings.Join(metadata.ValueFromIncomingContext(ctx, acceptLanguageMetadata), ","))
+	grpcgo.SetHeader(ctx, metadata.Pairs("content-language", tag.String()))
+	return locale.WithLanguage(ctx, tag)
+}
+
+// authenticator requires a valid access token on every method but the public ones
+// and scopes every call to the tenant of its token, API key or authority
+type authenticator struct {
+	public map[string]bool
+	// services that answer without a token, e.g. health and reflection
+	publicServices []string
+	tenants        usecase.TenantUseCase
+}
+
+func (a *authenticator) isPublic(fullMethod string) bool {
+	return a.public[fullMethod] || a.isPublicService(fullMethod)
+}
+
+// NOTE - reports whether the method belongs to a public service, those are neither authenticated nor scoped to a tenant
+func (a *authenticator) isPublicService(fullMethod string) bool {
+	for _, prefix := range a.publicServices {
+		if strings.HasPrefix(fullMethod, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// NOTE - validates the bearer access token in the authorization metadata and stores its claims in the context
+func (a *authenticator) authenticate(ctx context.Context) (context.Context, error) {
+	values := metadata.ValueFromIncomingContext(ctx, "authorization")
+	if len(values) == 0 || values[0] == "" {
+		return nil, entity.ErrMissingAuthorization.Localized("missing_authorization.metadata")
+	}
+
+	token := strings.TrimPrefix(values[0], "Bearer ")
+
+	claims, err := utils.ValidateToken(token, false)
+	if err != nil {
+		return nil, entity.ErrInvalidToken
+	}
+
+	ctx = utils.WithClaims(ctx, claims)
+	ctx = context.WithValue(ctx, tokenKey{}, token)
+	// tag everything logged for the rest of the call with the caller
+	return logger.WithUserID(ctx, claims.UserID), nil
+}
+
+// NOTE - scopes the call to the tenant its token, x-api-key metadata or authority names.
+// A call that names none goes on without one and every query it makes fails with tenant_required
+func (a *authenticator) withTenant(ctx context.Context) (context.Context, error) {
+	var credentials entity.TenantCredentials
+	if values := metadata.ValueFromIncomingContext(ctx, apiKeyMetadata); len(values) > 0 {
+		credentials.APIKey = values[0]
+	}
+	if values := metadata.ValueFromIncomingContext(ctx, ":authority"); len(values) > 0 {
+		credentials.Host = values[0]
+	}
+
+	tenantID, err := a.tenants.ResolveTenant(ctx, credentials)
+	if err != nil {
+		return nil, err
+	}
+	if tenantID == 0 {
+		return ctx, nil
+	}
+	return entity.WithTenant(ctx, tenantID), nil
+}
+
+func (a *authenticator) unary(ctx context.Context, req interface{}, info *grpcgo.UnaryServerInfo, handler grpcgo.UnaryHandler) (interface{}, error) {
+	if a.isPublicService(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	if !a.isPublic(info.FullMethod) {
+		var err error
+		if ctx, err = a.authenticate(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, err := a.withTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authenticator) stream(srv interface{}, ss grpcgo.ServerStream, info *grpcgo.StreamServerInfo, handler grpcgo.StreamHandler) error {
+	if a.isPublicService(info.FullMethod) {
+		return handler(srv, ss)
+	}
+
+	ctx := ss.Context()
+	if !a.isPublic(info.FullMethod) {
+		var err error
+		if ctx, err = a.authenticate(ctx); err != nil {
+			return err
+		}
+	}
+
+	ctx, err := a.withTenant(ctx)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// contextStream hands a derived context to stream handlers
+type contextStream struct {
+	grpcgo.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,107 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"sample-project/internal/config/health"
+	"sample-project/internal/usecase"
+	samplev1 "sample-project/pkg/pb/sample/v1"
+	"time"
+
+	grpcgo "google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// avatars are sent inline, leave room for a 5MB image and the rest of the message
+const maxRecvMsgSize = 6 << 20
+
+// Server is the gRPC server that runs next to the gin server over the same usecases.
+type Server struct {
+	server   *grpcgo.Server
+	health   *grpchealth.Server
+	checker  *health.Checker
+	interval time.Duration
+	done     chan struct{}
+}
+
+// NOTE - new grpc server with the user, subject and auth services, the standard health service and reflection.
+// Health follows the readiness of checker, refreshed every interval
+func NewServer(checker *health.Checker, interval time.Duration, userUseCase usecase.UserUseCase, subjectUseCase usecase.SubjectUsecase, authUseCase usecase.AuthUseCase, tenantUseCase usecase.TenantUseCase) *Server {
+	auth := &authenticator{
+		public: map[string]bool{
+			samplev1.AuthService_Login_FullMethodName: true,
+		},
+		publicServices: []string{"/grpc.health.v1.Health/", "/grpc.reflection."},
+		tenants:        tenantUseCase,
+	}
+
+	server := grpcgo.NewServer(
+		grpcgo.MaxRecvMsgSize(maxRecvMsgSize),
+		grpcgo.ChainUnaryInterceptor(accessLogUnary, recoverUnary, errorsUnary, auth.unary),
+		grpcgo.ChainStreamInterceptor(accessLogStream, recoverStream, errorsStream, auth.stream),
+	)
+
+	samplev1.RegisterUserServiceServer(server, NewUserServer(userUseCase))
+	samplev1.RegisterSubjectServiceServer(server, NewSubjectServer(subjectUseCase))
+	samplev1.RegisterAuthServiceServer(server, NewAuthServer(authUseCase))
+
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	reflection.Register(server)
+
+	return &Server{server: server, health: healthServer, checker: checker, interval: interval, done: make(chan struct{})}
+}
+
+// NOTE - serves on lis until Shutdown, returns nil once it was shut down
+func (s *Server) Serve(lis net.Listener) error {
+	s.refreshHealth()
+	go s.watchHealth()
+
+	return s.server.Serve(lis)
+}
+
+// NOTE - reports not serving, then waits for the calls in flight until ctx is done and cancels the rest
+func (s *Server) Shutdown(ctx context.Context) {
+	close(s.done)
+	s.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.server.Stop()
+	}
+}
+
+func (s *Server) watchHealth() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.refreshHealth()
+		}
+	}
+}
+
+// every service is as healthy as the API, they share the same dependencies
+func (s *Server) refreshHealth() {
+	status := healthpb.HealthCheckResponse_SERVING
+	if _, ready := s.checker.Ready(context.Background()); !ready {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	for _, service := range []string{"", samplev1.UserService_ServiceDesc.ServiceName, samplev1.SubjectService_ServiceDesc.ServiceName, samplev1.AuthService_ServiceDesc.ServiceName} {
+		s.health.SetServingStatus(service, status)
+	}
+}
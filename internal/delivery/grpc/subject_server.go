@@ -0,0 +1,99 @@
+package grpc
+
+import (
+	"context"
+	"sample-project/internal/entity"
+	"sample-project/internal/usecase"
+	"sample-project/internal/validation"
+	samplev1 "sample-project/pkg/pb/sample/v1"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// the Subject message carries its users
+var subjectWithUsers = entity.Projection{Include: []string{entity.IncludeUsers}}
+
+// NOTE - subject grpc server struct
+type SubjectServer struct {
+	samplev1.UnimplementedSubjectServiceServer
+	useCase usecase.SubjectUsecase
+}
+
+// NOTE - new subject grpc server
+func NewSubjectServer(useCase usecase.SubjectUsecase) *SubjectServer {
+	return &SubjectServer{useCase: useCase}
+}
+
+// NOTE - list subjects rpc
+func (s *SubjectServer) ListSubjects(ctx context.Context, _ *emptypb.Empty) (*samplev1.ListSubjectsResponse, error) {
+	subjects, err := s.useCase.GetSubject(ctx, subjectWithUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &samplev1.ListSubjectsResponse{Subjects: make([]*samplev1.Subject, 0, len(subjects))}
+	for _, subject := range subjects {
+		response.Subjects = append(response.Subjects, toSubject(subject))
+	}
+	return response, nil
+}
+
+// NOTE - get subject by id rpc
+func (s *SubjectServer) GetSubject(ctx context.Context, req *samplev1.GetSubjectRequest) (*samplev1.Subject, error) {
+	subject, err := s.useCase.GetSubjectByID(ctx, int(req.GetId()), subjectWithUsers)
+	if err != nil {
+		return nil, err
+	}
+	return toSubject(*subject), nil
+}
+
+// NOTE - create subject rpc
+func (s *SubjectServer) CreateSubject(ctx context.Context, req *samplev1.CreateSubjectRequest) (*samplev1.Subject, error) {
+	create := entity.CreateSubjectRequest{Name: req.GetName()}
+	if err := validation.Struct(ctx, &create); err != nil {
+		return nil, err
+	}
+
+	subject, err := s.useCase.CreateSubject(ctx, entity.Subject{Name: create.Name})
+	if err != nil {
+		return nil, err
+	}
+	return toSubject(*subject), nil
+}
+
+// NOTE - update subject rpc
+func (s *SubjectServer) UpdateSubject(ctx context.Context, req *samplev1.UpdateSubjectRequest) (*samplev1.Subject, error) {
+	var patch entity.SubjectPatch
+	if req.Name != nil {
+		patch.Name = entity.NewNullable(req.GetName())
+	}
+	if req.Status != nil {
+		patch.Status = entity.NewNullable(req.GetStatus())
+	}
+
+	if err := validation.Struct(ctx, &patch); err != nil {
+		return nil, err
+	}
+
+	subject, err := s.useCase.UpdateSubject(ctx, int(req.GetId()), patch)
+	if err != nil {
+		return nil, err
+	}
+	return toSubject(*subject), nil
+}
+
+// NOTE - delete subject rpc
+func (s *SubjectServer) DeleteSubject(ctx context.Context, req *samplev1.DeleteSubjectRequest) (*emptypb.Empty, error) {
+	if err := s.useCase.DeleteSubject(ctx, int(req.GetId())); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// NOTE - purge subject cache rpc
+func (s *SubjectServer) PurgeSubjectCache(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	if err := s.useCase.ClearSubjectCache(ctx); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,154 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"sample-project/internal/config/health"
+	"sample-project/internal/entity"
+	"sample-project/internal/repository"
+	"sample-project/internal/usecase"
+	"sample-project/internal/utils"
+	samplev1 "sample-project/pkg/pb/sample/v1"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	grpcgo "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// schoolHosts serves school 1 on a.example.com and school 2 on b.example.com
+type schoolHosts struct {
+	repository.TenantRepository
+}
+
+func (schoolHosts) GetTenantByHost(ctx context.Context, host string) (*entity.Tenant, error) {
+	switch host {
+	case "a.example.com":
+		return &entity.Tenant{ID: 1}, nil
+	case "b.example.com":
+		return &entity.Tenant{ID: 2}, nil
+	}
+	return nil, entity.ErrNotFound
+}
+
+// schoolUsers keeps the users of every school apart, like the repositories do, and only sees the school of ctx
+type schoolUsers struct {
+	usecase.UserUseCase
+	mu    sync.Mutex
+	users map[int][]entity.User
+}
+
+func (u *schoolUsers) GetUsers(ctx context.Context, page, limit int, name string, startDate, endDate string, state entity.UserState, projection entity.Projection) ([]entity.User, int, error) {
+	tenantID, ok := entity.TenantID(ctx)
+	if !ok {
+		return nil, 0, entity.ErrTenantRequired
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.users[tenantID], len(u.users[tenantID]), nil
+}
+
+func (u *schoolUsers) GetUserByID(ctx context.Context, id int, projection entity.Projection) (*entity.User, error) {
+	tenantID, ok := entity.TenantID(ctx)
+	if !ok {
+		return nil, entity.ErrTenantRequired
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for _, user := range u.users[tenantID] {
+		if user.ID == id {
+			return &user, nil
+		}
+	}
+	return nil, entity.NotFound("user_not_found", "user with ID: %d not found", id)
+}
+
+func (u *schoolUsers) DeleteUser(ctx context.Context, id int, versions []int) error {
+	tenantID, ok := entity.TenantID(ctx)
+	if !ok {
+		return entity.ErrTenantRequired
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for i, user := range u.users[tenantID] {
+		if user.ID == id {
+			u.users[tenantID] = slices.Delete(u.users[tenantID], i, i+1)
+			return nil
+		}
+	}
+	return entity.NotFound("user_not_found", "user with ID: %d not found", id)
+}
+
+// NOTE - user service client on a server over users, the calls carry token and are sent to authority
+func schoolClient(t *testing.T, users *schoolUsers, token, authority string) samplev1.UserServiceClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	server := NewServer(health.NewChecker(time.Second), time.Minute, users, nil, nil, usecase.NewTenantUseCase(schoolHosts{}))
+	go server.Serve(lis)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	})
+
+	conn, err := grpcgo.NewClient("passthrough:///bufconn",
+		grpcgo.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpcgo.WithTransportCredentials(insecure.NewCredentials()),
+		grpcgo.WithAuthority(authority),
+		grpcgo.WithUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpcgo.ClientConn, invoker grpcgo.UnaryInvoker, opts ...grpcgo.CallOption) error {
+			return invoker(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token), method, req, reply, cc, opts...)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return samplev1.NewUserServiceClient(conn)
+}
+
+func TestCallsOnlyReachTheTokenSchool(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	tokenA, _, err := utils.GenerateToken(1, 1, "Noy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := &schoolUsers{users: map[int][]entity.User{
+		1: {{ID: 1, Name: "Noy", Version: 1}},
+		2: {{ID: 2, Name: "Dan", Version: 1}},
+	}}
+	ctx := context.Background()
+
+	client := schoolClient(t, users, tokenA, "a.example.com")
+
+	list, err := client.ListUsers(ctx, &samplev1.ListUsersRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.GetUsers()) != 1 || list.GetUsers()[0].GetId() != 1 {
+		t.Errorf("school 1 listed %v", list.GetUsers())
+	}
+
+	if _, err := client.GetUser(ctx, &samplev1.GetUserRequest{Id: 2}); status.Code(err) != codes.NotFound {
+		t.Errorf("reading the user of school 2: got %v, want NotFound", err)
+	}
+	if _, err := client.DeleteUser(ctx, &samplev1.DeleteUserRequest{Id: 2, Version: 1}); status.Code(err) != codes.NotFound {
+		t.Errorf("deleting the user of school 2: got %v, want NotFound", err)
+	}
+	if len(users.users[2]) != 1 {
+		t.Errorf("school 2 has users %v after school 1 deleted one of them", users.users[2])
+	}
+
+	// the token is bound to its school, the authority of another one does not switch it
+	other := schoolClient(t, users, tokenA, "b.example.com")
+	if _, err := other.GetUser(ctx, &samplev1.GetUserRequest{Id: 2}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("token of school 1 sent to school 2: got %v, want PermissionDenied", err)
+	}
+}
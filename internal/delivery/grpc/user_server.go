@@ -0,0 +1,208 @@
+package grpc
+
+import (
+	"context"
+	"sample-project/internal/entity"
+	"sample-project/internal/usecase"
+	"sample-project/internal/utils"
+	"sample-project/internal/validation"
+	samplev1 "sample-project/pkg/pb/sample/v1"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const maxAvatarSize = 5 << 20
+
+var (
+	errVersionRequired = entity.NewError(entity.ErrPreconditionRequired, "version_required", "version is required")
+	errAvatarTooLarge  = entity.NewError(entity.ErrPayloadTooLarge, "avatar_too_large", "Avatar must be 5MB or smaller")
+	errAvatarRequired  = entity.BadRequest("avatar_required", "avatar image is required")
+)
+
+// NOTE - user grpc server struct
+type UserServer struct {
+	samplev1.UnimplementedUserServiceServer
+	useCase usecase.UserUseCase
+}
+
+// NOTE - new user grpc server
+func NewUserServer(useCase usecase.UserUseCase) *UserServer {
+	return &UserServer{useCase: useCase}
+}
+
+// NOTE - list users rpc, same filters and defaults as GET /api/v2/users
+func (s *UserServer) ListUsers(ctx context.Context, req *samplev1.ListUsersRequest) (*samplev1.ListUsersResponse, error) {
+	page, limit := int(req.GetPage()), int(req.GetLimit())
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 15
+	}
+	limit = min(limit, 100)
+
+	users, total, err := s.useCase.GetUsers(ctx, page, limit, req.GetName(), req.GetStartDate(), req.GetEndDate(), fromUserState(req.GetState()), entity.Projection{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &samplev1.ListUsersResponse{
+		Users: toUsers(users),
+		Page:  int32(page),
+		Limit: int32(limit),
+		Total: int32(total),
+	}, nil
+}
+
+// NOTE - get user by id rpc
+func (s *UserServer) GetUser(ctx context.Context, req *samplev1.GetUserRequest) (*samplev1.User, error) {
+	user, err := s.useCase.GetUserByID(ctx, int(req.GetId()), entity.Projection{})
+	if err != nil {
+		return nil, err
+	}
+	return toUser(*user), nil
+}
+
+// NOTE - get user by name rpc
+func (s *UserServer) GetUserByName(ctx context.Context, req *samplev1.GetUserByNameRequest) (*samplev1.User, error) {
+	user, err := s.useCase.GetUserByName(ctx, req.GetName())
+	if err != nil {
+		return nil, err
+	}
+	return toUser(*user), nil
+}
+
+// NOTE - create user rpc, validated with the same rules as POST /api/v2/users
+func (s *UserServer) CreateUser(ctx context.Context, req *samplev1.CreateUserRequest) (*samplev1.User, error) {
+	create := entity.CreateUserRequest{
+		Name:      req.GetName(),
+		Email:     req.GetEmail(),
+		Password:  req.GetPassword(),
+		SubjectID: int(req.GetSubjectId()),
+	}
+	if err := validation.Struct(ctx, &create); err != nil {
+		return nil, err
+	}
+
+	user, err := s.useCase.CreateUser(ctx, entity.User{
+		Name:      create.Name,
+		Email:     create.Email,
+		Password:  create.Password,
+		SubjectID: create.SubjectID,
+		Status:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toUser(*user), nil
+}
+
+// NOTE - update user rpc, version plays the part of If-Match
+func (s *UserServer) UpdateUser(ctx context.Context, req *samplev1.UpdateUserRequest) (*samplev1.User, error) {
+	if req.GetVersion() <= 0 {
+		return nil, errVersionRequired
+	}
+
+	var patch entity.UserPatch
+	if req.Name != nil {
+		patch.Name = entity.NewNullable(req.GetName())
+	}
+	if req.Email != nil {
+		patch.Email = entity.NewNullable(req.GetEmail())
+	}
+	if req.Password != nil {
+		patch.Password = entity.NewNullable(req.GetPassword())
+	}
+	switch subject := req.GetSubject().(type) {
+	case *samplev1.UpdateUserRequest_SubjectId:
+		patch.SubjectID = entity.NewNullable(int(subject.SubjectId))
+	case *samplev1.UpdateUserRequest_ClearSubject:
+		if subject.ClearSubject {
+			patch.SubjectID = entity.Nullable[int]{Present: true, Null: true}
+		}
+	}
+	if req.Status != nil {
+		patch.Status = entity.NewNullable(req.GetStatus())
+	}
+
+	if err := validation.Struct(ctx, &patch); err != nil {
+		return nil, err
+	}
+
+	user, err := s.useCase.UpdateUser(ctx, int(req.GetId()), []int{int(req.GetVersion())}, patch)
+	if err != nil {
+		return nil, err
+	}
+	return toUser(*user), nil
+}
+
+// NOTE - update avatar rpc
+func (s *UserServer) UpdateAvatar(ctx context.Context, req *samplev1.UpdateAvatarRequest) (*samplev1.Avatar, error) {
+	if len(req.GetImage()) == 0 {
+		return nil, errAvatarRequired
+	}
+	if len(req.GetImage()) > maxAvatarSize {
+		return nil, errAvatarTooLarge
+	}
+
+	avatar, err := s.useCase.UpdateAvatar(ctx, int(req.GetId()), req.GetImage())
+	if err != nil {
+		return nil, err
+	}
+	return &samplev1.Avatar{Url: avatar.URL, Thumbnails: avatar.Thumbnails}, nil
+}
+
+// NOTE - user lifecycle transition rpc, the caller is recorded as the actor
+func (s *UserServer) TransitionState(ctx context.Context, req *samplev1.TransitionStateRequest) (*samplev1.User, error) {
+	transition := entity.TransitionUserStateRequest{
+		State:  fromUserState(req.GetState()),
+		Reason: req.GetReason(),
+	}
+	if req.ReactivateAt != nil {
+		reactivateAt := req.GetReactivateAt().AsTime()
+		transition.ReactivateAt = &reactivateAt
+	}
+	if err := validation.Struct(ctx, &transition); err != nil {
+		return nil, err
+	}
+
+	user, err := s.useCase.TransitionState(ctx, int(req.GetId()), transition, &utils.ClaimsFrom(ctx).UserID)
+	if err != nil {
+		return nil, err
+	}
+	return toUser(*user), nil
+}
+
+// NOTE - user lifecycle history rpc, newest first
+func (s *UserServer) ListStateTransitions(ctx context.Context, req *samplev1.ListStateTransitionsRequest) (*samplev1.ListStateTransitionsResponse, error) {
+	transitions, err := s.useCase.GetStateTransitions(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+
+	response := &samplev1.ListStateTransitionsResponse{Transitions: make([]*samplev1.StateTransition, 0, len(transitions))}
+	for _, transition := range transitions {
+		response.Transitions = append(response.Transitions, toStateTransition(transition))
+	}
+	return response, nil
+}
+
+// NOTE - delete user rpc, version plays the part of If-Match
+func (s *UserServer) DeleteUser(ctx context.Context, req *samplev1.DeleteUserRequest) (*emptypb.Empty, error) {
+	if req.GetVersion() <= 0 {
+		return nil, errVersionRequired
+	}
+
+	if err := s.useCase.DeleteUser(ctx, int(req.GetId()), []int{int(req.GetVersion())}); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// NOTE - purge user cache rpc
+func (s *UserServer) PurgeUserCache(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	if err := s.useCase.ClearUserCache(ctx); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -1,8 +1,8 @@
 package delivery
 
 import (
-	"context"
 	"net/http"
+	"sample-project/internal/entity"
 	"sample-project/internal/usecase"
 
 	"github.com/gin-gonic/gin"
@@ -18,56 +18,95 @@ func NewAuthHandler(router *gin.Engine, useCase usecase.AuthUseCase) {
 	auth := router.Group("/api/v1/auth")
 
 	auth.POST("/login", handler.Login)
+	auth.POST("/refresh", handler.Refresh)
 	auth.GET("/me", handler.GetUserProfile)
+
+	authV2 := router.Group("/api/v2/auth")
+
+	authV2.POST("/login", handler.Login)
+	authV2.POST("/refresh", handler.Refresh)
+	authV2.GET("/me", handler.GetUserProfile)
 }
 
 // @Summary      Login user
 // @Description  Authenticates a user and returns access & refresh tokens
 // @Tags         auth
-// @Accept       json
-// @Produce      json
+// @Accept       json,xml,application/msgpack,text/csv
+// @Produce      json,xml,application/msgpack,text/csv
 // @Param        request body entity.LoginRequest true "Login request payload"
 // @Success 200 {object} entity.Tokens
 // @Failure 400 {object} entity.ErrorResponse
+// @Failure 401 {object} entity.ErrorResponse
+// @Failure 403 {object} entity.ErrorResponse
 // @Failure 500 {object} entity.ErrorResponse
+// @Failure 422 {object} entity.ErrorResponse
+// @Failure 429 {object} entity.ErrorResponse
 // @Router       /api/v1/auth/login [post]
+// @Router       /api/v2/auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
-	var req struct {
-		Name     string `json:"name"`
-		Password string `json:"password"`
+	var req entity.LoginRequest
+
+	if err := bindBody(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	accessToken, refreshToken, err := h.useCase.Login(c.Request.Context(), req.Name, req.Password)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+	render(c, http.StatusOK, entity.Tokens{AccessToken: accessToken, RefreshToken: refreshToken})
+}
+
+// @Summary      Refresh tokens
+// @Description  Trades a refresh token for a new access & refresh token pair
+// @Tags         auth
+// @Accept       json,xml,application/msgpack,text/csv
+// @Produce      json,xml,application/msgpack,text/csv
+// @Param        request body entity.RefreshRequest true "Refresh request payload"
+// @Success 200 {object} entity.Tokens
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 401 {object} entity.ErrorResponse
+// @Failure 403 {object} entity.ErrorResponse
+// @Failure 422 {object} entity.ErrorResponse
+// @Failure 429 {object} entity.ErrorResponse
+// @Failure 500 {object} entity.ErrorResponse
+// @Router       /api/v1/auth/refresh [post]
+// @Router       /api/v2/auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req entity.RefreshRequest
+
+	if err := bindBody(c, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
-	accessToken, refreshToken, err := h.useCase.Login(context.Background(), req.Name, req.Password)
+	accessToken, refreshToken, err := h.useCase.Refresh(c.Request.Context(), req.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
-	})
+	render(c, http.StatusOK, entity.Tokens{AccessToken: accessToken, RefreshToken: refreshToken})
 }
 
 // @Summary      Get authenticated user data
 // @Description  Retrieves user data using the authorization token
 // @Tags         auth
 // @Security 	 BearerAuth
-// @Accept       json
-// @Produce      json
-// @Success 200 {object} entity.User
+// @Accept       json,xml,application/msgpack,text/csv
+// @Produce      json,xml,application/msgpack,text/csv
+// @Success 200 {object} entity.ProfileResponse
 // @Failure 401 {object} entity.ErrorResponse
 // @Failure 500 {object} entity.ErrorResponse
 // @Router       /api/v1/auth/me [get]
+// @Router       /api/v2/auth/me [get]
 func (h *AuthHandler) GetUserProfile(c *gin.Context) {
 	token := c.GetHeader("Authorization")
 	if token == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is missing"})
+		c.Error(entity.ErrMissingAuthorization)
 		return
 	}
 
@@ -75,11 +114,11 @@ func (h *AuthHandler) GetUserProfile(c *gin.Context) {
 		token = token[7:]
 	}
 
-	user, err := h.useCase.GetUserProfile(context.Background(), token)
+	user, err := h.useCase.GetUserProfile(c.Request.Context(), token)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"user": user})
+	render(c, http.StatusOK, entity.ProfileResponse{User: user})
 }
@@ -0,0 +1,52 @@
+package delivery
+
+import (
+	"net/http"
+	"sample-project/internal/entity"
+	"sample-project/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NOTE - batch handler struct
+type BatchHandler struct {
+	useCase usecase.BatchUseCase
+}
+
+// NOTE - new batch handler
+func NewBatchHandler(router *gin.Engine, useCase usecase.BatchUseCase) {
+	handler := &BatchHandler{useCase: useCase}
+
+	router.POST("/api/v1/batch", handler.ExecuteBatch)
+	router.POST("/api/v2/batch", handler.ExecuteBatch)
+}
+
+// NOTE - execute batch handler
+// @Summary Run a batch of writes
+// @Description Create, update and delete users and subjects in one all-or-nothing transaction. Operations run in order; `id`, and `subject_id` in a user body, take `$<ref>` to point at an earlier create with that `ref`. User updates and deletes need the `version` they were read at, user bodies are merge patches. When an operation is rejected nothing is written and every failed operation is listed under `operations[<index>]`
+// @Tags batch
+// @Accept json
+// @Produce json,xml,application/msgpack
+// @Param batch body entity.BatchRequest true "Operations, at most 100"
+// @Success 200 {object} entity.BatchResponse
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 409 {object} entity.ErrorResponse
+// @Failure 422 {object} entity.ErrorResponse
+// @Failure 500 {object} entity.ErrorResponse
+// @Router /api/v1/batch [post]
+// @Router /api/v2/batch [post]
+func (h *BatchHandler) ExecuteBatch(c *gin.Context) {
+	var req entity.BatchRequest
+	if err := bindBody(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	results, err := h.useCase.ExecuteBatch(c.Request.Context(), req.Operations)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	render(c, http.StatusOK, entity.BatchResponse{Results: results})
+}
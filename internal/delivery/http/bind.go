@@ -0,0 +1,27 @@
+package delivery
+
+import (
+	"sample-project/internal/delivery/http/negotiation"
+	"sample-project/internal/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NOTE - decodes the body into obj by its Content-Type and runs its binding rules
+func bindBody(c *gin.Context, obj interface{}) error {
+	body, err := negotiation.DecodeBody(c, obj)
+	if err != nil {
+		return err
+	}
+
+	if err := validation.DecodeJSON(body, obj); err != nil {
+		return err
+	}
+
+	return validation.Struct(c.Request.Context(), obj)
+}
+
+// NOTE - writes body in the format negotiated for the request
+func render(c *gin.Context, status int, body interface{}) {
+	negotiation.Render(c, status, body)
+}
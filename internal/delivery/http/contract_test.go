@@ -0,0 +1,429 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sample-project/docs"
+	"sample-project/internal/config/health"
+	"sample-project/internal/delivery/graphql"
+	"sample-project/internal/delivery/http/middleware"
+	"sample-project/internal/entity"
+	"sample-project/internal/repository"
+	"sample-project/internal/usecase"
+	"sample-project/internal/utils"
+	"sample-project/internal/validation"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+var (
+	contractTime    = time.Date(2026, time.October, 19, 8, 30, 0, 0, time.UTC)
+	contractSubject = entity.Subject{ID: 2, Name: "Math", Status: true, CreatedAt: contractTime, UpdatedAt: contractTime}
+	contractUser    = entity.User{
+		ID: 1, Name: "Noy", Email: "noy@example.com", SubjectID: 2, Status: true, State: entity.UserStateActive,
+		Version: 3, Day: 19, Month: 10, Year: 2026, CreatedAt: contractTime, UpdatedAt: contractTime, Subject: &contractSubject,
+	}
+)
+
+// contractUsers answers every user operation with contractUser
+type contractUsers struct{ usecase.UserUseCase }
+
+func (contractUsers) GetUsers(ctx context.Context, page, limit int, name string, startDate, endDate string, state entity.UserState, projection entity.Projection) ([]entity.User, int, error) {
+	return []entity.User{contractUser}, 1, nil
+}
+
+func (contractUsers) GetUserByID(ctx context.Context, id int, projection entity.Projection) (*entity.User, error) {
+	user := contractUser
+	return &user, nil
+}
+
+func (contractUsers) GetUserByName(ctx context.Context, name string) (*entity.User, error) {
+	user := contractUser
+	return &user, nil
+}
+
+func (contractUsers) CreateUser(ctx context.Context, user entity.User) (*entity.User, error) {
+	created := contractUser
+	return &created, nil
+}
+
+func (contractUsers) UpdateUser(ctx context.Context, id int, versions []int, patch entity.UserPatch) (*entity.User, error) {
+	user := contractUser
+	user.Version++
+	return &user, nil
+}
+
+func (contractUsers) UpdateAvatar(ctx context.Context, id int, image []byte) (*entity.AvatarResponse, error) {
+	return &entity.AvatarResponse{
+		URL:        "https://cdn.example.com/avatars/1/original.jpg?v=1",
+		Thumbnails: map[string]string{"64": "https://cdn.example.com/avatars/1/64.jpg?v=1"},
+	}, nil
+}
+
+func (contractUsers) TransitionState(ctx context.Context, id int, req entity.TransitionUserStateRequest, actorID *int) (*entity.User, error) {
+	user := contractUser
+	user.State = req.State
+	user.SuspendedReason = req.Reason
+	return &user, nil
+}
+
+func (contractUsers) GetStateTransitions(ctx context.Context, id int) ([]entity.UserStateTransition, error) {
+	actor := 9
+	return []entity.UserStateTransition{{ID: 1, UserID: id, FromState: entity.UserStatePending, ToState: entity.UserStateActive, ActorID: &actor, CreatedAt: contractTime}}, nil
+}
+
+func (contractUsers) DeleteUser(ctx context.Context, id int, versions []int) error { return nil }
+
+func (contractUsers) ClearUserCache(ctx context.Context) error { return nil }
+
+// contractSubjects answers every subject operation with contractSubject
+type contractSubjects struct{ usecase.SubjectUsecase }
+
+func (contractSubjects) GetSubject(ctx context.Context, projection entity.Projection) ([]entity.Subject, error) {
+	return []entity.Subject{contractSubject}, nil
+}
+
+func (contractSubjects) GetSubjectByID(ctx context.Context, id int, projection entity.Projection) (*entity.Subject, error) {
+	subject := contractSubject
+	return &subject, nil
+}
+
+func (contractSubjects) CreateSubject(ctx context.Context, subject entity.Subject) (*entity.Subject, error) {
+	created := contractSubject
+	return &created, nil
+}
+
+func (contractSubjects) UpdateSubject(ctx context.Context, id int, patch entity.SubjectPatch) (*entity.Subject, error) {
+	subject := contractSubject
+	return &subject, nil
+}
+
+func (contractSubjects) DeleteSubject(ctx context.Context, id int) error { return nil }
+
+func (contractSubjects) ClearSubjectCache(ctx context.Context) error { return nil }
+
+func (contractSubjects) GetSubjectsETag(ctx context.Context, projection entity.Projection) string {
+	return ""
+}
+
+func (contractSubjects) GetSubjectETag(ctx context.Context, id int, projection entity.Projection) string {
+	return ""
+}
+
+type contractAuth struct{}
+
+func (contractAuth) Login(ctx context.Context, name, password string) (string, string, error) {
+	return "access", "refresh", nil
+}
+
+func (contractAuth) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
+	return "access", "refresh", nil
+}
+
+func (contractAuth) GetUserProfile(ctx context.Context, token string) (*entity.User, error) {
+	user := contractUser
+	return &user, nil
+}
+
+type contractPrivacy struct{}
+
+func (contractPrivacy) ExportUserData(ctx context.Context, id int, actorID *int) ([]byte, error) {
+	return []byte("PK\x05\x06" + strings.Repeat("\x00", 18)), nil
+}
+
+func (contractPrivacy) EraseUser(ctx context.Context, id int, actorID *int) (*entity.PrivacyRecord, error) {
+	return &entity.PrivacyRecord{ID: 1, UserID: id, Type: entity.PrivacyRequestErasure, ActorID: actorID, Details: "{}", CompletedAt: contractTime, PrevHash: "0", Hash: "1"}, nil
+}
+
+func (contractPrivacy) VerifyPrivacyLog(ctx context.Context) (*entity.PrivacyChainStatus, error) {
+	return &entity.PrivacyChainStatus{Valid: true, Records: 1}, nil
+}
+
+type contractBatch struct{}
+
+func (contractBatch) ExecuteBatch(ctx context.Context, operations []entity.BatchOperation) ([]entity.BatchResult, error) {
+	subject := contractSubject
+	return []entity.BatchResult{{Index: 0, Op: entity.BatchOpCreate, Resource: entity.BatchResourceSubject, Ref: "math", ID: subject.ID, Status: http.StatusCreated, Data: &subject}}, nil
+}
+
+// contractEvents ends every stream right away
+type contractEvents struct{}
+
+func (contractEvents) Run(ctx context.Context) {}
+
+func (contractEvents) Subscribe(ctx context.Context, filter entity.EventFilter, lastEventID string) (<-chan entity.ChangeEvent, error) {
+	events := make(chan entity.ChangeEvent)
+	close(events)
+	return events, nil
+}
+
+// contractUserRepo and contractSubjectRepo back the validation rules that look records up
+type contractUserRepo struct{ repository.UserRepository }
+
+func (contractUserRepo) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
+	return nil, entity.NotFound("user_not_found", "user not found")
+}
+
+type contractSubjectRepo struct{ repository.SubjectRepository }
+
+func (contractSubjectRepo) GetSubjectByID(ctx context.Context, id int, projection entity.Projection) (*entity.Subject, error) {
+	subject := contractSubject
+	return &subject, nil
+}
+
+// violationLog collects what OpenAPIValidation reports
+type violationLog struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *violationLog) Enabled(context.Context, slog.Level) bool { return true }
+
+func (l *violationLog) Handle(ctx context.Context, record slog.Record) error {
+	if !strings.Contains(record.Message, "OpenAPI") {
+		return nil
+	}
+	line := record.Message
+	record.Attrs(func(attr slog.Attr) bool {
+		line += fmt.Sprintf(" %s=%v", attr.Key, attr.Value)
+		return true
+	})
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, line)
+	return nil
+}
+
+func (l *violationLog) WithAttrs([]slog.Attr) slog.Handler { return l }
+
+func (l *violationLog) WithGroup(string) slog.Handler { return l }
+
+func (l *violationLog) take() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lines := l.lines
+	l.lines = nil
+	return lines
+}
+
+// router with every handler over the contract usecases and the spec enforced, in the order cmd/main.go registers them
+func contractRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+
+	openAPIValidation, err := middleware.OpenAPIValidation([]byte(docs.SwaggerInfo.ReadDoc()), middleware.OpenAPIEnforce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validation.Register(contractUserRepo{}, contractSubjectRepo{})
+
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(middleware.Locale(), middleware.Authenticate("/api/", "/graphql"), openAPIValidation, middleware.ErrorHandler(), middleware.ContentNegotiation("/api/", "application/zip", "text/event-stream"))
+
+	NewUserHandler(router, contractUsers{})
+	NewAuthHandler(router, contractAuth{})
+	NewSubjectHandler(router, contractSubjects{})
+	NewPrivacyHandler(router, contractPrivacy{})
+	NewBatchHandler(router, contractBatch{})
+	graphql.NewGraphQLHandler(router, contractUsers{}, contractSubjects{})
+	NewEventHandler(router, contractEvents{}, func(string) bool { return true })
+	NewHealthHandler(router, health.NewChecker(time.Second))
+	return router
+}
+
+// contractCase is one request of the contract, route is the operation of the spec it covers
+type contractCase struct {
+	route       string
+	target      string
+	body        string
+	contentType string
+	header      http.Header
+	status      int
+}
+
+func avatarForm(t *testing.T) (string, string) {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("avatar", "avatar.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte("\x89PNG\r\n\x1a\n"))
+	form.Close()
+	return body.String(), form.FormDataContentType()
+}
+
+func contractCases(t *testing.T) []contractCase {
+	avatar, avatarType := avatarForm(t)
+	ifMatch := http.Header{"If-Match": {`"3"`}}
+	graphqlQuery := `{ users { nodes { id name } pageInfo { total } } }`
+
+	var cases []contractCase
+	for _, v := range []string{"v1", "v2"} {
+		api := "/api/" + v
+		cases = append(cases,
+			contractCase{route: "POST " + api + "/auth/login", body: `{"name":"Noy","password":"password1"}`, status: http.StatusOK},
+			contractCase{route: "POST " + api + "/auth/refresh", body: `{"refresh_token":"refresh"}`, status: http.StatusOK},
+			contractCase{route: "GET " + api + "/auth/me", status: http.StatusOK},
+			contractCase{route: "POST " + api + "/batch", body: `{"operations":[{"op":"create","resource":"subject","ref":"math","body":{"name":"Math"}}]}`, status: http.StatusOK},
+			contractCase{route: "GET " + api + "/events/stream", target: api + "/events/stream?resource=user", header: http.Header{"Accept": {"text/event-stream"}}, status: http.StatusOK},
+			contractCase{route: "GET " + api + "/events/ws", target: api + "/events/ws?resource=user", status: http.StatusSwitchingProtocols},
+			contractCase{route: "GET " + api + "/privacy/records/verify", status: http.StatusOK},
+			contractCase{route: "GET " + api + "/subjects", target: api + "/subjects?include=users", status: http.StatusOK},
+			contractCase{route: "POST " + api + "/subjects", body: `{"name":"Math"}`, status: http.StatusCreated},
+			contractCase{route: "GET " + api + "/subjects/{id}", target: api + "/subjects/2", status: http.StatusOK},
+			contractCase{route: "PATCH " + api + "/subjects/{id}", target: api + "/subjects/2", body: `{"name":"Algebra"}`, contentType: "application/merge-patch+json", status: http.StatusOK},
+			contractCase{route: "GET " + api + "/users", target: api + "/users?page=1&limit=10&state=ACTIVE&include=subject", status: http.StatusOK},
+			contractCase{route: "POST " + api + "/users", body: `{"name":"Noy","email":"noy@example.com","password":"password1","subject_id":2}`, status: http.StatusCreated},
+			contractCase{route: "GET " + api + "/users/{id}", target: api + "/users/1", status: http.StatusOK},
+			contractCase{route: "PATCH " + api + "/users/{id}", target: api + "/users/1", body: `{"name":"Noy P."}`, contentType: "application/merge-patch+json", header: ifMatch, status: http.StatusOK},
+			contractCase{route: "PUT " + api + "/users/{id}/avatar", target: api + "/users/1/avatar", body: avatar, contentType: avatarType, status: http.StatusOK},
+			contractCase{route: "POST " + api + "/users/{id}/erasure", target: api + "/users/1/erasure", status: http.StatusOK},
+			contractCase{route: "GET " + api + "/users/{id}/export", target: api + "/users/1/export", header: http.Header{"Accept": {"application/zip"}}, status: http.StatusOK},
+			contractCase{route: "POST " + api + "/users/{id}/state", target: api + "/users/1/state", body: `{"state":"SUSPENDED","reason":"unpaid fees"}`, status: http.StatusOK},
+			contractCase{route: "GET " + api + "/users/{id}/state-transitions", target: api + "/users/1/state-transitions", status: http.StatusOK},
+		)
+	}
+
+	return append(cases,
+		contractCase{route: "PUT /api/v1/subjects/update/{id}", target: "/api/v1/subjects/update/2", body: `{"name":"Algebra","status":true}`, status: http.StatusOK},
+		contractCase{route: "DELETE /api/v1/subjects/delete/{id}", target: "/api/v1/subjects/delete/2", status: http.StatusNoContent},
+		contractCase{route: "DELETE /api/v1/subjects/clear-cache", status: http.StatusOK},
+		contractCase{route: "PUT /api/v2/subjects/{id}", target: "/api/v2/subjects/2", body: `{"name":"Algebra","status":true}`, status: http.StatusOK},
+		contractCase{route: "DELETE /api/v2/subjects/{id}", target: "/api/v2/subjects/2", status: http.StatusNoContent},
+		contractCase{route: "DELETE /api/v2/subjects/cache", status: http.StatusNoContent},
+		contractCase{route: "GET /api/v1/users/by/{name}", target: "/api/v1/users/by/Noy", status: http.StatusOK},
+		contractCase{route: "PUT /api/v1/users/update/{id}", target: "/api/v1/users/update/1", body: `{"name":"Noy P."}`, header: ifMatch, status: http.StatusOK},
+		contractCase{route: "DELETE /api/v1/users/delete/{id}", target: "/api/v1/users/delete/1", header: ifMatch, status: http.StatusNoContent},
+		contractCase{route: "DELETE /api/v1/users/clear-cache", status: http.StatusOK},
+		contractCase{route: "PUT /api/v2/users/{id}", target: "/api/v2/users/1", body: `{"name":"Noy P."}`, header: ifMatch, status: http.StatusOK},
+		contractCase{route: "DELETE /api/v2/users/{id}", target: "/api/v2/users/1", header: ifMatch, status: http.StatusNoContent},
+		contractCase{route: "DELETE /api/v2/users/cache", status: http.StatusNoContent},
+		contractCase{route: "GET /graphql", target: "/graphql?query=" + url.QueryEscape(graphqlQuery), status: http.StatusOK},
+		contractCase{route: "POST /graphql", body: fmt.Sprintf(`{"query":%q}`, graphqlQuery), status: http.StatusOK},
+		contractCase{route: "GET /healthz", status: http.StatusOK},
+		contractCase{route: "GET /readyz", status: http.StatusOK},
+	)
+}
+
+// NOTE - every operation of the spec, as "METHOD /path"
+func specOperations(t *testing.T) map[string]bool {
+	t.Helper()
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal([]byte(docs.SwaggerInfo.ReadDoc()), &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	operations := map[string]bool{}
+	for path, methods := range spec.Paths {
+		for method := range methods {
+			operations[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+	return operations
+}
+
+// NOTE - runs every operation of the spec against the router with request and response validation on,
+// any mismatch between the API and its spec fails the test
+func TestContractMatchesSpec(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	token, _, err := utils.GenerateToken(9, 1, "Admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	violations := &violationLog{}
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(violations))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	router := contractRouter(t)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	operations := specOperations(t)
+	for _, tc := range contractCases(t) {
+		if !operations[tc.route] {
+			t.Errorf("%s is not in the spec", tc.route)
+			continue
+		}
+		delete(operations, tc.route)
+
+		method, path, _ := strings.Cut(tc.route, " ")
+		target := tc.target
+		if target == "" {
+			target = path
+		}
+
+		var status int
+		var body string
+		if strings.HasSuffix(path, "/ws") {
+			status = dialContract(t, server.URL+target, token)
+		} else {
+			req := httptest.NewRequest(method, target, strings.NewReader(tc.body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			if tc.body != "" {
+				contentType := tc.contentType
+				if contentType == "" {
+					contentType = "application/json"
+				}
+				req.Header.Set("Content-Type", contentType)
+			}
+			for name, values := range tc.header {
+				req.Header[name] = values
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			status, body = w.Code, w.Body.String()
+		}
+		if status != tc.status {
+			t.Errorf("%s: status is %d, want %d: %s", tc.route, status, tc.status, body)
+		}
+
+		for _, line := range violations.take() {
+			t.Errorf("%s: %s", tc.route, line)
+		}
+	}
+
+	for operation := range operations {
+		t.Errorf("%s has no contract case", operation)
+	}
+}
+
+// NOTE - opens the WebSocket at target and returns the status of the handshake
+func dialContract(t *testing.T, target, token string) int {
+	t.Helper()
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(target, "http"), http.Header{"Authorization": {"Bearer " + token}})
+	if err != nil && resp == nil {
+		t.Errorf("%s: %v", target, err)
+		return 0
+	}
+	if conn != nil {
+		conn.Close()
+	}
+	return resp.StatusCode
+}
@@ -0,0 +1,145 @@
+package delivery
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sample-project/internal/entity"
+	"sample-project/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NOTE - strong entity tag for a versioned resource
+func versionETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// NOTE - parses If-Match into the versions it accepts, an empty slice means `*`
+func parseIfMatch(c *gin.Context) ([]int, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return nil, entity.ErrMissingIfMatch
+	}
+
+	if header == "*" {
+		return []int{}, nil
+	}
+
+	var versions []int
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+
+		// weak tags never match under the strong comparison If-Match requires
+		if strings.HasPrefix(tag, "W/") {
+			continue
+		}
+
+		version, err := strconv.Atoi(strings.Trim(tag, `"`))
+		if err != nil {
+			continue
+		}
+		versions = append(versions, version)
+	}
+
+	// nothing usable to compare against, so nothing can match
+	if len(versions) == 0 {
+		versions = append(versions, -1)
+	}
+
+	return versions, nil
+}
+
+// NOTE - strong entity tag from the JSON representation of body
+func contentETag(body interface{}) string {
+	data, _ := json.Marshal(body)
+	return utils.ContentETag(data)
+}
+
+// NOTE - reports whether If-None-Match lists etag, weak comparison as RFC 9110 requires for GET
+func etagMatches(c *gin.Context, etag string) bool {
+	header := strings.TrimSpace(c.GetHeader("If-None-Match"))
+	if header == "" || etag == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// NOTE - evaluates the GET preconditions, If-Modified-Since only counts when If-None-Match is absent
+func notModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	if c.GetHeader("If-None-Match") != "" {
+		return etagMatches(c, etag)
+	}
+
+	since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+	if err != nil || lastModified.IsZero() {
+		return false
+	}
+	// HTTP dates only carry seconds
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// NOTE - answers a cached etag without loading the resource, reports whether the response was written
+func respondNotModified(c *gin.Context, etag string) bool {
+	if !etagMatches(c, etag) {
+		return false
+	}
+
+	c.Header("ETag", etag)
+	c.Status(http.StatusNotModified)
+	c.Writer.WriteHeaderNow()
+	return true
+}
+
+// NOTE - writes body with its validators, or an empty 304 when the client copy is still current
+func respondConditional(c *gin.Context, etag string, lastModified time.Time, body interface{}) {
+	c.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(c, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+
+	render(c, http.StatusOK, body)
+}
+
+// NOTE - latest updated_at of the users, zero when there are none
+func usersLastModified(users []entity.User) time.Time {
+	var latest time.Time
+	for _, user := range users {
+		if user.UpdatedAt.After(latest) {
+			latest = user.UpdatedAt
+		}
+	}
+	return latest
+}
+
+// NOTE - latest updated_at of the subjects and the users they embed
+func subjectsLastModified(subjects ...entity.Subject) time.Time {
+	var latest time.Time
+	for _, subject := range subjects {
+		if subject.UpdatedAt.After(latest) {
+			latest = subject.UpdatedAt
+		}
+		if users := usersLastModified(subject.User); users.After(latest) {
+			latest = users
+		}
+	}
+	return latest
+}
@@ -0,0 +1,244 @@
+package delivery
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sample-project/internal/entity"
+	"sample-project/internal/usecase"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// comment line that keeps proxies from closing an idle event stream
+	eventHeartbeat = 15 * time.Second
+	// how long an EventSource waits before it reconnects
+	eventRetry = 3 * time.Second
+
+	wsWriteWait    = 10 * time.Second
+	wsPongWait     = 60 * time.Second
+	wsPingInterval = 30 * time.Second
+)
+
+var eventResources = []string{entity.EventResourceUser, entity.EventResourceSubject}
+
+// NOTE - event handler struct
+type EventHandler struct {
+	useCase  usecase.EventUseCase
+	upgrader websocket.Upgrader
+}
+
+// NOTE - new event handler, allowOrigin decides which pages may open the WebSocket
+func NewEventHandler(router *gin.Engine, useCase usecase.EventUseCase, allowOrigin func(origin string) bool) {
+	handler := &EventHandler{
+		useCase: useCase,
+		upgrader: websocket.Upgrader{
+			HandshakeTimeout: 10 * time.Second,
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				// clients that are not browsers send no origin
+				return origin == "" || allowOrigin(origin)
+			},
+		},
+	}
+
+	// v1 is deprecated, see middleware.Deprecation
+	events := router.Group("/api/v1/events")
+
+	events.GET("/stream", handler.Stream)
+	events.GET("/ws", handler.WebSocket)
+
+	eventsV2 := router.Group("/api/v2/events")
+
+	eventsV2.GET("/stream", handler.Stream)
+	eventsV2.GET("/ws", handler.WebSocket)
+}
+
+// NOTE - change stream over server-sent events
+// @Summary Stream changes
+// @Description Pushes an event after every committed write to a user or subject of the caller's school: user.created, user.updated, user.deleted, subject.created, subject.updated and subject.deleted.
+// @Description Every event has an id, send it back as Last-Event-ID (or last_event_id) to resume after it. Reconnecting EventSources do that on their own.
+// @Description Clients that fall too far behind are disconnected and resume the same way.
+// @Tags events
+// @Produce text/event-stream
+// @Param resource query string false "Only these resources, comma separated" Enums(user, subject)
+// @Param id query int false "Only this resource ID, needs exactly one resource"
+// @Param Last-Event-ID header string false "Resume after this event"
+// @Param last_event_id query string false "Resume after this event, for clients that cannot set headers"
+// @Param access_token query string false "Access token, for clients that cannot set the Authorization header"
+// @Success 200 {object} entity.ChangeEvent "One `id`, `event` and `data` block per event, data is the JSON event"
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 503 {object} entity.ErrorResponse
+// @Router /api/v1/events/stream [get]
+// @Router /api/v2/events/stream [get]
+func (h *EventHandler) Stream(c *gin.Context) {
+	filter, err := eventFilter(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	events, err := h.useCase.Subscribe(ctx, filter, lastEventID(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// the stream outlives the write timeout of the server
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		slog.WarnContext(ctx, "Failed to lift the write deadline of an event stream", "error", err)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	// nginx would otherwise buffer the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", eventRetry.Milliseconds())
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+		}
+		c.Writer.Flush()
+	}
+}
+
+// NOTE - change stream over a WebSocket
+// @Summary Stream changes over a WebSocket
+// @Description Same events, filters and resuming as /events/stream, one JSON event per text message. Only pages from the CORS origins may connect.
+// @Tags events
+// @Param resource query string false "Only these resources, comma separated" Enums(user, subject)
+// @Param id query int false "Only this resource ID, needs exactly one resource"
+// @Param last_event_id query string false "Resume after this event"
+// @Param access_token query string false "Access token, browsers cannot set the Authorization header on a WebSocket"
+// @Success 101 "Switching protocols"
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 503 {object} entity.ErrorResponse
+// @Router /api/v1/events/ws [get]
+// @Router /api/v2/events/ws [get]
+func (h *EventHandler) WebSocket(c *gin.Context) {
+	filter, err := eventFilter(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	events, err := h.useCase.Subscribe(ctx, filter, lastEventID(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// the upgrader writes its own error response
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// the client only sends pongs and the close frame, reading them notices when it is gone
+	closed := make(chan struct{})
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-closed:
+			return
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "reconnect with last_event_id"), time.Now().Add(wsWriteWait))
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// NOTE - filter from the resource and id query parameters
+func eventFilter(c *gin.Context) (entity.EventFilter, error) {
+	var filter entity.EventFilter
+
+	if raw := c.Query("resource"); raw != "" {
+		for _, resource := range strings.Split(raw, ",") {
+			resource = strings.ToLower(strings.TrimSpace(resource))
+			if !slices.Contains(eventResources, resource) {
+				return filter, entity.BadRequest("invalid_resource", "resource must be one of %s", strings.Join(eventResources, ", ")).With("Resources", strings.Join(eventResources, ", "))
+			}
+			filter.Resources = append(filter.Resources, resource)
+		}
+	}
+
+	if raw := c.Query("id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil || id <= 0 {
+			return filter, entity.BadRequest("invalid_resource_id", "id must be a positive integer")
+		}
+		// user 5 and subject 5 are different things
+		if len(filter.Resources) != 1 {
+			return filter, entity.BadRequest("resource_required", "filtering by id needs exactly one resource")
+		}
+		filter.ResourceID = id
+	}
+
+	return filter, nil
+}
+
+// NOTE - event to resume after, EventSource sends the header on reconnect
+func lastEventID(c *gin.Context) string {
+	if id := c.GetHeader("Last-Event-ID"); id != "" {
+		return id
+	}
+	return c.Query("last_event_id")
+}
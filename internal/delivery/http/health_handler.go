@@ -0,0 +1,48 @@
+package delivery
+
+import (
+	"net/http"
+	"sample-project/internal/config/health"
+	"sample-project/internal/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(router *gin.Engine, checker *health.Checker) {
+	handler := &HealthHandler{checker: checker}
+
+	router.GET("/healthz", handler.Liveness)
+	router.GET("/readyz", handler.Readiness)
+}
+
+// NOTE - liveness probe handler, the process is up and serving
+// @Summary      Liveness probe
+// @Description  Reports that the process is running, dependencies are not checked
+// @Tags         health
+// @Produce      json
+// @Success 200 {object} entity.HealthReport
+// @Router       /healthz [get]
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, entity.HealthReport{Status: entity.HealthOK})
+}
+
+// NOTE - readiness probe handler
+// @Summary      Readiness probe
+// @Description  Pings Postgres, Redis and RabbitMQ. Ready while every critical dependency is up, a non critical one being down reports degraded
+// @Tags         health
+// @Produce      json
+// @Success 200 {object} entity.HealthReport
+// @Failure 503 {object} entity.HealthReport "A critical dependency is down or the server is draining"
+// @Router       /readyz [get]
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report, ready := h.checker.Ready(c.Request.Context())
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
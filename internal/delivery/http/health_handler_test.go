@@ -0,0 +1,64 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sample-project/internal/config/health"
+	"sample-project/internal/entity"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestProbesReportReadinessByStatusCode(t *testing.T) {
+	postgresUp := true
+	checker := health.NewChecker(time.Second,
+		health.Check{Name: "postgres", Critical: true, Ping: func(ctx context.Context) error {
+			if !postgresUp {
+				return errors.New("connection refused")
+			}
+			return nil
+		}},
+		health.Check{Name: "redis", Ping: func(ctx context.Context) error { return errors.New("connection refused") }},
+	)
+	router := gin.New()
+	NewHealthHandler(router, checker)
+
+	probe := func(path string) (int, entity.HealthReport) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var report entity.HealthReport
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatalf("%s: %v in %s", path, err, w.Body)
+		}
+		return w.Code, report
+	}
+
+	steps := []struct {
+		name   string
+		before func()
+		path   string
+		status int
+		health string
+	}{
+		{name: "cache down", path: "/readyz", status: http.StatusOK, health: entity.HealthDegraded},
+		{name: "database down", before: func() { postgresUp = false }, path: "/readyz", status: http.StatusServiceUnavailable, health: entity.HealthUnavailable},
+		{name: "alive with the database down", path: "/healthz", status: http.StatusOK, health: entity.HealthOK},
+		{name: "draining", before: func() { postgresUp = true; checker.SetDraining() }, path: "/readyz", status: http.StatusServiceUnavailable, health: entity.HealthDraining},
+		{name: "alive while draining", path: "/healthz", status: http.StatusOK, health: entity.HealthOK},
+	}
+
+	for _, step := range steps {
+		if step.before != nil {
+			step.before()
+		}
+		status, report := probe(step.path)
+		if status != step.status || report.Status != step.health {
+			t.Errorf("%s: got %d %s, want %d %s", step.name, status, report.Status, step.status, step.health)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package delivery
+
+import (
+	"mime"
+	"sample-project/internal/delivery/http/negotiation"
+	"sample-project/internal/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+// media types a merge-patch document is accepted in, null is nil="true" in XML
+const acceptPatch = mergePatchContentType + ", application/json, application/msgpack, application/xml, text/csv"
+
+// NOTE - binds a RFC 7396 merge-patch body
+func bindMergePatch(c *gin.Context, patch interface{}) error {
+	mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if err != nil || !negotiation.Readable(mediaType) {
+		c.Header("Accept-Patch", acceptPatch)
+		return entity.NewError(entity.ErrUnsupportedMediaType, "unsupported_media_type", "Content-Type must be one of %s", acceptPatch).With("Types", acceptPatch)
+	}
+
+	return bindBody(c, patch)
+}
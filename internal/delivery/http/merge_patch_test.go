@@ -0,0 +1,78 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sample-project/internal/delivery/http/middleware"
+	"sample-project/internal/entity"
+	"sample-project/internal/validation"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// patchedUsers keeps the last patch the handlers passed on
+type patchedUsers struct {
+	contractUsers
+	patch *entity.UserPatch
+}
+
+func (u *patchedUsers) UpdateUser(ctx context.Context, id int, versions []int, patch entity.UserPatch) (*entity.User, error) {
+	u.patch = &patch
+	return u.contractUsers.UpdateUser(ctx, id, versions, patch)
+}
+
+func TestPatchUserOnlyWritesTheMembersItWasSent(t *testing.T) {
+	validation.Register(contractUserRepo{}, contractSubjectRepo{})
+	users := &patchedUsers{}
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	NewUserHandler(router, users)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		want        func(entity.UserPatch) bool
+	}{
+		{name: "name only", contentType: mergePatchContentType, body: `{"name":"Noy P."}`, status: http.StatusOK, want: func(p entity.UserPatch) bool {
+			return p.Name.Value == "Noy P." && !p.Status.Present && !p.SubjectID.Present
+		}},
+		{name: "deactivate", contentType: mergePatchContentType, body: `{"status":false}`, status: http.StatusOK, want: func(p entity.UserPatch) bool {
+			return p.Status.IsSet() && !p.Status.Value
+		}},
+		{name: "unlink subject", contentType: mergePatchContentType, body: `{"subject_id":null}`, status: http.StatusOK, want: func(p entity.UserPatch) bool {
+			return p.SubjectID.Null
+		}},
+		{name: "plain json", contentType: "application/json", body: `{"email":"noy@example.org"}`, status: http.StatusOK, want: func(p entity.UserPatch) bool {
+			return p.Email.Value == "noy@example.org"
+		}},
+		{name: "unsupported type", contentType: "text/plain", body: `name=Noy`, status: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		users.patch = nil
+		req := httptest.NewRequest(http.MethodPatch, "/api/v2/users/1", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		req.Header.Set("If-Match", `"3"`)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: got %d %s, want %d", tt.name, w.Code, w.Body, tt.status)
+			continue
+		}
+		if tt.want == nil {
+			if users.patch != nil || w.Header().Get("Accept-Patch") != acceptPatch {
+				t.Errorf("%s: patch %+v reached the use case, Accept-Patch is %q", tt.name, users.patch, w.Header().Get("Accept-Patch"))
+			}
+			continue
+		}
+		if users.patch == nil || !tt.want(*users.patch) {
+			t.Errorf("%s: the use case got %+v", tt.name, users.patch)
+		}
+	}
+}
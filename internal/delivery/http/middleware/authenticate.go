@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"sample-project/internal/config/logger"
+	"sample-project/internal/entity"
+	"sample-project/internal/utils"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// query parameter of the access token, for EventSource and WebSocket clients that cannot set headers
+const accessTokenQuery = "access_token"
+
+// NOTE - validates the access token of the requests under prefixes once and puts its claims on the request
+// context, the handlers, the tenant resolver and the rate limiter read them back. Requests without a valid
+// token go on anonymously, the routes that need a caller refuse them with Claims
+func Authenticate(prefixes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := accessToken(c)
+		if token == "" || !hasAnyPrefix(c.Request.URL.Path, prefixes) {
+			c.Next()
+			return
+		}
+
+		claims, err := utils.ValidateToken(token, false)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		ctx := utils.WithClaims(c.Request.Context(), claims)
+		// tag everything logged for the rest of the request with the caller
+		c.Request = c.Request.WithContext(logger.WithUserID(ctx, claims.UserID))
+
+		c.Next()
+	}
+}
+
+// NOTE - claims Authenticate found on the request, missing_authorization or invalid_token when it has none
+func Claims(c *gin.Context) (*utils.Claims, error) {
+	if claims := utils.ClaimsFrom(c.Request.Context()); claims != nil {
+		return claims, nil
+	}
+	if accessToken(c) == "" {
+		return nil, entity.ErrMissingAuthorization
+	}
+	return nil, entity.ErrInvalidToken
+}
+
+// NOTE - bearer token of the Authorization header. Event streams may send it as access_token instead,
+// browsers cannot set headers on an EventSource or a WebSocket
+func accessToken(c *gin.Context) string {
+	if token := c.GetHeader("Authorization"); token != "" {
+		return strings.TrimPrefix(token, "Bearer ")
+	}
+	if c.IsWebsocket() || strings.HasPrefix(c.GetHeader("Accept"), "text/event-stream") {
+		return c.Query(accessTokenQuery)
+	}
+	return ""
+}
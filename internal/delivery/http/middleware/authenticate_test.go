@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sample-project/internal/entity"
+	"sample-project/internal/utils"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthenticatePutsTheClaimsOnTheContext(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	token, _, err := utils.GenerateToken(3, 1, "Noy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		header http.Header
+		userID int
+		err    error
+	}{
+		{name: "no token", path: "/api/v2/users", err: entity.ErrMissingAuthorization},
+		{name: "bad token", path: "/api/v2/users", header: http.Header{"Authorization": {"Bearer nope"}}, err: entity.ErrInvalidToken},
+		{name: "valid token", path: "/api/v2/users", header: http.Header{"Authorization": {"Bearer " + token}}, userID: 3},
+		{name: "graphql", path: "/graphql", header: http.Header{"Authorization": {"Bearer " + token}}, userID: 3},
+		{name: "outside the prefixes", path: "/healthz", header: http.Header{"Authorization": {"Bearer " + token}}, err: entity.ErrInvalidToken},
+		{name: "query token on a plain request", path: "/api/v2/users?access_token=" + token, err: entity.ErrMissingAuthorization},
+		{name: "query token on an event stream", path: "/api/v2/events/stream?access_token=" + token, header: http.Header{"Accept": {"text/event-stream"}}, userID: 3},
+	}
+
+	for _, tt := range tests {
+		var claims *utils.Claims
+		var claimsErr error
+		router := gin.New()
+		router.Use(Authenticate("/api/", "/graphql"))
+		router.NoRoute(func(c *gin.Context) {
+			claims, claimsErr = Claims(c)
+			c.Status(http.StatusNoContent)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		for name, values := range tt.header {
+			req.Header[name] = values
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// a request without a valid token goes on, the routes that need a caller refuse it
+		if w.Code != http.StatusNoContent {
+			t.Errorf("%s: status is %d, want the request to go on", tt.name, w.Code)
+		}
+		if claimsErr != tt.err {
+			t.Errorf("%s: got %v, want %v", tt.name, claimsErr, tt.err)
+		}
+		if tt.err == nil && (claims == nil || claims.UserID != tt.userID) {
+			t.Errorf("%s: claims are %+v, want user %d", tt.name, claims, tt.userID)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"sample-project/internal/delivery/http/negotiation"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NOTE - resolves the response format of routes under prefix from ?format= and Accept, anything else is a 406.
+// passthrough lists media types some routes write themselves, like a zip export.
+func ContentNegotiation(prefix string, passthrough ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.Request.URL.Path, prefix) {
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Accept")
+
+		format, err := negotiation.Select(c)
+		if err != nil && !negotiation.Accepts(c, passthrough...) {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		negotiation.SetFormat(c, format)
+		c.Next()
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sample-project/internal/delivery/http/negotiation"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestContentNegotiationRefusesUnsupportedTypes(t *testing.T) {
+	router := gin.New()
+	router.Use(ErrorHandler(), ContentNegotiation("/api/", "application/zip"))
+	for _, path := range []string{"/api/v2/users/1", "/healthz"} {
+		router.GET(path, func(c *gin.Context) {
+			negotiation.Render(c, http.StatusOK, gin.H{"id": 1})
+		})
+	}
+
+	tests := []struct {
+		target      string
+		accept      string
+		status      int
+		contentType string
+	}{
+		{target: "/api/v2/users/1", status: http.StatusOK, contentType: "application/json; charset=utf-8"},
+		{target: "/api/v2/users/1", accept: "application/msgpack", status: http.StatusOK, contentType: "application/msgpack; charset=utf-8"},
+		{target: "/api/v2/users/1?format=xml", accept: "text/csv", status: http.StatusOK, contentType: "application/xml; charset=utf-8"},
+		{target: "/api/v2/users/1", accept: "image/png", status: http.StatusNotAcceptable, contentType: "application/json; charset=utf-8"},
+		{target: "/api/v2/users/1?format=yaml", status: http.StatusNotAcceptable, contentType: "application/json; charset=utf-8"},
+		// routes that write their own media type are let through
+		{target: "/api/v2/users/1", accept: "application/zip", status: http.StatusOK, contentType: "application/json; charset=utf-8"},
+		// outside of the prefix nothing is negotiated
+		{target: "/healthz", accept: "image/png", status: http.StatusOK, contentType: "application/json; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		if tt.accept != "" {
+			req.Header.Add("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.status || w.Header().Get("Content-Type") != tt.contentType {
+			t.Errorf("%s %q: got %d %s, want %d %s", tt.target, tt.accept, w.Code, w.Header().Get("Content-Type"), tt.status, tt.contentType)
+		}
+	}
+}
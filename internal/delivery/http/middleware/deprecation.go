@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// calls to deprecated routes by "METHOD /route", published on /debug/vars
+var deprecatedRequests = expvar.NewMap("deprecated_requests")
+
+// NOTE - marks every route under prefix as deprecated (RFC 9745) with its Sunset date (RFC 8594) and counts its use
+func Deprecation(prefix, successor string, deprecatedAt, sunset time.Time) gin.HandlerFunc {
+	deprecation := fmt.Sprintf("@%d", deprecatedAt.Unix())
+	sunsetDate := sunset.UTC().Format(http.TimeFormat)
+	link := fmt.Sprintf(`<%s>; rel="successor-version"`, successor)
+
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.Request.URL.Path, prefix) {
+			c.Next()
+			return
+		}
+
+		c.Header("Deprecation", deprecation)
+		c.Header("Sunset", sunsetDate)
+		c.Header("Link", link)
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		deprecatedRequests.Add(c.Request.Method+" "+route, 1)
+
+		c.Next()
+	}
+}
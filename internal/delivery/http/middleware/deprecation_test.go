@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NOTE - calls to route counted so far, 0 before the first
+func deprecatedCount(route string) int64 {
+	if counter, ok := deprecatedRequests.Get(route).(*expvar.Int); ok {
+		return counter.Value()
+	}
+	return 0
+}
+
+func TestDeprecationMarksAndCountsV1(t *testing.T) {
+	deprecatedAt := time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC)
+
+	router := gin.New()
+	router.Use(Deprecation("/api/v1/", "/api/v2", deprecatedAt, sunset))
+	for _, path := range []string{"/api/v1/users/:id", "/api/v2/users/:id"} {
+		router.GET(path, func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	}
+
+	before := deprecatedCount("GET /api/v1/users/:id")
+	for _, id := range []string{"1", "2"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users/"+id, nil))
+
+		if w.Header().Get("Deprecation") != "@1792368000" || w.Header().Get("Sunset") != "Wed, 30 Jun 2027 00:00:00 GMT" || w.Header().Get("Link") != `</api/v2>; rel="successor-version"` {
+			t.Errorf("v1 headers are %v", w.Header())
+		}
+	}
+	// counted per route, not per path
+	if got := deprecatedCount("GET /api/v1/users/:id") - before; got != 2 {
+		t.Errorf("counted %d calls, want 2", got)
+	}
+
+	v2Before := deprecatedCount("GET /api/v2/users/:id")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/users/1", nil))
+	if w.Header().Get("Deprecation") != "" || deprecatedCount("GET /api/v2/users/:id") != v2Before {
+		t.Errorf("v2 is marked or counted as deprecated: %v", w.Header())
+	}
+}
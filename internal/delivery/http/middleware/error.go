@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sample-project/internal/config/locale"
+	"sample-project/internal/delivery/http/negotiation"
+	"sample-project/internal/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// status code for every error kind, anything not listed is a 500
+var errorStatus = map[error]int{
+	entity.ErrBadRequest:           http.StatusBadRequest,
+	entity.ErrValidation:           http.StatusUnprocessableEntity,
+	entity.ErrUnauthorized:         http.StatusUnauthorized,
+	entity.ErrForbidden:            http.StatusForbidden,
+	entity.ErrNotFound:             http.StatusNotFound,
+	entity.ErrConflict:             http.StatusConflict,
+	entity.ErrPreconditionFailed:   http.StatusPreconditionFailed,
+	entity.ErrPreconditionRequired: http.StatusPreconditionRequired,
+	entity.ErrNotAcceptable:        http.StatusNotAcceptable,
+	entity.ErrUnsupportedMediaType: http.StatusUnsupportedMediaType,
+	entity.ErrPayloadTooLarge:      http.StatusRequestEntityTooLarge,
+	entity.ErrTooManyRequests:      http.StatusTooManyRequests,
+	entity.ErrTimeout:              http.StatusGatewayTimeout,
+	entity.ErrUnavailable:          http.StatusServiceUnavailable,
+}
+
+// NOTE - turns the last error a handler attached with c.Error into the error response
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		writeError(c, c.Errors.Last().Err)
+	}
+}
+
+// nginx's status for a request the client gave up on, only ever seen in the access log
+const statusClientClosedRequest = 499
+
+var errRequestTimeout = entity.NewError(entity.ErrTimeout, "request_timeout", "the request took too long, please try again later")
+
+// NOTE - writes the error envelope with the status of the error kind
+func writeError(c *gin.Context, err error) {
+	// whatever the store reported, a cancelled request context is the cause
+	switch c.Request.Context().Err() {
+	case context.DeadlineExceeded:
+		err = errRequestTimeout
+	case context.Canceled:
+		// the deadline of a request that finished is cancelled as well, its error still gets a response
+		if !errors.Is(context.Cause(c.Request.Context()), errRequestFinished) {
+			c.AbortWithStatus(statusClientClosedRequest)
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	c.Header("Content-Language", locale.Language(ctx).String())
+	c.Writer.Header().Add("Vary", "Accept-Language")
+
+	var domainErr *entity.Error
+	if errors.As(err, &domainErr) {
+		if status, ok := errorStatus[domainErr.Kind]; ok {
+			negotiation.Render(c, status, entity.ErrorResponse{
+				Code:    domainErr.Code,
+				Message: locale.Error(ctx, domainErr),
+				Errors:  locale.Fields(ctx, domainErr.Fields),
+			})
+			return
+		}
+	}
+
+	slog.ErrorContext(ctx, "Unhandled error", "method", c.Request.Method, "path", c.Request.URL.Path, "error", err)
+	negotiation.Render(c, http.StatusInternalServerError, entity.ErrorResponse{
+		Code:    "internal_error",
+		Message: locale.Localize(ctx, "internal_error", nil, "Something went wrong, please try again later"),
+	})
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sample-project/internal/entity"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestErrorHandlerMapsKindsToStatusAndEnvelope(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{name: "not found", err: entity.NotFound("user_not_found", "user with ID: %d not found", 5), status: http.StatusNotFound, code: "user_not_found"},
+		{name: "conflict", err: entity.Conflict("user_email_taken", "user with this email already exists"), status: http.StatusConflict, code: "user_email_taken"},
+		{name: "validation", err: entity.InvalidFields(entity.FieldError{Field: "email", Code: "email", Message: "must be a valid email address"}), status: http.StatusUnprocessableEntity, code: "validation_failed"},
+		{name: "unauthorized", err: entity.ErrInvalidToken, status: http.StatusUnauthorized, code: "invalid_token"},
+		{name: "precondition", err: entity.ErrVersionMismatch, status: http.StatusPreconditionFailed, code: "version_mismatch"},
+		{name: "wrapped", err: fmt.Errorf("failed to delete user: %w", entity.NotFound("user_not_found", "user not found")), status: http.StatusNotFound, code: "user_not_found"},
+		{name: "unknown kind", err: &entity.Error{Kind: errors.New("teapot"), Code: "teapot", Message: "short and stout"}, status: http.StatusInternalServerError, code: "internal_error"},
+		{name: "plain error", err: errors.New("connection refused"), status: http.StatusInternalServerError, code: "internal_error"},
+	}
+
+	for _, tt := range tests {
+		router := gin.New()
+		router.Use(ErrorHandler())
+		router.GET("/", func(c *gin.Context) { c.Error(tt.err) })
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		var body entity.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: %v in %s", tt.name, err, w.Body)
+		}
+		if w.Code != tt.status || body.Code != tt.code || body.Message == "" {
+			t.Errorf("%s: got %d %+v, want %d with code %s", tt.name, w.Code, body, tt.status, tt.code)
+		}
+		if tt.name == "validation" && (len(body.Errors) != 1 || body.Errors[0].Field != "email") {
+			t.Errorf("%s: field errors are %+v", tt.name, body.Errors)
+		}
+		if tt.status == http.StatusInternalServerError && body.Message == tt.err.Error() {
+			t.Errorf("%s: the response leaked %q", tt.name, body.Message)
+		}
+	}
+}
+
+func TestErrorHandlerLeavesAWrittenResponseAlone(t *testing.T) {
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusAccepted, "queued")
+		c.Error(entity.ErrInvalidToken)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusAccepted || w.Body.String() != "queued" {
+		t.Errorf("got %d %s, want the handler's response", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sample-project/internal/config/cache"
+	"sample-project/internal/entity"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// how long a completed response is replayed
+	idempotencyTTL = 24 * time.Hour
+	// how long a request may hold a key before another attempt can take over
+	idempotencyLockTTL = time.Minute
+	// how often a concurrent duplicate checks whether the first request finished
+	idempotencyPollInterval = 50 * time.Millisecond
+	// the body is held in memory to fingerprint it, no POST endpoint takes more
+	idempotencyMaxBodySize = 1 << 20
+)
+
+// response headers a replay carries besides Content-Type, e.g. the Location and ETag of a created resource
+var replayedHeaders = []string{"Location", "ETag", "Last-Modified"}
+
+var errRequestTooLarge = entity.NewError(entity.ErrPayloadTooLarge, "request_too_large", "request body must be at most %d KB", idempotencyMaxBodySize>>10).With("Count", idempotencyMaxBodySize>>10)
+
+// idempotencyRecord is what is stored under an Idempotency-Key.
+type idempotencyRecord struct {
+	Fingerprint string            `json:"fingerprint"`
+	Completed   bool              `json:"completed"`
+	Status      int               `json:"status,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+}
+
+// NOTE - records POST responses under the Idempotency-Key header of their caller and replays them for retries of the
+// same request. It has to run after Authenticate, which names the caller, and RateLimit, and before ErrorHandler so
+// error responses are recorded too.
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+
+		if len(key) > 255 {
+			writeError(c, entity.BadRequest("invalid_idempotency_key", "Idempotency-Key must be at most 255 characters"))
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, idempotencyMaxBodySize))
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(c, errRequestTooLarge)
+			c.Abort()
+			return
+		}
+		if err != nil {
+			writeError(c, entity.BadRequest("invalid_body", "request body could not be read").Localized("invalid_body.unreadable"))
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		// keys are the caller's own, another caller sending the same key neither sees nor blocks its response
+		storeKey := cache.IDEMPOTENCY_KEY + ByIdentity(c) + ":" + c.Request.URL.Path + ":" + key
+		fingerprint := requestFingerprint(c, body)
+
+		pending, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint})
+		for {
+			acquired, err := cache.SetNX(ctx, storeKey, string(pending), idempotencyLockTTL)
+			if err != nil {
+				slog.WarnContext(ctx, "Idempotency store unavailable, processing request without it", "error", err)
+				c.Next()
+				return
+			}
+			if acquired {
+				break
+			}
+
+			// the key vanishes when the earlier attempt failed, then this one tries to take it over
+			if answered := awaitIdempotent(c, storeKey, fingerprint); answered {
+				return
+			}
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		// the outcome is stored even when the client already went away
+		clientGone := ctx.Err() != nil
+		ctx = context.WithoutCancel(ctx)
+
+		// abandoned requests and transient failures are worth retrying, so they are not kept and the key is released for the next attempt
+		if clientGone || !recorder.Written() || isRetryable(recorder.Status()) {
+			if err := cache.Del(ctx, storeKey); err != nil {
+				slog.WarnContext(ctx, "Failed to release idempotency key", "error", err)
+			}
+			return
+		}
+
+		completed, _ := json.Marshal(idempotencyRecord{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			Status:      recorder.Status(),
+			ContentType: recorder.Header().Get("Content-Type"),
+			Headers:     replayableHeaders(recorder.Header()),
+			Body:        recorder.body.Bytes(),
+		})
+		if err := cache.SetWithTTL(ctx, storeKey, string(completed), idempotencyTTL); err != nil {
+			slog.WarnContext(ctx, "Failed to store idempotent response", "error", err)
+		}
+	}
+}
+
+// NOTE - reports whether a response is a transient failure the same request may succeed after: a server error,
+// a timeout, a conflict with a concurrent write or a rate limit
+func isRetryable(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests, statusClientClosedRequest:
+		return true
+	}
+	return status >= http.StatusInternalServerError
+}
+
+// NOTE - answers a request whose key is already taken, waiting while the first attempt is still running.
+// Reports false when the key was released without a stored response.
+func awaitIdempotent(c *gin.Context, storeKey, fingerprint string) bool {
+	ctx := c.Request.Context()
+	deadline := time.Now().Add(idempotencyLockTTL)
+
+	for {
+		raw, err := cache.Get(ctx, storeKey)
+		if err != nil {
+			writeError(c, err)
+			c.Abort()
+			return true
+		}
+		if raw == "" {
+			return false
+		}
+
+		var record idempotencyRecord
+		if json.Unmarshal([]byte(raw), &record) == nil {
+			if record.Fingerprint != fingerprint {
+				writeError(c, entity.Validation("idempotency_key_reused", "Idempotency-Key was already used with a different request"))
+				c.Abort()
+				return true
+			}
+
+			if record.Completed {
+				for name, value := range record.Headers {
+					c.Header(name, value)
+				}
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(record.Status, record.ContentType, record.Body)
+				c.Abort()
+				return true
+			}
+		}
+
+		if time.Now().After(deadline) {
+			writeError(c, entity.Conflict("idempotency_in_progress", "a request with this Idempotency-Key is still being processed"))
+			c.Abort()
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			c.Abort()
+			return true
+		case <-time.After(idempotencyPollInterval):
+		}
+	}
+}
+
+// NOTE - the replayed headers the original response set
+func replayableHeaders(header http.Header) map[string]string {
+	headers := map[string]string{}
+	for _, name := range replayedHeaders {
+		if value := header.Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+	return headers
+}
+
+// NOTE - hash of what makes two requests the same request
+func requestFingerprint(c *gin.Context, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the response body while it is written to the client.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
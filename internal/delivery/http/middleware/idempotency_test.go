@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sample-project/internal/config/cache"
+	"sample-project/internal/entity"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func idempotentRouter(calls *int) *gin.Engine {
+	router := gin.New()
+	router.Use(Idempotency(), ErrorHandler())
+	router.POST("/api/v2/users", func(c *gin.Context) {
+		*calls++
+		c.Header("Location", "/api/v2/users/42")
+		c.Header("ETag", `"1"`)
+		c.JSON(http.StatusCreated, gin.H{"id": 42})
+	})
+	return router
+}
+
+func postWithKey(router http.Handler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/users", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysStatusBodyAndHeaders(t *testing.T) {
+	useTestRedis(t)
+	calls := 0
+	router := idempotentRouter(&calls)
+
+	first := postWithKey(router, `{"name":"a"}`)
+	replay := postWithKey(router, `{"name":"a"}`)
+
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want once", calls)
+	}
+	if replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("second response is not marked as replayed")
+	}
+	if replay.Code != first.Code || replay.Body.String() != first.Body.String() {
+		t.Errorf("replayed %d %s, want %d %s", replay.Code, replay.Body, first.Code, first.Body)
+	}
+	for _, header := range []string{"Location", "ETag", "Content-Type"} {
+		if got, want := replay.Header().Get(header), first.Header().Get(header); got != want {
+			t.Errorf("replayed %s is %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestIdempotencyRefusesOversizedBody(t *testing.T) {
+	useTestRedis(t)
+	calls := 0
+	router := idempotentRouter(&calls)
+
+	w := postWithKey(router, `{"name":"`+strings.Repeat("a", idempotencyMaxBodySize)+`"}`)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status is %d, want 413", w.Code)
+	}
+	var body entity.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != "request_too_large" {
+		t.Errorf("body is %s", w.Body)
+	}
+	if calls != 0 {
+		t.Error("handler ran for a body over the limit")
+	}
+}
+
+func TestIdempotencyReleasesTheKeyOfATransientFailure(t *testing.T) {
+	for _, status := range []int{http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		useTestRedis(t)
+		calls := 0
+		router := gin.New()
+		router.Use(Idempotency(), ErrorHandler())
+		router.POST("/api/v2/users", func(c *gin.Context) {
+			calls++
+			if calls == 1 {
+				c.JSON(status, gin.H{"code": "try_again"})
+				return
+			}
+			c.JSON(http.StatusCreated, gin.H{"id": 42})
+		})
+
+		postWithKey(router, `{"name":"a"}`)
+		retry := postWithKey(router, `{"name":"a"}`)
+
+		if calls != 2 || retry.Code != http.StatusCreated || retry.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("%d: retry got %d after %d calls, want the request processed again", status, retry.Code, calls)
+		}
+	}
+}
+
+func TestIdempotencyKeepsKeysPerCaller(t *testing.T) {
+	useTestRedis(t)
+	calls := 0
+	router := idempotentRouter(&calls)
+
+	send := func(apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/users", strings.NewReader(`{"name":"a"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		req.Header.Set(APIKeyHeader, apiKey)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	send("key-a")
+	other := send("key-b")
+	replay := send("key-a")
+
+	if calls != 2 || other.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("handler ran %d times, another caller's key was replayed to the second one", calls)
+	}
+	if replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("the retry of the first caller was not replayed")
+	}
+}
+
+func TestIdempotencyRetriesAfterTheRateLimit(t *testing.T) {
+	useTestRedis(t)
+	const window = 100 * time.Millisecond
+	calls := 0
+	router := gin.New()
+	// in the order of cmd/main.go, the limiter answers before a key is taken
+	router.Use(RateLimit(cache.NewMemoryRateLimiter(), RateLimitPolicy{Name: "write", Prefix: "/api/", Limit: 1, Window: window, Key: ByIdentity}), Idempotency(), ErrorHandler())
+	router.POST("/api/v2/users", func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"id": calls})
+	})
+	post := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/users", strings.NewReader(`{"name":"`+key+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(IdempotencyKeyHeader, key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	post("key-1")
+	if limited := post("key-2"); limited.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request got %d, want 429", limited.Code)
+	}
+
+	time.Sleep(window)
+	retry := post("key-2")
+	if retry.Code != http.StatusCreated || retry.Header().Get("Idempotent-Replayed") != "" || calls != 2 {
+		t.Errorf("retry got %d after %d calls, want it processed", retry.Code, calls)
+	}
+}
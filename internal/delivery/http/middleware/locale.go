@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"sample-project/internal/config/locale"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NOTE - picks the language of the request from Accept-Language and stores it in the request context,
+// error messages are localized to it. Requests that ask for no supported language get English
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tag := locale.Negotiate(c.GetHeader("Accept-Language"))
+		c.Request = c.Request.WithContext(locale.WithLanguage(c.Request.Context(), tag))
+
+		c.Next()
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"os"
+	"sample-project/internal/config/cache"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// NOTE - points the cache at an in-memory redis for the test
+func useTestRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	t.Setenv("REDIS_URL", "redis://"+server.Addr())
+	cache.ConnectRedis()
+	t.Cleanup(func() { cache.CloseRedis() })
+	return server
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"sample-project/internal/entity"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+)
+
+// What OpenAPIValidation does with requests and responses that do not match the spec.
+const (
+	// OpenAPIOff skips the validation
+	OpenAPIOff = "off"
+	// OpenAPIReport logs every mismatch and counts it, the request is served as usual
+	OpenAPIReport = "report"
+	// OpenAPIEnforce reports mismatches as well and rejects requests the spec does not allow
+	OpenAPIEnforce = "enforce"
+)
+
+// mismatches between the API and its spec by "request|response METHOD /route", published on /debug/vars
+var openAPIViolations = expvar.NewMap("openapi_violations")
+
+// NOTE - validates requests and responses of the documented operations against the swagger 2.0 spec, for dev and test.
+// Only JSON bodies are checked, routes the spec does not know are left alone
+func OpenAPIValidation(spec []byte, mode string) (gin.HandlerFunc, error) {
+	if mode == "" || mode == OpenAPIOff {
+		return func(c *gin.Context) { c.Next() }, nil
+	}
+	if mode != OpenAPIReport && mode != OpenAPIEnforce {
+		return nil, fmt.Errorf("unknown OpenAPI validation mode %q, use %s, %s or %s", mode, OpenAPIOff, OpenAPIReport, OpenAPIEnforce)
+	}
+
+	router, err := openAPIRouter(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *gin.Context) {
+		route, pathParams, err := router.FindRoute(c.Request)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		requestInput := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				MultiError:         true,
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+				ExcludeRequestBody: !isJSON(c.GetHeader("Content-Type")),
+				// the handlers fill in their own defaults
+				SkipSettingDefaults: true,
+			},
+		}
+		if err := openapi3filter.ValidateRequest(ctx, requestInput); err != nil {
+			reportViolation(c, "request", route, 0, err)
+			if mode == OpenAPIEnforce {
+				writeError(c, entity.BadRequest("openapi_request_invalid", "request does not match the API spec: %s", summarize(err)).With("Detail", summarize(err)))
+				c.Abort()
+				return
+			}
+		}
+
+		// streams last as long as the client stays, their responses are not checked
+		if strings.HasPrefix(c.GetHeader("Accept"), "text/event-stream") || c.IsWebsocket() {
+			c.Next()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		responseInput := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: requestInput,
+			Status:                 recorder.Status(),
+			Header:                 recorder.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(recorder.body.Bytes())),
+			Options: &openapi3filter.Options{
+				MultiError:            true,
+				IncludeResponseStatus: true,
+				ExcludeResponseBody:   !isJSON(recorder.Header().Get("Content-Type")) || recorder.body.Len() == 0,
+			},
+		}
+		if err := openapi3filter.ValidateResponse(ctx, responseInput); err != nil {
+			reportViolation(c, "response", route, recorder.Status(), err)
+		}
+	}, nil
+}
+
+// NOTE - router over the operations of a swagger 2.0 document, converted to OpenAPI 3
+func openAPIRouter(spec []byte) (routers.Router, error) {
+	var doc2 openapi2.T
+	if err := json.Unmarshal(spec, &doc2); err != nil {
+		return nil, fmt.Errorf("parse OpenAPI spec: %w", err)
+	}
+
+	doc, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		return nil, fmt.Errorf("convert OpenAPI spec: %w", err)
+	}
+	// swag leaves the host empty, operations are matched on any host
+	doc.Servers = nil
+
+	return gorillamux.NewRouter(doc)
+}
+
+// NOTE - logs and counts a mismatch between the API and its spec
+func reportViolation(c *gin.Context, direction string, route *routers.Route, status int, err error) {
+	openAPIViolations.Add(fmt.Sprintf("%s|%s %s", direction, route.Method, route.Path), 1)
+
+	attrs := []any{"direction", direction, "method", route.Method, "route", route.Path, "error", summarize(err)}
+	if status != 0 {
+		attrs = append(attrs, "status", status)
+	}
+	slog.WarnContext(c.Request.Context(), "API does not match its OpenAPI spec", attrs...)
+}
+
+// NOTE - reports whether the media type is JSON, `application/json` or a `+json` suffix
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// NOTE - first line of every validation error, the rest of a line repeats the schema that failed
+func summarize(err error) string {
+	var multi openapi3.MultiError
+	if !errors.As(err, &multi) {
+		multi = openapi3.MultiError{err}
+	}
+
+	lines := make([]string, 0, len(multi))
+	for _, err := range multi {
+		line, _, _ := strings.Cut(err.Error(), "\n")
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "; ")
+}
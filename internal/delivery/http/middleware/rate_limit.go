@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"sample-project/internal/config/cache"
+	"sample-project/internal/entity"
+	"sample-project/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// KeyFunc returns the identity a request is counted against, empty when it does not apply.
+type KeyFunc func(c *gin.Context) string
+
+// RateLimitPolicy limits the requests of one identity within a sliding window.
+// A policy applies to routes under Prefix, and only to Methods when that is set.
+type RateLimitPolicy struct {
+	Name    string
+	Prefix  string
+	Methods []string
+	Limit   int
+	Window  time.Duration
+	Key     KeyFunc
+}
+
+// NOTE - applies the first policy matching the route, RATE_LIMIT_<NAME>=limit/window overrides a policy limit.
+// It answers 429 itself and has to run before Idempotency, so a refused attempt is never stored under its key
+func RateLimit(limiter cache.RateLimiter, policies ...RateLimitPolicy) gin.HandlerFunc {
+	for i, policy := range policies {
+		env := "RATE_LIMIT_" + strings.ToUpper(strings.ReplaceAll(policy.Name, "-", "_"))
+		if limit, window, ok := cache.ParseRateLimit(os.Getenv(env)); ok {
+			policies[i].Limit, policies[i].Window = limit, window
+		}
+	}
+
+	return func(c *gin.Context) {
+		policy, ok := matchPolicy(c, policies)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		identity := policy.Key(c)
+		if identity == "" {
+			identity = ByIP(c)
+		}
+		key := policy.Name + ":" + identity
+
+		result, err := limiter.Allow(c.Request.Context(), key, policy.Limit, policy.Window)
+		if err != nil {
+			// an unavailable store must not take the API down with it
+			slog.WarnContext(c.Request.Context(), "Rate limiter unavailable, allowing request", "policy", policy.Name, "error", err)
+			c.Next()
+			return
+		}
+
+		reset := int(math.Ceil(result.Reset.Seconds()))
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(reset))
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Window.Seconds())))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(reset))
+			writeError(c, entity.NewError(entity.ErrTooManyRequests, "rate_limited", "too many requests, retry in %d seconds", reset).With("Count", reset))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// NOTE - first policy whose prefix and methods match the request
+func matchPolicy(c *gin.Context, policies []RateLimitPolicy) (RateLimitPolicy, bool) {
+	path := c.FullPath()
+	if path == "" {
+		path = c.Request.URL.Path
+	}
+
+	for _, policy := range policies {
+		if !strings.HasPrefix(path, policy.Prefix) {
+			continue
+		}
+		if len(policy.Methods) > 0 && !containsMethod(policy.Methods, c.Request.Method) {
+			continue
+		}
+		return policy, true
+	}
+	return RateLimitPolicy{}, false
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// NOTE - counts per client IP
+func ByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// NOTE - counts per user Authenticate found on the request, empty for anonymous requests
+func ByUserID(c *gin.Context) string {
+	claims := utils.ClaimsFrom(c.Request.Context())
+	if claims == nil {
+		return ""
+	}
+	return "user:" + strconv.Itoa(claims.UserID)
+}
+
+// NOTE - counts per API key, the key is hashed so it never lands in redis
+func ByAPIKey(c *gin.Context) string {
+	apiKey := c.GetHeader(APIKeyHeader)
+	if apiKey == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(apiKey))
+	return "key:" + hex.EncodeToString(sum[:8])
+}
+
+// NOTE - most specific identity available: API key, then user, then IP
+func ByIdentity(c *gin.Context) string {
+	for _, key := range []KeyFunc{ByAPIKey, ByUserID} {
+		if identity := key(c); identity != "" {
+			return identity
+		}
+	}
+	return ByIP(c)
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sample-project/internal/config/cache"
+	"sample-project/internal/entity"
+	"sample-project/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NOTE - router limiting like cmd/main.go with small limits, every route answers 204
+func rateLimitedRouter() *gin.Engine {
+	router := gin.New()
+	router.Use(Authenticate("/api/"), ErrorHandler(), RateLimit(cache.NewMemoryRateLimiter(),
+		RateLimitPolicy{Name: "login", Prefix: "/api/v2/auth/login", Limit: 1, Window: time.Minute, Key: ByIP},
+		RateLimitPolicy{Name: "write", Prefix: "/api/", Methods: []string{"POST", "PUT", "PATCH", "DELETE"}, Limit: 1, Window: time.Minute, Key: ByIdentity},
+		RateLimitPolicy{Name: "read", Prefix: "/api/", Limit: 2, Window: time.Minute, Key: ByIdentity},
+	))
+	router.NoRoute(func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	return router
+}
+
+func send(router http.Handler, method, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	router := rateLimitedRouter()
+
+	for i, remaining := range []string{"1", "0"} {
+		w := send(router, http.MethodGet, "/api/v2/users", nil)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("request %d: status is %d", i+1, w.Code)
+		}
+		if w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Remaining") != remaining || w.Header().Get("RateLimit-Reset") != "60" || w.Header().Get("RateLimit-Policy") != "2;w=60" {
+			t.Errorf("request %d: headers are %v", i+1, w.Header())
+		}
+	}
+
+	w := send(router, http.MethodGet, "/api/v2/users", nil)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("third request: %d with headers %v", w.Code, w.Header())
+	}
+	var body entity.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != "rate_limited" {
+		t.Errorf("third request: body is %s", w.Body)
+	}
+}
+
+func TestRateLimitAppliesTheFirstMatchingPolicy(t *testing.T) {
+	router := rateLimitedRouter()
+
+	// the login policy comes before the write one, both match a login
+	if w := send(router, http.MethodPost, "/api/v2/auth/login", nil); w.Header().Get("RateLimit-Policy") != "1;w=60" || w.Code != http.StatusNoContent {
+		t.Errorf("login: %d with policy %q", w.Code, w.Header().Get("RateLimit-Policy"))
+	}
+	// writes and reads count apart, the exhausted write policy leaves reads alone
+	if w := send(router, http.MethodPost, "/api/v2/users", nil); w.Code != http.StatusNoContent {
+		t.Errorf("first write: status is %d", w.Code)
+	}
+	if w := send(router, http.MethodDelete, "/api/v2/users/1", nil); w.Code != http.StatusTooManyRequests {
+		t.Errorf("second write: status is %d, want 429", w.Code)
+	}
+	if w := send(router, http.MethodGet, "/api/v2/users", nil); w.Code != http.StatusNoContent || w.Header().Get("RateLimit-Policy") != "2;w=60" {
+		t.Errorf("read after the writes: %d with policy %q", w.Code, w.Header().Get("RateLimit-Policy"))
+	}
+	// routes no policy matches are not limited
+	if w := send(router, http.MethodGet, "/healthz", nil); w.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("healthz is limited: %v", w.Header())
+	}
+}
+
+func TestRateLimitCountsEveryCallerApart(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	tokenOf := func(userID int) string {
+		token, _, err := utils.GenerateToken(userID, 1, "Noy")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	router := rateLimitedRouter()
+
+	callers := []http.Header{
+		{"Authorization": {"Bearer " + tokenOf(1)}},
+		{"Authorization": {"Bearer " + tokenOf(2)}},
+		{APIKeyHeader: {"key-a"}},
+		// anonymous, counted by IP
+		nil,
+	}
+	for i, header := range callers {
+		if w := send(router, http.MethodPost, "/api/v2/users", header); w.Code != http.StatusNoContent {
+			t.Errorf("caller %d: status is %d, the limit of another caller was used", i, w.Code)
+		}
+	}
+	if w := send(router, http.MethodPost, "/api/v2/users", callers[0]); w.Code != http.StatusTooManyRequests {
+		t.Errorf("second write of caller 0: status is %d, want 429", w.Code)
+	}
+}
+
+func TestRateLimitEnvironmentOverridesAPolicy(t *testing.T) {
+	t.Setenv("RATE_LIMIT_READ", "1/30s")
+	router := rateLimitedRouter()
+
+	if w := send(router, http.MethodGet, "/api/v2/users", nil); w.Header().Get("RateLimit-Policy") != "1;w=30" {
+		t.Errorf("policy is %q, want the RATE_LIMIT_READ override", w.Header().Get("RateLimit-Policy"))
+	}
+	if w := send(router, http.MethodGet, "/api/v2/users", nil); w.Code != http.StatusTooManyRequests {
+		t.Errorf("second read: status is %d, want 429", w.Code)
+	}
+}
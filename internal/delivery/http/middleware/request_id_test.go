@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sample-project/internal/config/logger"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestIDIsAcceptedOrGenerated(t *testing.T) {
+	var seen string
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/", func(c *gin.Context) { seen = logger.RequestID(c.Request.Context()) })
+
+	tests := []struct {
+		name string
+		sent string
+		kept bool
+	}{
+		{name: "caller id", sent: "abc-123.x_y", kept: true},
+		{name: "none"},
+		{name: "too long", sent: strings.Repeat("a", 129)},
+		{name: "breaks the log line", sent: "abc\" injected"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.sent != "" {
+			req.Header.Add(RequestIDHeader, tt.sent)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		echoed := w.Header().Get(RequestIDHeader)
+		if echoed == "" || echoed != seen {
+			t.Errorf("%s: echoed %q, the context has %q", tt.name, echoed, seen)
+		}
+		if (echoed == tt.sent) != tt.kept {
+			t.Errorf("%s: sent %q, got %q", tt.name, tt.sent, echoed)
+		}
+	}
+}
+
+func TestAccessLogWritesOneLinePerRequest(t *testing.T) {
+	var out bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&out, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	router := gin.New()
+	router.Use(AccessLog())
+	router.GET("/api/v2/users/:id", func(c *gin.Context) { c.String(http.StatusOK, "hello") })
+	router.GET("/fail", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	for _, target := range []string{"/api/v2/users/42", "/fail", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	want := []struct {
+		level  string
+		route  string
+		path   string
+		status float64
+		bytes  float64
+	}{
+		{level: "INFO", route: "/api/v2/users/:id", path: "/api/v2/users/42", status: 200, bytes: 5},
+		{level: "ERROR", route: "/fail", path: "/fail", status: 500},
+		{level: "WARN", route: "unmatched", path: "/missing", status: 404},
+	}
+
+	decoder := json.NewDecoder(&out)
+	for _, w := range want {
+		var line map[string]interface{}
+		if err := decoder.Decode(&line); err != nil {
+			t.Fatalf("%s: %v", w.path, err)
+		}
+		if line["level"] != w.level || line["route"] != w.route || line["path"] != w.path || line["status"] != w.status || line["bytes"] != w.bytes {
+			t.Errorf("logged %v, want %+v", line, w)
+		}
+		if _, ok := line["latency_ms"].(float64); !ok {
+			t.Errorf("%s: no latency in %v", w.path, line)
+		}
+	}
+	if decoder.More() {
+		t.Errorf("more than one line per request")
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityPolicy is the set of security headers sent for the routes under Prefix.
+// Empty values are not sent.
+type SecurityPolicy struct {
+	Prefix                string
+	HSTS                  string
+	ContentSecurityPolicy string
+	FrameOptions          string
+	ReferrerPolicy        string
+}
+
+// NOTE - sets the security headers of the first policy matching the path, X-Content-Type-Options is always sent
+func SecurityHeaders(policies ...SecurityPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("X-Content-Type-Options", "nosniff")
+
+		for _, policy := range policies {
+			if !strings.HasPrefix(c.Request.URL.Path, policy.Prefix) {
+				continue
+			}
+
+			setHeader(c, "Strict-Transport-Security", policy.HSTS)
+			setHeader(c, "Content-Security-Policy", policy.ContentSecurityPolicy)
+			setHeader(c, "X-Frame-Options", policy.FrameOptions)
+			setHeader(c, "Referrer-Policy", policy.ReferrerPolicy)
+			break
+		}
+
+		c.Next()
+	}
+}
+
+func setHeader(c *gin.Context, name, value string) {
+	if value != "" {
+		c.Header(name, value)
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSecurityHeadersApplyTheFirstMatchingProfile(t *testing.T) {
+	router := gin.New()
+	router.Use(SecurityHeaders(
+		SecurityPolicy{Prefix: "/swagger/", ContentSecurityPolicy: "default-src 'self'", FrameOptions: "SAMEORIGIN"},
+		SecurityPolicy{Prefix: "/", HSTS: "max-age=31536000; includeSubDomains", ContentSecurityPolicy: "default-src 'none'", FrameOptions: "DENY", ReferrerPolicy: "no-referrer"},
+	))
+	router.GET("/*path", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		path   string
+		header map[string]string
+	}{
+		{path: "/swagger/index.html", header: map[string]string{
+			"X-Content-Type-Options":    "nosniff",
+			"Content-Security-Policy":   "default-src 'self'",
+			"X-Frame-Options":           "SAMEORIGIN",
+			"Strict-Transport-Security": "",
+			"Referrer-Policy":           "",
+		}},
+		{path: "/api/v2/users", header: map[string]string{
+			"X-Content-Type-Options":    "nosniff",
+			"Content-Security-Policy":   "default-src 'none'",
+			"X-Frame-Options":           "DENY",
+			"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+			"Referrer-Policy":           "no-referrer",
+		}},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		for name, want := range tt.header {
+			if got := w.Header().Get(name); got != want {
+				t.Errorf("%s: %s is %q, want %q", tt.path, name, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"sample-project/internal/entity"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantResolver names the tenant of the credentials of a request, 0 when none does.
+// usecase.TenantUseCase.ResolveTenant is the one the API uses.
+type TenantResolver func(ctx context.Context, credentials entity.TenantCredentials) (int, error)
+
+// NOTE - scopes the requests under prefixes to the tenant of their access token, API key or host. The token is
+// the one Authenticate read, it has to run first.
+// A request that names none goes on without one and every query it makes fails with tenant_required,
+// so it has to run before anything that reads or caches tenant data
+func Tenant(resolve TenantResolver, prefixes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !hasAnyPrefix(c.Request.URL.Path, prefixes) {
+			c.Next()
+			return
+		}
+
+		tenantID, err := resolve(c.Request.Context(), entity.TenantCredentials{
+			APIKey: c.GetHeader(APIKeyHeader),
+			Host:   c.Request.Host,
+		})
+		if err != nil {
+			writeError(c, err)
+			c.Abort()
+			return
+		}
+
+		if tenantID != 0 {
+			c.Request = c.Request.WithContext(entity.WithTenant(c.Request.Context(), tenantID))
+		}
+
+		c.Next()
+	}
+}
+
+func hasAnyPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
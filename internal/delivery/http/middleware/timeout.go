@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cause of the cancelled deadline of a request that finished, tells it apart from a client that went away
+var errRequestFinished = errors.New("request finished")
+
+// NOTE - gives every request a deadline, prisma and redis calls made with the request context stop when it passes.
+// Routes under one of the streaming prefixes stay open as long as the client does.
+func Timeout(timeout time.Duration, streaming ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, prefix := range streaming {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		// ErrorHandler writes the response after this returns, when the deadline is already cancelled.
+		// finish runs first and cancels it with errRequestFinished as the cause
+		finished, finish := context.WithCancelCause(c.Request.Context())
+		ctx, cancel := context.WithTimeout(finished, timeout)
+		defer cancel()
+		defer finish(errRequestFinished)
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sample-project/internal/entity"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// router in the order cmd/main.go registers them, ErrorHandler writes after Timeout returned
+func timeoutRouter(timeout time.Duration, handler gin.HandlerFunc) *gin.Engine {
+	router := gin.New()
+	router.Use(ErrorHandler(), Timeout(timeout))
+	router.GET("/api/v2/users/:id", handler)
+	return router
+}
+
+func TestTimeoutKeepsHandlerErrors(t *testing.T) {
+	router := timeoutRouter(time.Second, func(c *gin.Context) {
+		c.Error(entity.NotFound("user_not_found", "user with ID: %d not found", 5).With("ID", 5))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/users/5", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status is %d, want 404", w.Code)
+	}
+	var body entity.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body is not the JSON error envelope: %q", w.Body)
+	}
+	if body.Code != "user_not_found" || body.Message != "user with ID: 5 not found" {
+		t.Errorf("body is %+v", body)
+	}
+}
+
+func TestTimeoutAnswersExpiredDeadline(t *testing.T) {
+	router := timeoutRouter(10*time.Millisecond, func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.Error(c.Request.Context().Err())
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/users/5", nil))
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status is %d, want 504", w.Code)
+	}
+}
+
+func TestTimeoutLeavesClientThatWentAwayWithoutBody(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	router := timeoutRouter(time.Second, func(c *gin.Context) {
+		cancel()
+		c.Error(c.Request.Context().Err())
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/users/5", nil).WithContext(ctx))
+
+	if w.Code != statusClientClosedRequest || w.Body.Len() != 0 {
+		t.Fatalf("got %d %q, want 499 without body", w.Code, w.Body)
+	}
+}
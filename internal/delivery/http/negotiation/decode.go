@@ -0,0 +1,252 @@
+package negotiation
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"reflect"
+	"sample-project/internal/entity"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
+)
+
+var (
+	jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	errEmptyBody    = entity.BadRequest("invalid_body", "request body is empty").Localized("invalid_body.empty")
+)
+
+// textNode is an untyped body tree, XML elements and CSV columns are read into it
+// and only get their JSON types once they are matched against the target struct.
+type textNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Text     string     `xml:",chardata"`
+	Children []textNode `xml:",any"`
+}
+
+// NOTE - returns the request body as JSON according to its Content-Type, so every format
+// is bound and validated the same way. obj tells how untyped XML and CSV text is read.
+func DecodeBody(c *gin.Context, obj interface{}) (io.Reader, error) {
+	mediaType := gin.MIMEJSON
+	if header := c.GetHeader("Content-Type"); header != "" {
+		parsed, _, err := mime.ParseMediaType(header)
+		if err != nil {
+			return nil, unsupportedMediaType()
+		}
+		mediaType = parsed
+	}
+
+	format, ok := formatOf(mediaType)
+	if !ok {
+		return nil, unsupportedMediaType()
+	}
+	if format == JSON {
+		return c.Request.Body, nil
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, entity.BadRequest("invalid_body", "request body could not be read").Localized("invalid_body.unreadable")
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, errEmptyBody
+	}
+
+	var value interface{}
+	switch format {
+	case MsgPack:
+		value, err = decodeMsgPack(body)
+	case XML:
+		value, err = decodeXML(body, reflect.TypeOf(obj))
+	case CSV:
+		value, err = decodeCSV(body, reflect.TypeOf(obj))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, entity.BadRequest("invalid_body", "request body could not be read").Localized("invalid_body.unreadable")
+	}
+	return bytes.NewReader(data), nil
+}
+
+func unsupportedMediaType() error {
+	types := strings.Join(readableTypes(), ", ")
+	return entity.NewError(entity.ErrUnsupportedMediaType, "unsupported_media_type", "Content-Type must be one of %s", types).With("Types", types)
+}
+
+// NOTE - media types request bodies can be sent as
+func readableTypes() []string {
+	var types []string
+	for _, format := range formats {
+		types = append(types, mediaTypes[format]...)
+	}
+	return types
+}
+
+func decodeMsgPack(body []byte) (interface{}, error) {
+	handle := &codec.MsgpackHandle{}
+	handle.RawToString = true
+	handle.MapType = reflect.TypeOf(map[string]interface{}(nil))
+
+	var value interface{}
+	if err := codec.NewDecoderBytes(body, handle).Decode(&value); err != nil {
+		return nil, entity.BadRequest("invalid_body", "request body is not valid MessagePack").Localized("invalid_body.msgpack")
+	}
+	return value, nil
+}
+
+func decodeXML(body []byte, target reflect.Type) (interface{}, error) {
+	var root textNode
+	if err := xml.Unmarshal(body, &root); err != nil {
+		return nil, entity.BadRequest("invalid_body", "request body is not valid XML").Localized("invalid_body.xml")
+	}
+	return root.value(target), nil
+}
+
+// NOTE - reads a header row and a single record, dotted columns are nested objects and empty cells are left out
+func decodeCSV(body []byte, target reflect.Type) (interface{}, error) {
+	records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+	if err != nil || len(records) != 2 {
+		return nil, entity.BadRequest("invalid_body", "request body must be a CSV header row and exactly one record").Localized("invalid_body.csv")
+	}
+
+	var root textNode
+	for i, column := range records[0] {
+		if records[1][i] == "" {
+			continue
+		}
+
+		node := &root
+		for _, name := range strings.Split(column, ".") {
+			node = node.child(name)
+		}
+		node.Text = records[1][i]
+	}
+	return root.value(target), nil
+}
+
+// NOTE - child element with the given name, created when missing
+func (n *textNode) child(name string) *textNode {
+	for i := range n.Children {
+		if n.Children[i].XMLName.Local == name {
+			return &n.Children[i]
+		}
+	}
+	n.Children = append(n.Children, textNode{XMLName: xml.Name{Local: name}})
+	return &n.Children[len(n.Children)-1]
+}
+
+// NOTE - the JSON value of a node, typed after target since XML and CSV text carries no types
+func (n textNode) value(target reflect.Type) interface{} {
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == "nil" && attr.Value == "true" {
+			return nil
+		}
+	}
+
+	t := valueType(target)
+	switch {
+	case t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		// a CSV cell holds the whole list as JSON
+		if len(n.Children) == 0 && json.Valid([]byte(n.Text)) {
+			return json.RawMessage(n.Text)
+		}
+		list := []interface{}{}
+		for _, child := range n.Children {
+			list = append(list, child.value(t.Elem()))
+		}
+		return list
+	case len(n.Children) > 0 || (t != nil && (t.Kind() == reflect.Struct || t.Kind() == reflect.Map)):
+		obj := map[string]interface{}{}
+		for _, child := range n.Children {
+			obj[child.XMLName.Local] = child.value(fieldType(t, child.XMLName.Local))
+		}
+		return obj
+	}
+
+	return scalar(n.Text, t)
+}
+
+// NOTE - type a node is read as, pointers and Nullable are transparent
+func valueType(t reflect.Type) reflect.Type {
+	for t != nil {
+		switch {
+		case t.Kind() == reflect.Pointer:
+			t = t.Elem()
+		case isNullable(t):
+			field, _ := t.FieldByName("Value")
+			t = field.Type
+		case reflect.PointerTo(t).Implements(jsonUnmarshaler):
+			// decodes itself from JSON, like time.Time, so it is read as text
+			return reflect.TypeOf("")
+		default:
+			return t
+		}
+	}
+	return nil
+}
+
+func isNullable(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || !reflect.PointerTo(t).Implements(jsonUnmarshaler) {
+		return false
+	}
+	_, present := t.FieldByName("Present")
+	_, value := t.FieldByName("Value")
+	return present && value
+}
+
+// NOTE - type of the member called name, nil when t does not declare it
+func fieldType(t reflect.Type, name string) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	if t.Kind() == reflect.Map {
+		return t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if tag == "-" {
+			continue
+		}
+		if tag == name || (tag == "" && strings.EqualFold(field.Name, name)) {
+			return field.Type
+		}
+	}
+	return nil
+}
+
+// NOTE - text as a JSON scalar of kind t, left as a string when it does not parse so validation reports it
+func scalar(text string, t reflect.Type) interface{} {
+	if t == nil {
+		return text
+	}
+
+	trimmed := strings.TrimSpace(text)
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(trimmed); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(trimmed, 64); err == nil && json.Valid([]byte(trimmed)) {
+			return json.Number(trimmed)
+		}
+	}
+	return text
+}
@@ -0,0 +1,267 @@
+package negotiation
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+)
+
+// member is one key of a JSON object, objects are kept as ordered members so XML
+// elements and CSV columns come out in the same order as the JSON fields.
+type member struct {
+	Key   string
+	Value interface{}
+}
+
+type object []member
+
+// NOTE - writes body in the negotiated format. Every format is derived from the JSON
+// representation, so field names, omitted fields and time formats are the same everywhere.
+func Render(c *gin.Context, status int, body interface{}) {
+	format := FromContext(c)
+	if format == JSON {
+		c.JSON(status, body)
+		return
+	}
+
+	tree, err := toTree(body)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to encode response", "format", format, "error", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	switch format {
+	case MsgPack:
+		c.Render(status, render.MsgPack{Data: plain(tree)})
+	case XML:
+		c.Data(status, XML.ContentType()+"; charset=utf-8", encodeXML(tree))
+	case CSV:
+		data, err := encodeCSV(tree)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to encode response", "format", format, "error", err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		c.Data(status, CSV.ContentType()+"; charset=utf-8", data)
+	}
+}
+
+// NOTE - JSON representation of body as ordered objects, lists and scalars
+func toTree(body interface{}) (interface{}, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return readTree(decoder)
+}
+
+func readTree(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		obj := object{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := readTree(decoder)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{Key: key.(string), Value: value})
+		}
+		_, err := decoder.Token()
+		return obj, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for decoder.More() {
+			value, err := readTree(decoder)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := decoder.Token()
+		return list, err
+	}
+
+	// string, json.Number, bool or nil
+	return token, nil
+}
+
+// NOTE - tree with plain maps and native numbers, for encoders that do not need the field order
+func plain(tree interface{}) interface{} {
+	switch v := tree.(type) {
+	case object:
+		m := make(map[string]interface{}, len(v))
+		for _, member := range v {
+			m[member.Key] = plain(member.Value)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, value := range v {
+			list[i] = plain(value)
+		}
+		return list
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		n, _ := v.Float64()
+		return n
+	}
+	return tree
+}
+
+// NOTE - text of a scalar as it is written to XML and CSV
+func scalarText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+// NOTE - XML document rooted at <response>, list entries are <item> elements and null is nil="true"
+func encodeXML(tree interface{}) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	writeXML(&buf, "response", tree)
+	return buf.Bytes()
+}
+
+func writeXML(buf *bytes.Buffer, name string, value interface{}) {
+	name = xmlName(name)
+
+	switch v := value.(type) {
+	case nil:
+		fmt.Fprintf(buf, `<%s nil="true"/>`, name)
+	case object:
+		fmt.Fprintf(buf, "<%s>", name)
+		for _, member := range v {
+			writeXML(buf, member.Key, member.Value)
+		}
+		fmt.Fprintf(buf, "</%s>", name)
+	case []interface{}:
+		fmt.Fprintf(buf, "<%s>", name)
+		for _, item := range v {
+			writeXML(buf, "item", item)
+		}
+		fmt.Fprintf(buf, "</%s>", name)
+	default:
+		fmt.Fprintf(buf, "<%s>", name)
+		xml.EscapeText(buf, []byte(scalarText(v)))
+		fmt.Fprintf(buf, "</%s>", name)
+	}
+}
+
+// NOTE - replaces what is not allowed in an XML element name
+func xmlName(key string) string {
+	name := []rune(key)
+	for i, r := range name {
+		if !(r == '_' || r == '-' || r == '.' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')) {
+			name[i] = '_'
+		}
+	}
+	if len(name) == 0 || strings.ContainsRune("-.0123456789", name[0]) {
+		return "_" + string(name)
+	}
+	return string(name)
+}
+
+// NOTE - one record per list entry with a header row, nested objects become dotted columns
+// and nested lists a JSON cell. A page envelope is written as the rows of its data.
+func encodeCSV(tree interface{}) ([]byte, error) {
+	var columns []string
+	seen := map[string]bool{}
+
+	rows := csvRows(tree)
+	records := make([]map[string]string, len(rows))
+	for i, row := range rows {
+		records[i] = map[string]string{}
+		flatten("", row, records[i], func(column string) {
+			if !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(columns)
+	for _, record := range records {
+		line := make([]string, len(columns))
+		for i, column := range columns {
+			line[i] = record[column]
+		}
+		writer.Write(line)
+	}
+	writer.Flush()
+
+	return buf.Bytes(), writer.Error()
+}
+
+// NOTE - the rows of a representation: list entries, the data of a page envelope, or the single object
+func csvRows(tree interface{}) []interface{} {
+	switch v := tree.(type) {
+	case []interface{}:
+		return v
+	case object:
+		for _, member := range v {
+			if list, ok := member.Value.([]interface{}); ok && member.Key == "data" {
+				return list
+			}
+		}
+	}
+	return []interface{}{tree}
+}
+
+func flatten(prefix string, value interface{}, record map[string]string, addColumn func(string)) {
+	if obj, ok := value.(object); ok {
+		for _, member := range obj {
+			column := member.Key
+			if prefix != "" {
+				column = prefix + "." + member.Key
+			}
+			flatten(column, member.Value, record, addColumn)
+		}
+		return
+	}
+
+	column := prefix
+	if column == "" {
+		column = "value"
+	}
+	addColumn(column)
+
+	if list, ok := value.([]interface{}); ok {
+		data, _ := json.Marshal(plain(list))
+		record[column] = string(data)
+		return
+	}
+	record[column] = scalarText(value)
+}
@@ -0,0 +1,167 @@
+package negotiation
+
+import (
+	"mime"
+	"sample-project/internal/entity"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Format is a representation the API reads request bodies from and writes responses in.
+type Format string
+
+const (
+	JSON    Format = "json"
+	MsgPack Format = "msgpack"
+	XML     Format = "xml"
+	CSV     Format = "csv"
+)
+
+const formatKey = "negotiation.format"
+
+// server preference, used when the client accepts several formats equally
+var formats = []Format{JSON, MsgPack, XML, CSV}
+
+// media types of every format, responses are sent with the first one
+var mediaTypes = map[Format][]string{
+	JSON:    {"application/json"},
+	MsgPack: {"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"},
+	XML:     {"application/xml", "text/xml"},
+	CSV:     {"text/csv"},
+}
+
+var errNotAcceptable = entity.NewError(entity.ErrNotAcceptable, "not_acceptable", "supported formats are json, msgpack, xml and csv")
+
+// NOTE - media type a response in the format is sent with
+func (f Format) ContentType() string {
+	return mediaTypes[f][0]
+}
+
+// NOTE - picks the response format from ?format=, then from Accept, JSON when neither is given
+func Select(c *gin.Context) (Format, error) {
+	if query := c.Query("format"); query != "" {
+		format := Format(strings.ToLower(query))
+		if _, ok := mediaTypes[format]; !ok {
+			return JSON, errNotAcceptable
+		}
+		return format, nil
+	}
+
+	header := strings.TrimSpace(c.GetHeader("Accept"))
+	if header == "" {
+		return JSON, nil
+	}
+
+	for _, mediaRange := range acceptRanges(header) {
+		for _, format := range formats {
+			for _, mediaType := range mediaTypes[format] {
+				if rangeMatches(mediaRange, mediaType) {
+					return format, nil
+				}
+			}
+		}
+	}
+	return JSON, errNotAcceptable
+}
+
+// NOTE - reports whether Accept lists one of mediaTypes, for routes that write their own media type
+func Accepts(c *gin.Context, mediaTypes ...string) bool {
+	for _, mediaRange := range acceptRanges(c.GetHeader("Accept")) {
+		for _, mediaType := range mediaTypes {
+			if mediaRange == mediaType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NOTE - format the request was negotiated to, JSON when negotiation did not run
+func FromContext(c *gin.Context) Format {
+	if format, ok := c.Get(formatKey); ok {
+		return format.(Format)
+	}
+	return JSON
+}
+
+// NOTE - stores the negotiated format for Render
+func SetFormat(c *gin.Context, format Format) {
+	c.Set(formatKey, format)
+}
+
+// NOTE - format a request body of mediaType is read as
+func formatOf(mediaType string) (Format, bool) {
+	// merge-patch and other structured JSON syntaxes are plain JSON to the decoder
+	if strings.HasSuffix(mediaType, "+json") {
+		return JSON, true
+	}
+
+	for _, format := range formats {
+		for _, candidate := range mediaTypes[format] {
+			if candidate == mediaType {
+				return format, true
+			}
+		}
+	}
+	return "", false
+}
+
+// NOTE - reports whether request bodies of mediaType can be read
+func Readable(mediaType string) bool {
+	_, ok := formatOf(mediaType)
+	return ok
+}
+
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+// NOTE - media ranges of an Accept header by descending quality, ties keep the order of the header.
+// Ranges with q=0 are refused by the client and left out.
+func acceptRanges(header string) []string {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if raw, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+
+		ranges = append(ranges, acceptRange{mediaType: mediaType, quality: quality})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	mediaRanges := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		mediaRanges = append(mediaRanges, r.mediaType)
+	}
+	return mediaRanges
+}
+
+// NOTE - matches a media range such as */* or text/* against a concrete media type
+func rangeMatches(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+
+	if prefix, ok := strings.CutSuffix(mediaRange, "/*"); ok {
+		return strings.HasPrefix(mediaType, prefix+"/")
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package negotiation
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sample-project/internal/entity"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
+)
+
+type testSubject struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type testUser struct {
+	ID      int          `json:"id"`
+	Name    string       `json:"name"`
+	Status  bool         `json:"status"`
+	Subject *testSubject `json:"subject"`
+	Tags    []string     `json:"tags,omitempty"`
+}
+
+// testUserPatch reads members the way the merge patch documents do
+type testUserPatch struct {
+	ID      int                     `json:"id"`
+	Name    string                  `json:"name"`
+	Status  bool                    `json:"status"`
+	Subject *testSubject            `json:"subject"`
+	Nick    entity.Nullable[string] `json:"nick"`
+}
+
+// NOTE - gin context of a request to target with the given headers, and its recorder
+func testContext(method, target string, body io.Reader, header map[string]string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, target, body)
+	for name, value := range header {
+		c.Request.Header.Add(name, value)
+	}
+	return c, w
+}
+
+func TestSelectHonorsFormatThenAccept(t *testing.T) {
+	tests := []struct {
+		target string
+		accept string
+		want   Format
+		fails  bool
+	}{
+		{target: "/", want: JSON},
+		{target: "/", accept: "application/msgpack", want: MsgPack},
+		{target: "/", accept: "text/xml", want: XML},
+		{target: "/", accept: "text/csv;q=0.5, application/xml;q=0.9", want: XML},
+		{target: "/", accept: "application/json;q=0, text/*", want: XML},
+		{target: "/", accept: "application/json;q=0, text/csv", want: CSV},
+		{target: "/", accept: "*/*", want: JSON},
+		{target: "/", accept: "image/png", fails: true},
+		{target: "/?format=CSV", accept: "application/json", want: CSV},
+		{target: "/?format=yaml", fails: true},
+	}
+
+	for _, tt := range tests {
+		c, _ := testContext(http.MethodGet, tt.target, nil, map[string]string{"Accept": tt.accept})
+		got, err := Select(c)
+		if tt.fails {
+			if !errors.Is(err, entity.ErrNotAcceptable) {
+				t.Errorf("%s %q: got %v, want not acceptable", tt.target, tt.accept, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s %q: got %s, %v, want %s", tt.target, tt.accept, got, err, tt.want)
+		}
+	}
+}
+
+func TestRenderKeepsTheJSONRepresentation(t *testing.T) {
+	users := []testUser{
+		{ID: 1, Name: "Noy & Co", Status: true, Subject: &testSubject{ID: 2, Name: "Math"}, Tags: []string{"a", "b"}},
+		{ID: 3, Name: "Dan"},
+	}
+
+	tests := []struct {
+		format      Format
+		contentType string
+		want        string
+	}{
+		{
+			format:      XML,
+			contentType: "application/xml; charset=utf-8",
+			want: `<response><item><id>1</id><name>Noy &amp; Co</name><status>true</status><subject><id>2</id><name>Math</name></subject><tags><item>a</item><item>b</item></tags></item>` +
+				`<item><id>3</id><name>Dan</name><status>false</status><subject nil="true"/></item></response>`,
+		},
+		{
+			format:      CSV,
+			contentType: "text/csv; charset=utf-8",
+			// a null object has a column of its own
+			want: "id,name,status,subject.id,subject.name,tags,subject\n1,Noy & Co,true,2,Math,\"[\"\"a\"\",\"\"b\"\"]\",\n3,Dan,false,,,,\n",
+		},
+	}
+
+	for _, tt := range tests {
+		c, w := testContext(http.MethodGet, "/", nil, nil)
+		SetFormat(c, tt.format)
+		Render(c, http.StatusOK, users)
+
+		if w.Header().Get("Content-Type") != tt.contentType {
+			t.Errorf("%s: Content-Type is %q", tt.format, w.Header().Get("Content-Type"))
+		}
+		if got := strings.TrimPrefix(w.Body.String(), `<?xml version="1.0" encoding="UTF-8"?>`+"\n"); got != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.format, got, tt.want)
+		}
+	}
+
+	c, w := testContext(http.MethodGet, "/", nil, nil)
+	SetFormat(c, MsgPack)
+	Render(c, http.StatusOK, users[0])
+
+	var decoded map[string]interface{}
+	handle := &codec.MsgpackHandle{}
+	handle.RawToString = true
+	if err := codec.NewDecoderBytes(w.Body.Bytes(), handle).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["name"] != "Noy & Co" || decoded["status"] != true {
+		t.Errorf("msgpack body is %v", decoded)
+	}
+}
+
+func TestDecodeBodyReadsEveryFormatAsJSON(t *testing.T) {
+	var msgpack []byte
+	if err := codec.NewEncoderBytes(&msgpack, &codec.MsgpackHandle{}).Encode(map[string]interface{}{"id": 1, "name": "Noy", "status": true}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		contentType string
+		body        []byte
+		want        string
+	}{
+		{contentType: "application/msgpack", body: msgpack, want: `{"id":1,"name":"Noy","status":true}`},
+		{contentType: "application/xml", body: []byte(`<user><id>1</id><name>Noy</name><status>true</status><subject><id>2</id></subject><nick nil="true"/></user>`), want: `{"id":1,"name":"Noy","nick":null,"status":true,"subject":{"id":2}}`},
+		{contentType: "text/csv", body: []byte("id,name,status,subject.id\n1,Noy,true,2\n"), want: `{"id":1,"name":"Noy","status":true,"subject":{"id":2}}`},
+		{contentType: "application/merge-patch+json", body: []byte(`{"name":"Noy"}`), want: `{"name":"Noy"}`},
+	}
+
+	for _, tt := range tests {
+		c, _ := testContext(http.MethodPost, "/", bytes.NewReader(tt.body), map[string]string{"Content-Type": tt.contentType})
+		body, err := DecodeBody(c, &testUserPatch{})
+		if err != nil {
+			t.Errorf("%s: %v", tt.contentType, err)
+			continue
+		}
+		got, _ := io.ReadAll(body)
+		if string(got) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.contentType, got, tt.want)
+		}
+	}
+
+	for _, contentType := range []string{"text/plain", "application/yaml", "not a type"} {
+		c, _ := testContext(http.MethodPost, "/", strings.NewReader("name: Noy"), map[string]string{"Content-Type": contentType})
+		if _, err := DecodeBody(c, &testUser{}); !errors.Is(err, entity.ErrUnsupportedMediaType) {
+			t.Errorf("%s: got %v, want unsupported media type", contentType, err)
+		}
+	}
+}
+
+func TestAcceptRangesDropRefusedTypes(t *testing.T) {
+	got := acceptRanges("text/csv;q=0.2, application/xml, application/json;q=0, text/html;q=bad, application/msgpack;q=0.8")
+	if want := []string{"application/xml", "application/msgpack", "text/csv"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
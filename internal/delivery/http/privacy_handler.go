@@ -0,0 +1,137 @@
+package delivery
+
+import (
+	"fmt"
+	"net/http"
+	"sample-project/internal/delivery/http/middleware"
+	"sample-project/internal/usecase"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NOTE - privacy handler struct
+type PrivacyHandler struct {
+	useCase usecase.PrivacyUseCase
+}
+
+// NOTE - new privacy handler
+func NewPrivacyHandler(router *gin.Engine, useCase usecase.PrivacyUseCase) {
+	handler := &PrivacyHandler{useCase: useCase}
+
+	users := router.Group("/api/v1/users")
+	users.GET("/:id/export", handler.ExportUserData)
+	users.POST("/:id/erasure", handler.EraseUser)
+
+	privacy := router.Group("/api/v1/privacy")
+	privacy.GET("/records/verify", handler.VerifyPrivacyLog)
+
+	usersV2 := router.Group("/api/v2/users")
+	usersV2.GET("/:id/export", handler.ExportUserData)
+	usersV2.POST("/:id/erasure", handler.EraseUser)
+
+	privacyV2 := router.Group("/api/v2/privacy")
+	privacyV2.GET("/records/verify", handler.VerifyPrivacyLog)
+}
+
+// NOTE - export user data handler
+// @Summary Export user data
+// @Description Download a zip archive of everything stored about a user: profile, subject membership, sessions and audit events
+// @Tags privacy
+// @Security BearerAuth
+// @Produce application/zip
+// @Param id path int true "User ID"
+// @Success 200 {file} file "Zip archive"
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 401 {object} entity.ErrorResponse
+// @Failure 404 {object} entity.ErrorResponse
+// @Failure 500 {object} entity.ErrorResponse
+// @Router /api/v1/users/{id}/export [get]
+// @Router /api/v2/users/{id}/export [get]
+func (h *PrivacyHandler) ExportUserData(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	claims, err := middleware.Claims(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	archive, err := h.useCase.ExportUserData(c.Request.Context(), id, &claims.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	filename := fmt.Sprintf("user-%d-export-%s.zip", id, time.Now().Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// NOTE - erase user handler
+// @Summary Erase user data
+// @Description Anonymize a user in place (right to erasure), purge its cache entries and write a tamper-evident completion record
+// @Tags privacy
+// @Security BearerAuth
+// @Produce json,xml,application/msgpack,text/csv
+// @Param id path int true "User ID"
+// @Success 200 {object} entity.PrivacyRecord
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 401 {object} entity.ErrorResponse
+// @Failure 404 {object} entity.ErrorResponse
+// @Failure 409 {object} entity.ErrorResponse
+// @Failure 500 {object} entity.ErrorResponse
+// @Router /api/v1/users/{id}/erasure [post]
+// @Router /api/v2/users/{id}/erasure [post]
+func (h *PrivacyHandler) EraseUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	claims, err := middleware.Claims(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	record, err := h.useCase.EraseUser(c.Request.Context(), id, &claims.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	render(c, http.StatusOK, record)
+}
+
+// NOTE - verify privacy log handler
+// @Summary Verify the privacy request log
+// @Description Recompute the hash chain of completed data-subject requests of the school and report the first broken record
+// @Tags privacy
+// @Security BearerAuth
+// @Produce json,xml,application/msgpack,text/csv
+// @Success 200 {object} entity.PrivacyChainStatus
+// @Failure 401 {object} entity.ErrorResponse
+// @Failure 500 {object} entity.ErrorResponse
+// @Router /api/v1/privacy/records/verify [get]
+// @Router /api/v2/privacy/records/verify [get]
+func (h *PrivacyHandler) VerifyPrivacyLog(c *gin.Context) {
+	if _, err := middleware.Claims(c); err != nil {
+		c.Error(err)
+		return
+	}
+
+	status, err := h.useCase.VerifyPrivacyLog(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	render(c, http.StatusOK, status)
+}
@@ -0,0 +1,62 @@
+package delivery
+
+import (
+	"bytes"
+	"encoding/json"
+	"sample-project/internal/entity"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NOTE - reads the sparse fieldset from `fields` and the relations from `include`
+func queryProjection(c *gin.Context, selectable, includable []string) (entity.Projection, error) {
+	return entity.ParseProjection(c.Query("fields"), c.Query("include"), selectable, includable)
+}
+
+// NOTE - body with only the projected members, body is a resource or a list of them.
+// Relations stay under their json key, which the resource names in relationKeys
+func projectBody(body interface{}, projection entity.Projection, relationKeys ...string) interface{} {
+	if len(projection.Fields) == 0 {
+		return body
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return body
+	}
+
+	var tree interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// keeps integers as they are instead of turning them into floats
+	decoder.UseNumber()
+	if err := decoder.Decode(&tree); err != nil {
+		return body
+	}
+
+	keep := func(key string) bool {
+		return slices.Contains(projection.Fields, key) || slices.Contains(relationKeys, key)
+	}
+
+	switch value := tree.(type) {
+	case []interface{}:
+		for _, item := range value {
+			if resource, ok := item.(map[string]interface{}); ok {
+				pruneMembers(resource, keep)
+			}
+		}
+	case map[string]interface{}:
+		pruneMembers(value, keep)
+	}
+
+	return tree
+}
+
+// NOTE - removes the members keep rejects
+func pruneMembers(resource map[string]interface{}, keep func(string) bool) {
+	for key := range resource {
+		if !keep(key) {
+			delete(resource, key)
+		}
+	}
+}
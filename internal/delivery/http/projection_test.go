@@ -0,0 +1,100 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sample-project/internal/delivery/http/middleware"
+	"sample-project/internal/entity"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// projectedUsers keeps the projection the handlers asked for and, like the repositories,
+// only loads the subject when it was included
+type projectedUsers struct {
+	contractUsers
+	projection entity.Projection
+}
+
+func (u *projectedUsers) read(projection entity.Projection) entity.User {
+	u.projection = projection
+	user := contractUser
+	if !projection.Includes(entity.IncludeSubject) {
+		user.Subject = nil
+	}
+	return user
+}
+
+func (u *projectedUsers) GetUserByID(ctx context.Context, id int, projection entity.Projection) (*entity.User, error) {
+	user := u.read(projection)
+	return &user, nil
+}
+
+func (u *projectedUsers) GetUsers(ctx context.Context, page, limit int, name string, startDate, endDate string, state entity.UserState, projection entity.Projection) ([]entity.User, int, error) {
+	return []entity.User{u.read(projection)}, 1, nil
+}
+
+// NOTE - sorted member names of a JSON object
+func memberNames(object map[string]json.RawMessage) []string {
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestReadsOnlyReturnTheProjectedMembers(t *testing.T) {
+	users := &projectedUsers{}
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	NewUserHandler(router, users)
+
+	tests := []struct {
+		target  string
+		status  int
+		members []string
+		asked   entity.Projection
+	}{
+		{target: "/api/v2/users/1?fields=name,id", status: http.StatusOK, members: []string{"id", "name"}, asked: entity.Projection{Fields: []string{"id", "name"}}},
+		{target: "/api/v2/users/1?fields=id&include=subject", status: http.StatusOK, members: []string{"id", "subject"}, asked: entity.Projection{Fields: []string{"id"}, Include: []string{"subject"}}},
+		{target: "/api/v2/users?fields=email", status: http.StatusOK, members: []string{"email"}, asked: entity.Projection{Fields: []string{"email"}}},
+		{target: "/api/v2/users/1?fields=password", status: http.StatusUnprocessableEntity},
+		{target: "/api/v2/users/1?include=users", status: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		users.projection = entity.Projection{}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("%s: got %d %s, want %d", tt.target, w.Code, w.Body, tt.status)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		if !reflect.DeepEqual(users.projection, tt.asked) {
+			t.Errorf("%s: the use case was asked for %+v, want %+v", tt.target, users.projection, tt.asked)
+		}
+
+		var user map[string]json.RawMessage
+		var page struct {
+			Data []map[string]json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err == nil && len(page.Data) > 0 {
+			user = page.Data[0]
+		} else if err := json.Unmarshal(w.Body.Bytes(), &user); err != nil {
+			t.Fatalf("%s: %v in %s", tt.target, err, w.Body)
+		}
+		if got := memberNames(user); !reflect.DeepEqual(got, tt.members) {
+			t.Errorf("%s: returned members %v, want %v", tt.target, got, tt.members)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sample-project/internal/delivery/http/middleware"
+	"sample-project/internal/entity"
+	"sample-project/internal/validation"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routeUsers is contractUsers without user 404
+type routeUsers struct{ contractUsers }
+
+func (u routeUsers) GetUserByID(ctx context.Context, id int, projection entity.Projection) (*entity.User, error) {
+	if id == 404 {
+		return nil, entity.NotFound("user_not_found", "user with ID: %d not found", id)
+	}
+	return u.contractUsers.GetUserByID(ctx, id, projection)
+}
+
+func TestV2RoutesShareTheHandlersWithResourceSemantics(t *testing.T) {
+	validation.Register(contractUserRepo{}, contractSubjectRepo{})
+	router := gin.New()
+	router.Use(
+		middleware.Deprecation("/api/v1/", "/api/v2", time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC), time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC)),
+		middleware.ErrorHandler(),
+	)
+	NewUserHandler(router, routeUsers{})
+	NewSubjectHandler(router, contractSubjects{})
+
+	tests := []struct {
+		method   string
+		target   string
+		body     string
+		status   int
+		location string
+	}{
+		{method: http.MethodPost, target: "/api/v2/users", body: `{"name":"Noy","email":"noy@example.com","password":"password1"}`, status: http.StatusCreated, location: "/api/v2/users/1"},
+		{method: http.MethodGet, target: "/api/v2/users/1", status: http.StatusOK},
+		{method: http.MethodGet, target: "/api/v2/users/404", status: http.StatusNotFound},
+		{method: http.MethodPut, target: "/api/v2/users/1", body: `{"name":"Noy P."}`, status: http.StatusOK},
+		{method: http.MethodDelete, target: "/api/v2/users/1", status: http.StatusNoContent},
+		{method: http.MethodDelete, target: "/api/v2/users/cache", status: http.StatusNoContent},
+		{method: http.MethodPost, target: "/api/v2/subjects", body: `{"name":"Math"}`, status: http.StatusCreated, location: "/api/v2/subjects/2"},
+		{method: http.MethodDelete, target: "/api/v2/subjects/2", status: http.StatusNoContent},
+		{method: http.MethodPut, target: "/api/v1/users/update/1", body: `{"name":"Noy P."}`, status: http.StatusOK},
+		{method: http.MethodGet, target: "/api/v1/users/by/Noy", status: http.StatusOK},
+		{method: http.MethodDelete, target: "/api/v1/subjects/delete/2", status: http.StatusNoContent},
+		{method: http.MethodDelete, target: "/api/v1/users/clear-cache", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		req.Header.Set("If-Match", `"3"`)
+		if tt.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		name := tt.method + " " + tt.target
+		if w.Code != tt.status {
+			t.Errorf("%s: got %d %s, want %d", name, w.Code, w.Body, tt.status)
+		}
+		if got := w.Header().Get("Location"); got != tt.location {
+			t.Errorf("%s: Location is %q, want %q", name, got, tt.location)
+		}
+		if deprecated := w.Header().Get("Deprecation") != ""; deprecated != strings.HasPrefix(tt.target, "/api/v1/") {
+			t.Errorf("%s: Deprecation header is %q", name, w.Header().Get("Deprecation"))
+		}
+	}
+}
@@ -1,7 +1,7 @@
 package delivery
 
 import (
-	"context"
+	"fmt"
 	"net/http"
 	"sample-project/internal/entity"
 	"sample-project/internal/usecase"
@@ -10,6 +10,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+var errInvalidSubjectID = entity.BadRequest("invalid_subject_id", "Invalid subject ID")
+
 // NOTE - subject handler struct
 type SubjectHandler struct {
 	useCase usecase.SubjectUsecase
@@ -19,141 +21,239 @@ type SubjectHandler struct {
 func NewSubjectHandler(router *gin.Engine, useCase usecase.SubjectUsecase) {
 	handler := &SubjectHandler{useCase: useCase}
 
+	// v1 is deprecated, see middleware.Deprecation
 	subjects := router.Group("/api/v1/subjects")
 
 	subjects.GET("", handler.GetSubject)
 	subjects.GET("/:id", handler.GetSubjectByID)
 	subjects.POST("", handler.CreateSubject)
 	subjects.PUT("/update/:id", handler.UpdateSubject)
+	subjects.PATCH("/:id", handler.PatchSubject)
 	subjects.DELETE("/delete/:id", handler.DeleteSubject)
 	subjects.DELETE("/clear-cache", handler.ClearSubjectCache)
+
+	subjectsV2 := router.Group("/api/v2/subjects")
+
+	subjectsV2.GET("", handler.GetSubject)
+	subjectsV2.POST("", handler.CreateSubject)
+	subjectsV2.GET("/:id", handler.GetSubjectByID)
+	subjectsV2.PUT("/:id", handler.UpdateSubject)
+	subjectsV2.PATCH("/:id", handler.PatchSubject)
+	subjectsV2.DELETE("/:id", handler.DeleteSubject)
+	subjectsV2.DELETE("/cache", handler.PurgeSubjectCache)
 }
 
 // NOTE - get all subjects handler
 // @Summary Get all subjects
 // @Description Get a list of all subjects
 // @Tags subjects
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack,text/csv
+// @Produce json,xml,application/msgpack,text/csv
+// @Param fields query string false "Comma separated fields to return, e.g. id,name. Default: every field"
+// @Param include query string false "Comma separated relations to load" Enums(users)
+// @Param If-None-Match header string false "ETag of the cached copy, answered with 304 when it is still current"
+// @Param If-Modified-Since header string false "Last-Modified of the cached copy, answered with 304 when nothing changed since"
 // @Success 200 {array} entity.Subject
+// @Header 200 {string} ETag "Hash of the subject list"
+// @Header 200 {string} Last-Modified "Latest update of the subjects and their users"
+// @Success 304 "Not modified"
 // @Router /api/v1/subjects [get]
+// @Router /api/v2/subjects [get]
 func (h *SubjectHandler) GetSubject(c *gin.Context) {
-	subjects, err := h.useCase.GetSubject(c)
+	projection, err := queryProjection(c, entity.SubjectFields, []string{entity.IncludeUsers})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// a cached list carries its etag, so a current client copy is confirmed without touching the database
+	if respondNotModified(c, h.useCase.GetSubjectsETag(c.Request.Context(), projection)) {
+		return
+	}
+
+	subjects, err := h.useCase.GetSubject(c.Request.Context(), projection)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
-	c.JSON(http.StatusOK, subjects)
+	respondConditional(c, contentETag(subjects), subjectsLastModified(subjects...), projectBody(subjects, projection, "user"))
 }
 
 // NOTE - get subject by id handler
 // @Summary Get subject by ID
 // @Description Get a single subject by ID
 // @Tags subjects
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack,text/csv
+// @Produce json,xml,application/msgpack,text/csv
 // @Param id path int true "Subject ID"
+// @Param fields query string false "Comma separated fields to return, e.g. id,name. Default: every field"
+// @Param include query string false "Comma separated relations to load" Enums(users)
+// @Param If-None-Match header string false "ETag of the cached copy, answered with 304 when it is still current"
+// @Param If-Modified-Since header string false "Last-Modified of the cached copy, answered with 304 when nothing changed since"
 // @Success 200 {object} entity.Subject
+// @Header 200 {string} ETag "Hash of the subject"
+// @Header 200 {string} Last-Modified "Latest update of the subject and its users"
+// @Success 304 "Not modified"
 // @Router /api/v1/subjects/{id} [get]
+// @Router /api/v2/subjects/{id} [get]
 func (h *SubjectHandler) GetSubjectByID(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subject ID"})
+		c.Error(errInvalidSubjectID)
+		return
+	}
+
+	projection, err := queryProjection(c, entity.SubjectFields, []string{entity.IncludeUsers})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if respondNotModified(c, h.useCase.GetSubjectETag(c.Request.Context(), id, projection)) {
 		return
 	}
 
-	subject, err := h.useCase.GetSubjectByID(c, id)
+	subject, err := h.useCase.GetSubjectByID(c.Request.Context(), id, projection)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Subject not found"})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, subject)
+	respondConditional(c, contentETag(subject), subjectsLastModified(*subject), projectBody(subject, projection, "user"))
 }
 
 // NOTE - create subject handler
 // @Summary Create a subject
 // @Description Create a new subject
 // @Tags subjects
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack,text/csv
+// @Produce json,xml,application/msgpack,text/csv
+// @Param Idempotency-Key header string false "Unique key, retries with the same key and body replay the first response"
 // @Param subject body entity.CreateSubjectRequest true "Subject data"
 // @Success 201 {object} entity.Subject
+// @Failure 422 {object} entity.ErrorResponse
 // @Router /api/v1/subjects [post]
+// @Router /api/v2/subjects [post]
 func (h *SubjectHandler) CreateSubject(c *gin.Context) {
-	var subject entity.Subject
-	if err := c.ShouldBindJSON(&subject); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var req entity.CreateSubjectRequest
+	if err := bindBody(c, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
 	newSubject := entity.Subject{
-		Name: subject.Name,
+		Name: req.Name,
 	}
 
-	subjectCreated, err := h.useCase.CreateSubject(c, newSubject)
+	subjectCreated, err := h.useCase.CreateSubject(c.Request.Context(), newSubject)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
-	c.JSON(http.StatusCreated, subjectCreated)
+	c.Header("Location", fmt.Sprintf("%s/%d", c.FullPath(), subjectCreated.ID))
+	render(c, http.StatusCreated, subjectCreated)
 }
 
 // NOTE - update subject handler
 // @Summary Update a subject
 // @Description Update subject details
 // @Tags subjects
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack,text/csv
+// @Produce json,xml,application/msgpack,text/csv
 // @Param id path int true "Subject ID"
 // @Param subject body entity.UpdateSubjectRequest true "Updated subject data"
 // @Success 200 {object} entity.Subject
+// @Failure 422 {object} entity.ErrorResponse
 // @Router /api/v1/subjects/update/{id} [put]
+// @Router /api/v2/subjects/{id} [put]
 func (h *SubjectHandler) UpdateSubject(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subject ID"})
+		c.Error(errInvalidSubjectID)
 		return
 	}
 
 	var req entity.UpdateSubjectRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindBody(c, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
-	subjectUpdate := entity.Subject{
-		Name: req.Name,
+	var patch entity.SubjectPatch
+	if req.Name != "" {
+		patch.Name = entity.NewNullable(req.Name)
+	}
+	if req.Status != nil {
+		patch.Status = entity.NewNullable(*req.Status)
 	}
 
-	updatedSubject, err := h.useCase.UpdateSubject(c, id, subjectUpdate)
+	updatedSubject, err := h.useCase.UpdateSubject(c.Request.Context(), id, patch)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedSubject)
+	render(c, http.StatusOK, updatedSubject)
+}
+
+// NOTE - patch subject handler
+// @Summary Patch a subject
+// @Description Partially update a subject with JSON merge-patch (RFC 7396). Absent members are left untouched
+// @Tags subjects
+// @Accept application/merge-patch+json,json,xml,application/msgpack,text/csv
+// @Produce json,xml,application/msgpack,text/csv
+// @Param id path int true "Subject ID"
+// @Param subject body entity.SubjectPatch true "Merge patch document"
+// @Success 200 {object} entity.Subject
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 404 {object} entity.ErrorResponse
+// @Failure 415 {object} entity.ErrorResponse
+// @Failure 422 {object} entity.ErrorResponse
+// @Router /api/v1/subjects/{id} [patch]
+// @Router /api/v2/subjects/{id} [patch]
+func (h *SubjectHandler) PatchSubject(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errInvalidSubjectID)
+		return
+	}
+
+	var patch entity.SubjectPatch
+	if err := bindMergePatch(c, &patch); err != nil {
+		c.Error(err)
+		return
+	}
+
+	updatedSubject, err := h.useCase.UpdateSubject(c.Request.Context(), id, patch)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	render(c, http.StatusOK, updatedSubject)
 }
 
 // NOTE - delete subject handler
 // @Summary Delete a subject
 // @Description Remove a subject by ID
 // @Tags subjects
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack,text/csv
+// @Produce json,xml,application/msgpack,text/csv
 // @Param id path int true "Subject ID"
 // @Success 204 "No Content"
+// @Failure 404 {object} entity.ErrorResponse
 // @Router /api/v1/subjects/delete/{id} [delete]
+// @Router /api/v2/subjects/{id} [delete]
 func (h *SubjectHandler) DeleteSubject(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subject ID"})
+		c.Error(errInvalidSubjectID)
 		return
 	}
 
-	err = h.useCase.DeleteSubject(c, id)
+	err = h.useCase.DeleteSubject(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -164,18 +264,36 @@ func (h *SubjectHandler) DeleteSubject(c *gin.Context) {
 // @Summary Clear cache of subjects
 // @Description Clear the cache of subjects
 // @Tags subjects
-// @Accept json
-// @Produce json
-// @Success 204 "No Content"
+// @Accept json,xml,application/msgpack,text/csv
+// @Produce json,xml,application/msgpack,text/csv
+// @Success 200 {object} entity.MessageResponse
+// @Failure 500 {object} entity.ErrorResponse
 // @Router /api/v1/subjects/clear-cache [delete]
 func (h *SubjectHandler) ClearSubjectCache(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	err := h.useCase.ClearSubjectCache(ctx)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear subjects caches"})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Subject caches cleared successfully"})
+	render(c, http.StatusOK, entity.MessageResponse{Message: "Subject caches cleared successfully"})
+}
+
+// NOTE - purge cache of subjects handler
+// @Summary Purge cache of subjects
+// @Description Clear the cache of subjects
+// @Tags subjects
+// @Produce json,xml,application/msgpack,text/csv
+// @Success 204 "No Content"
+// @Failure 500 {object} entity.ErrorResponse
+// @Router /api/v2/subjects/cache [delete]
+func (h *SubjectHandler) PurgeSubjectCache(c *gin.Context) {
+	if err := h.useCase.ClearSubjectCache(c.Request.Context()); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
 }
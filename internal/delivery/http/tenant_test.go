@@ -0,0 +1,184 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sample-project/internal/delivery/graphql"
+	"sample-project/internal/delivery/http/middleware"
+	"sample-project/internal/entity"
+	"sample-project/internal/repository"
+	"sample-project/internal/usecase"
+	"sample-project/internal/utils"
+	"sample-project/internal/validation"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// schoolHosts serves school 1 on a.example.com and school 2 on b.example.com
+type schoolHosts struct {
+	repository.TenantRepository
+}
+
+func (schoolHosts) GetTenantByHost(ctx context.Context, host string) (*entity.Tenant, error) {
+	switch host {
+	case "a.example.com":
+		return &entity.Tenant{ID: 1}, nil
+	case "b.example.com":
+		return &entity.Tenant{ID: 2}, nil
+	}
+	return nil, entity.ErrNotFound
+}
+
+// schoolUsers keeps the users of every school apart, like the repositories do, and only sees the school of ctx
+type schoolUsers struct {
+	usecase.UserUseCase
+	mu    sync.Mutex
+	users map[int][]entity.User
+}
+
+// NOTE - index of the user in the school of ctx
+func (u *schoolUsers) find(ctx context.Context, id int) (int, int, error) {
+	tenantID, ok := entity.TenantID(ctx)
+	if !ok {
+		return 0, 0, entity.ErrTenantRequired
+	}
+	for i, user := range u.users[tenantID] {
+		if user.ID == id {
+			return tenantID, i, nil
+		}
+	}
+	return 0, 0, entity.NotFound("user_not_found", "user with ID: %d not found", id)
+}
+
+func (u *schoolUsers) GetUsers(ctx context.Context, page, limit int, name string, startDate, endDate string, state entity.UserState, projection entity.Projection) ([]entity.User, int, error) {
+	tenantID, ok := entity.TenantID(ctx)
+	if !ok {
+		return nil, 0, entity.ErrTenantRequired
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.users[tenantID], len(u.users[tenantID]), nil
+}
+
+func (u *schoolUsers) GetUserByID(ctx context.Context, id int, projection entity.Projection) (*entity.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	tenantID, i, err := u.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	user := u.users[tenantID][i]
+	return &user, nil
+}
+
+func (u *schoolUsers) UpdateUser(ctx context.Context, id int, versions []int, patch entity.UserPatch) (*entity.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	tenantID, i, err := u.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	user := &u.users[tenantID][i]
+	if patch.Name.IsSet() {
+		user.Name = patch.Name.Value
+	}
+	user.Version++
+	updated := *user
+	return &updated, nil
+}
+
+func (u *schoolUsers) DeleteUser(ctx context.Context, id int, versions []int) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	tenantID, i, err := u.find(ctx, id)
+	if err != nil {
+		return err
+	}
+	u.users[tenantID] = slices.Delete(u.users[tenantID], i, i+1)
+	return nil
+}
+
+// NOTE - router authenticating and scoping requests like cmd/main.go, over the user handlers and GraphQL
+func schoolRouter(users *schoolUsers) *gin.Engine {
+	validation.Register(contractUserRepo{}, contractSubjectRepo{})
+
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(
+		middleware.Authenticate("/api/", "/graphql"),
+		middleware.Tenant(usecase.NewTenantUseCase(schoolHosts{}).ResolveTenant, "/api/", "/graphql"),
+		middleware.ErrorHandler(),
+	)
+	NewUserHandler(router, users)
+	graphql.NewGraphQLHandler(router, users, nil)
+	return router
+}
+
+func TestRequestsOnlyReachTheTokenSchool(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	tokenA, _, err := utils.GenerateToken(1, 1, "Noy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := &schoolUsers{users: map[int][]entity.User{
+		1: {{ID: 1, Name: "Noy", Email: "noy@example.com", State: entity.UserStateActive, Version: 1}},
+		2: {{ID: 2, Name: "Dan", Email: "dan@example.com", State: entity.UserStateActive, Version: 1}},
+	}}
+	router := schoolRouter(users)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		host   string
+		body   string
+		status int
+		// part of the response body
+		want string
+	}{
+		{name: "list", method: http.MethodGet, target: "/api/v2/users", host: "a.example.com", status: http.StatusOK, want: `"name":"Noy"`},
+		{name: "read own", method: http.MethodGet, target: "/api/v2/users/1", status: http.StatusOK, want: `"name":"Noy"`},
+		{name: "read other", method: http.MethodGet, target: "/api/v2/users/2", host: "a.example.com", status: http.StatusNotFound},
+		{name: "update other", method: http.MethodPatch, target: "/api/v2/users/2", body: `{"name":"Mallory"}`, status: http.StatusNotFound},
+		{name: "delete other", method: http.MethodDelete, target: "/api/v2/users/2", status: http.StatusNotFound},
+		{name: "host of other", method: http.MethodGet, target: "/api/v2/users/2", host: "b.example.com", status: http.StatusForbidden, want: "tenant_mismatch"},
+		{name: "graphql list", method: http.MethodPost, target: "/graphql", body: `{"query":"{ users { nodes { id name } } }"}`, status: http.StatusOK, want: `{"data":{"users":{"nodes":[{"id":1,"name":"Noy"}]}}}`},
+		{name: "graphql read other", method: http.MethodPost, target: "/graphql", body: `{"query":"{ user(id: 2) { name } }"}`, status: http.StatusOK, want: `{"data":{"user":null}}`},
+		{name: "graphql delete other", method: http.MethodPost, target: "/graphql", body: `{"query":"mutation { deleteUser(id: 2, version: 1) }"}`, status: http.StatusOK, want: "user_not_found"},
+		{name: "graphql host of other", method: http.MethodPost, target: "/graphql", host: "b.example.com", body: `{"query":"{ user(id: 2) { name } }"}`, status: http.StatusForbidden, want: "tenant_mismatch"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		if tt.host != "" {
+			req.Host = tt.host
+		}
+		req.Header.Set("Authorization", "Bearer "+tokenA)
+		req.Header.Set("If-Match", `"1"`)
+		switch {
+		case tt.method == http.MethodPatch:
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+		case tt.body != "":
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s: got %d %s, want %d with %s", tt.name, w.Code, w.Body, tt.status, tt.want)
+		}
+		if strings.Contains(w.Body.String(), "Dan") {
+			t.Errorf("%s: the user of school 2 reached school 1: %s", tt.name, w.Body)
+		}
+	}
+
+	if len(users.users[2]) != 1 || users.users[2][0].Name != "Dan" {
+		t.Errorf("school 2 has users %+v after the requests of school 1", users.users[2])
+	}
+}
@@ -1,8 +1,11 @@
 package delivery
 
 import (
-	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"sample-project/internal/delivery/http/middleware"
 	"sample-project/internal/entity"
 	"sample-project/internal/usecase"
 	"strconv"
@@ -11,6 +14,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+const maxAvatarUploadSize = 5 << 20
+
+var errInvalidUserID = entity.BadRequest("invalid_user_id", "Invalid user ID")
+
 // NOTE - user handler struct
 type UserHandler struct {
 	useCase usecase.UserUseCase
@@ -20,6 +27,7 @@ type UserHandler struct {
 func NewUserHandler(router *gin.Engine, useCase usecase.UserUseCase) {
 	handler := &UserHandler{useCase: useCase}
 
+	// v1 is deprecated, see middleware.Deprecation
 	users := router.Group("/api/v1/users")
 
 	users.GET("", handler.GetUsers)
@@ -27,25 +35,51 @@ func NewUserHandler(router *gin.Engine, useCase usecase.UserUseCase) {
 	users.GET("by/:name", handler.GetUserByName)
 	users.POST("", handler.CreateUser)
 	users.PUT("/update/:id", handler.UpdateUser)
+	users.PATCH("/:id", handler.PatchUser)
+	users.PUT("/:id/avatar", handler.UploadAvatar)
+	users.POST("/:id/state", handler.TransitionState)
+	users.GET("/:id/state-transitions", handler.GetStateTransitions)
 	users.DELETE("/delete/:id", handler.DeleteUser)
 	users.DELETE("/clear-cache", handler.ClearUserCache)
+
+	usersV2 := router.Group("/api/v2/users")
+
+	usersV2.GET("", handler.GetUsers)
+	usersV2.POST("", handler.CreateUser)
+	usersV2.GET("/:id", handler.GetUserByID)
+	usersV2.PUT("/:id", handler.UpdateUser)
+	usersV2.PATCH("/:id", handler.PatchUser)
+	usersV2.DELETE("/:id", handler.DeleteUser)
+	usersV2.PUT("/:id/avatar", handler.UploadAvatar)
+	usersV2.POST("/:id/state", handler.TransitionState)
+	usersV2.GET("/:id/state-transitions", handler.GetStateTransitions)
+	usersV2.DELETE("/cache", handler.PurgeUserCache)
 }
 
 // NOTE - get all users handler
 // @Summary Get all users
 // @Description Get list of all users
 // @Tags users
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack,text/csv
+// @Produce json,xml,application/msgpack,text/csv
 // @Param page query int false "Page number (default: 1)" minimum(1)
 // @Param limit query int false "Results per page (default: 10)" minimum(1) maximum(100)
 // @Param name query string false "Filter by user name (partial match)"
 // @Param startDate query string false "Filter by start date (format: YYYY-MM-DD)"
 // @Param endDate query string false "Filter by end date (format: YYYY-MM-DD)"
-// @Success 200 {object} entity.UserListResponse
+// @Param state query string false "Filter by lifecycle state" Enums(PENDING, ACTIVE, SUSPENDED, GRADUATED)
+// @Param fields query string false "Comma separated fields to return, e.g. id,name. Default: every field"
+// @Param include query string false "Comma separated relations to load" Enums(subject)
+// @Param If-None-Match header string false "ETag of the cached copy, answered with 304 when it is still current"
+// @Param If-Modified-Since header string false "Last-Modified of the cached copy, answered with 304 when nothing changed since"
+// @Success 200 {object} entity.UserListResponse{data=[]entity.UserResponse}
+// @Header 200 {string} ETag "Hash of the page"
+// @Header 200 {string} Last-Modified "Latest update of the users on the page"
+// @Success 304 "Not modified"
 // @Failure 400 {object} entity.ErrorResponse
 // @Failure 500 {object} entity.ErrorResponse
 // @Router /api/v1/users [get]
+// @Router /api/v2/users [get]
 func (h *UserHandler) GetUsers(c *gin.Context) {
 	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
 	if err != nil || page <= 0 {
@@ -61,59 +95,101 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 	startDate := c.DefaultQuery("startDate", "")
 	endDate := c.DefaultQuery("endDate", "")
 
-	users, totalCount, err := h.useCase.GetUsers(c, page, limit, name, startDate, endDate)
+	state := entity.UserState(strings.ToUpper(c.DefaultQuery("state", "")))
+	if state != "" && !state.IsValid() {
+		c.Error(entity.BadRequest("invalid_state", "Invalid user state"))
+		return
+	}
+
+	projection, err := queryProjection(c, entity.UserFields, []string{entity.IncludeSubject})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	users, totalCount, err := h.useCase.GetUsers(c.Request.Context(), page, limit, name, startDate, endDate, state, projection)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve users"})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"meta": gin.H{
-			"page":       page,
-			"limit":      limit,
-			"total":      totalCount,
-			"totalPages": (totalCount + limit - 1) / limit,
+	response := entity.UserListResponse{
+		Data: projectBody(users, projection, "subject"),
+		Meta: entity.PageMeta{
+			Page:       page,
+			Limit:      limit,
+			Total:      totalCount,
+			TotalPages: (totalCount + limit - 1) / limit,
 		},
-		"data": users,
-	})
+	}
+	respondConditional(c, contentETag(response), usersLastModified(users), response)
 }
 
 // NOTE - get user by id handler
 // @Summary Get user by ID
 // @Description Get a single user by ID
 // @Tags users
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack,text/csv
+// @Produce json,xml,application/msgpack,text/csv
 // @Param id path int true "User ID"
+// @Param fields query string false "Comma separated fields to return, e.g. id,name. Default: every field"
+// @Param include query string false "Comma separated relations to load" Enums(subject)
+// @Param If-None-Match header string false "ETag of the cached copy, answered with 304 when it is still current"
+// @Param If-Modified-Since header string false "Last-Modified of the cached copy, answered with 304 when nothing changed since"
 // @Success 200 {object} entity.UserResponse
+// @Header 200 {string} ETag "Current version of the user"
+// @Header 200 {string} Last-Modified "Last update of the user"
+// @Success 304 "Not modified"
 // @Failure 404 {object} entity.ErrorResponse
 // @Failure 400 {object} entity.ErrorResponse
 // @Failure 500 {object} entity.ErrorResponse
 // @Router /api/v1/users/{id} [get]
+// @Router /api/v2/users/{id} [get]
 func (h *UserHandler) GetUserByID(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(errInvalidUserID)
 		return
 	}
 
-	user, err := h.useCase.GetUserByID(c, id)
+	projection, err := queryProjection(c, entity.UserFields, []string{entity.IncludeSubject})
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	user, err := h.useCase.GetUserByID(c.Request.Context(), id, projection)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	body := projectBody(user, projection, "subject")
+	etag, lastModified := versionETag(user.Version), user.UpdatedAt
+	// the version only covers the user, an embedded subject changes on its own
+	if user.Subject != nil {
+		etag = contentETag(body)
+		if user.Subject.UpdatedAt.After(lastModified) {
+			lastModified = user.Subject.UpdatedAt
+		}
+	}
+
+	respondConditional(c, etag, lastModified, body)
 }
 
 // NOTE - get user by name handler
 // @Summary Get user by Name
 // @Description Get a single user by Name
 // @Tags users
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack,text/csv
+// @Produce json,xml,application/msgpack,text/csv
 // @Param name path string true "User Name"
+// @Param If-None-Match header string false "ETag of the cached copy, answered with 304 when it is still current"
+// @Param If-Modified-Since header string false "Last-Modified of the cached copy, answered with 304 when nothing changed since"
 // @Success 200 {object} entity.UserResponse
+// @Header 200 {string} ETag "Current version of the user"
+// @Header 200 {string} Last-Modified "Last update of the user"
+// @Success 304 "Not modified"
 // @Failure 404 {object} entity.ErrorResponse
 // @Failure 400 {object} entity.ErrorResponse
 // @Failure 500 {object} entity.ErrorResponse
@@ -121,34 +197,39 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 func (h *UserHandler) GetUserByName(c *gin.Context) {
 	name := c.Param("name")
 	if name == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user Name"})
+		c.Error(entity.BadRequest("invalid_user_name", "Invalid user Name"))
 		return
 	}
 
-	user, err := h.useCase.GetUserByName(c, name)
+	user, err := h.useCase.GetUserByName(c.Request.Context(), name)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	respondConditional(c, versionETag(user.Version), user.UpdatedAt, user)
 }
 
 // NOTE - create user handler
 // @Summary Create a user
 // @Description Create a new user
 // @Tags users
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack,text/csv
+// @Produce json,xml,application/msgpack,text/csv
+// @Param Idempotency-Key header string false "Unique key, retries with the same key and body replay the first response"
 // @Param user body entity.CreateUserRequest true "User data"
 // @Success 201 {object} entity.UserResponse
 // @Failure 400 {object} entity.ErrorResponse
+// @Failure 404 {object} entity.ErrorResponse
+// @Failure 409 {object} entity.ErrorResponse
 // @Failure 500 {object} entity.ErrorResponse
+// @Failure 422 {object} entity.ErrorResponse
 // @Router /api/v1/users [post]
+// @Router /api/v2/users [post]
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	var req entity.CreateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindBody(c, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -160,92 +241,302 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		Status:    true,
 	}
 
-	createdUser, err := h.useCase.CreateUser(c, newUser)
+	createdUser, err := h.useCase.CreateUser(c.Request.Context(), newUser)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
-	c.JSON(http.StatusCreated, createdUser)
+	c.Header("Location", fmt.Sprintf("%s/%d", c.FullPath(), createdUser.ID))
+	render(c, http.StatusCreated, createdUser)
 }
 
 // NOTE - update user handler
 // @Summary Update a user
 // @Description Update user details
 // @Tags users
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack,text/csv
+// @Produce json,xml,application/msgpack,text/csv
 // @Param id path int true "User ID"
+// @Param If-Match header string true "ETag of the version being replaced"
 // @Param user body entity.UpdateUserRequest true "User data"
-// @Success 201 {object} entity.UserResponse
+// @Success 200 {object} entity.UserResponse
+// @Failure 412 {object} entity.ErrorResponse
+// @Failure 428 {object} entity.ErrorResponse
 // @Failure 404 {object} entity.ErrorResponse
 // @Failure 400 {object} entity.ErrorResponse
+// @Failure 409 {object} entity.ErrorResponse
 // @Failure 500 {object} entity.ErrorResponse
+// @Failure 422 {object} entity.ErrorResponse
 // @Router /api/v1/users/update/{id} [put]
+// @Router /api/v2/users/{id} [put]
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	_, err = h.useCase.GetUserByID(c.Request.Context(), id, entity.Projection{})
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	_, err = h.useCase.GetUserByID(c, id)
+	versions, err := parseIfMatch(c)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		c.Error(err)
 		return
 	}
 
 	var req entity.UpdateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindBody(c, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
-	userUpdate := entity.User{
-		Name:      req.Name,
-		Email:     req.Email,
-		Status:    req.Status,
-		SubjectID: req.SubjectID,
+	var patch entity.UserPatch
+	if req.Name != "" {
+		patch.Name = entity.NewNullable(req.Name)
+	}
+	if req.Email != "" {
+		patch.Email = entity.NewNullable(req.Email)
+	}
+	if req.Password != "" {
+		patch.Password = entity.NewNullable(req.Password)
+	}
+	if req.SubjectID != 0 {
+		patch.SubjectID = entity.NewNullable(req.SubjectID)
+	}
+	if req.Status != nil {
+		patch.Status = entity.NewNullable(*req.Status)
+	}
+
+	updatedUser, err := h.useCase.UpdateUser(c.Request.Context(), id, versions, patch)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", versionETag(updatedUser.Version))
+	render(c, http.StatusOK, updatedUser)
+}
+
+// NOTE - patch user handler
+// @Summary Patch a user
+// @Description Partially update a user with JSON merge-patch (RFC 7396). Absent members are left untouched, `subject_id: null` unlinks the subject
+// @Tags users
+// @Accept application/merge-patch+json,json,xml,application/msgpack,text/csv
+// @Produce json,xml,application/msgpack,text/csv
+// @Param id path int true "User ID"
+// @Param If-Match header string true "ETag of the version being patched"
+// @Param user body entity.UserPatch true "Merge patch document"
+// @Success 200 {object} entity.UserResponse
+// @Failure 412 {object} entity.ErrorResponse
+// @Failure 428 {object} entity.ErrorResponse
+// @Failure 404 {object} entity.ErrorResponse
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 409 {object} entity.ErrorResponse
+// @Failure 415 {object} entity.ErrorResponse
+// @Failure 422 {object} entity.ErrorResponse
+// @Failure 500 {object} entity.ErrorResponse
+// @Router /api/v1/users/{id} [patch]
+// @Router /api/v2/users/{id} [patch]
+func (h *UserHandler) PatchUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	versions, err := parseIfMatch(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var patch entity.UserPatch
+	if err := bindMergePatch(c, &patch); err != nil {
+		c.Error(err)
+		return
+	}
+
+	updatedUser, err := h.useCase.UpdateUser(c.Request.Context(), id, versions, patch)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", versionETag(updatedUser.Version))
+	render(c, http.StatusOK, updatedUser)
+}
+
+// NOTE - upload user avatar handler
+// @Summary Upload a user avatar
+// @Description Upload a JPEG, PNG or GIF avatar (max 5MB). The image is stripped of EXIF data, cropped square and stored with fixed size thumbnails
+// @Tags users
+// @Accept multipart/form-data
+// @Produce json,xml,application/msgpack,text/csv
+// @Param id path int true "User ID"
+// @Param avatar formData file true "Avatar image"
+// @Success 200 {object} entity.AvatarResponse
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 404 {object} entity.ErrorResponse
+// @Failure 413 {object} entity.ErrorResponse
+// @Failure 415 {object} entity.ErrorResponse
+// @Failure 500 {object} entity.ErrorResponse
+// @Router /api/v1/users/{id}/avatar [put]
+// @Router /api/v2/users/{id}/avatar [put]
+func (h *UserHandler) UploadAvatar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
 	}
 
-	updatedUser, err := h.useCase.UpdateUser(c, id, userUpdate)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAvatarUploadSize)
+
+	fileHeader, err := c.FormFile("avatar")
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.Error(entity.NewError(entity.ErrPayloadTooLarge, "avatar_too_large", "Avatar must be 5MB or smaller"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(entity.BadRequest("avatar_required", "avatar file is required").Localized("avatar_required.file"))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.Error(entity.BadRequest("invalid_body", "%s", err.Error()).Localized("invalid_body.unreadable"))
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.Error(entity.BadRequest("invalid_body", "%s", err.Error()).Localized("invalid_body.unreadable"))
+		return
+	}
+
+	avatar, err := h.useCase.UpdateAvatar(c.Request.Context(), id, data)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	render(c, http.StatusOK, avatar)
+}
+
+// NOTE - user lifecycle transition handler
+// @Summary Change a user lifecycle state
+// @Description Move a user to another lifecycle state. Suspending requires a reason and may set reactivate_at for automatic reactivation
+// @Description A suspended user can no longer log in or refresh, access tokens issued before stay valid until they expire, at most 15 minutes later
+// @Tags users
+// @Security BearerAuth
+// @Accept json,xml,application/msgpack,text/csv
+// @Produce json,xml,application/msgpack,text/csv
+// @Param id path int true "User ID"
+// @Param request body entity.TransitionUserStateRequest true "Target state"
+// @Success 200 {object} entity.UserResponse
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 401 {object} entity.ErrorResponse
+// @Failure 404 {object} entity.ErrorResponse
+// @Failure 409 {object} entity.ErrorResponse
+// @Failure 422 {object} entity.ErrorResponse
+// @Failure 500 {object} entity.ErrorResponse
+// @Router /api/v1/users/{id}/state [post]
+// @Router /api/v2/users/{id}/state [post]
+func (h *UserHandler) TransitionState(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	claims, err := middleware.Claims(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req entity.TransitionUserStateRequest
+	if err := bindBody(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	user, err := h.useCase.TransitionState(c.Request.Context(), id, req, &claims.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", versionETag(user.Version))
+	render(c, http.StatusOK, user)
+}
+
+// NOTE - user lifecycle history handler
+// @Summary Get user lifecycle history
+// @Description List every state transition of a user with who made it and when, newest first
+// @Tags users
+// @Accept json,xml,application/msgpack,text/csv
+// @Produce json,xml,application/msgpack,text/csv
+// @Param id path int true "User ID"
+// @Success 200 {array} entity.UserStateTransition
+// @Failure 400 {object} entity.ErrorResponse
+// @Failure 404 {object} entity.ErrorResponse
+// @Failure 500 {object} entity.ErrorResponse
+// @Router /api/v1/users/{id}/state-transitions [get]
+// @Router /api/v2/users/{id}/state-transitions [get]
+func (h *UserHandler) GetStateTransitions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	transitions, err := h.useCase.GetStateTransitions(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedUser)
+	render(c, http.StatusOK, transitions)
 }
 
 // NOTE - delete user handler
 // @Summary Delete a user
 // @Description Remove a user by ID
 // @Tags users
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack,text/csv
+// @Produce json,xml,application/msgpack,text/csv
 // @Param id path int true "User ID"
+// @Param If-Match header string true "ETag of the version being deleted"
 // @Success 204 "No Content"
+// @Failure 412 {object} entity.ErrorResponse
+// @Failure 428 {object} entity.ErrorResponse
 // @Failure 404 {object} entity.ErrorResponse
 // @Failure 400 {object} entity.ErrorResponse
 // @Failure 500 {object} entity.ErrorResponse
 // @Router /api/v1/users/delete/{id} [delete]
+// @Router /api/v2/users/{id} [delete]
 func (h *UserHandler) DeleteUser(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	versions, err := parseIfMatch(c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	err = h.useCase.DeleteUser(c, id)
+	err = h.useCase.DeleteUser(c.Request.Context(), id, versions)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -256,21 +547,36 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 // @Summary Clear cache of users
 // @Description Clear the cache of users
 // @Tags users
-// @Accept json
-// @Produce json
-// @Success 204 "No Content"
-// @Failure 404 {object} entity.ErrorResponse
-// @Failure 400 {object} entity.ErrorResponse
+// @Accept json,xml,application/msgpack,text/csv
+// @Produce json,xml,application/msgpack,text/csv
+// @Success 200 {object} entity.MessageResponse
 // @Failure 500 {object} entity.ErrorResponse
 // @Router /api/v1/users/clear-cache [delete]
 func (h *UserHandler) ClearUserCache(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	err := h.useCase.ClearUserCache(ctx)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear users caches"})
+		c.Error(err)
+		return
+	}
+
+	render(c, http.StatusOK, entity.MessageResponse{Message: "User caches cleared successfully"})
+}
+
+// NOTE - purge cache of users handler
+// @Summary Purge cache of users
+// @Description Clear the cache of users
+// @Tags users
+// @Produce json,xml,application/msgpack,text/csv
+// @Success 204 "No Content"
+// @Failure 500 {object} entity.ErrorResponse
+// @Router /api/v2/users/cache [delete]
+func (h *UserHandler) PurgeUserCache(c *gin.Context) {
+	if err := h.useCase.ClearUserCache(c.Request.Context()); err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "User caches cleared successfully"})
+	c.Status(http.StatusNoContent)
 }
@@ -0,0 +1,98 @@
+package entity
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Batch operations and the resources they can write.
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+
+	BatchResourceUser    = "user"
+	BatchResourceSubject = "subject"
+)
+
+// BatchRequest is an ordered list of writes that are applied together or not at all.
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations" binding:"required,min=1,max=100"`
+}
+
+// BatchOperation is a single write of a batch. `id`, and `subject_id` in a user body,
+// take either an ID or `$<ref>`: the ID of an earlier create of the batch with that ref.
+// Users are written with merge patch semantics and need the `version` they were read at.
+type BatchOperation struct {
+	Op       string          `json:"op" binding:"required,oneof=create update delete" example:"update"`
+	Resource string          `json:"resource" binding:"required,oneof=user subject" example:"user"`
+	Ref      string          `json:"ref,omitempty" binding:"omitempty,alphanum,max=64" example:"math"`
+	ID       BatchID         `json:"id,omitempty" swaggertype:"string" example:"12"`
+	Version  int             `json:"version,omitempty" binding:"omitempty,min=1" example:"3"`
+	Body     json.RawMessage `json:"body,omitempty" swaggertype:"object"`
+}
+
+// BatchID is an ID given as a number, or as `$<ref>` of an earlier create of the batch.
+type BatchID struct {
+	Value int
+	Ref   string
+}
+
+// NOTE - a JSON string is kept as the reference, it is checked once the whole batch is known
+func (id *BatchID) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &id.Ref)
+	}
+	return json.Unmarshal(data, &id.Value)
+}
+
+// NOTE - true when no ID was given
+func (id BatchID) IsZero() bool {
+	return id.Value == 0 && id.Ref == ""
+}
+
+// BatchStep is a checked batch operation, with the body decoded for its resource.
+// References are resolved by the repository, once the IDs of the creates are known.
+type BatchStep struct {
+	Op       string
+	Resource string
+	Ref      string
+	ID       BatchID
+	Version  int
+
+	User         User
+	UserPatch    UserPatch
+	Subject      Subject
+	SubjectPatch SubjectPatch
+	// ref of the subject a user is linked to, when the body gave `subject_id` as `$<ref>`
+	SubjectRef string
+}
+
+// BatchResult is the outcome of a single operation of a committed batch.
+type BatchResult struct {
+	Index    int         `json:"index" example:"0"`
+	Op       string      `json:"op" example:"create"`
+	Resource string      `json:"resource" example:"subject"`
+	Ref      string      `json:"ref,omitempty" example:"math"`
+	ID       int         `json:"id" example:"7"`
+	Status   int         `json:"status" example:"201"`
+	Data     interface{} `json:"data,omitempty" swaggertype:"object"`
+}
+
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// BatchOperationError is why one operation of a batch failed, nothing of the batch was written.
+type BatchOperationError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchOperationError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchOperationError) Unwrap() error {
+	return e.Err
+}
@@ -1,6 +1,144 @@
 package entity
 
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorResponse is the single error envelope every endpoint returns.
 type ErrorResponse struct {
-	// StatusCode int    `json:"status_code"`
-	Message string `json:"message"`
+	Code    string       `json:"code" example:"user_not_found"`
+	Message string       `json:"message" example:"user with ID: 5 not found"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes why a single request field was rejected.
+type FieldError struct {
+	Field   string `json:"field" example:"email"`
+	Code    string `json:"code" example:"email"`
+	Message string `json:"message" example:"must be a valid email address"`
+	// catalog message the response text is localized from, Message is used when empty
+	MessageID string                 `json:"-"`
+	Params    map[string]interface{} `json:"-"`
+}
+
+// Error kinds. Every domain error wraps exactly one of them, which decides the HTTP status.
+var (
+	ErrBadRequest           = errors.New("bad request")
+	ErrValidation           = errors.New("validation failed")
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrForbidden            = errors.New("forbidden")
+	ErrNotFound             = errors.New("not found")
+	ErrConflict             = errors.New("conflict")
+	ErrPreconditionFailed   = errors.New("precondition failed")
+	ErrPreconditionRequired = errors.New("precondition required")
+	ErrNotAcceptable        = errors.New("not acceptable")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	ErrPayloadTooLarge      = errors.New("payload too large")
+	ErrTooManyRequests      = errors.New("too many requests")
+	ErrTimeout              = errors.New("timeout")
+	ErrUnavailable          = errors.New("unavailable")
+)
+
+// Error is a domain error with a stable, machine-readable code. Message is the English text,
+// the error response carries it localized from the message catalogs.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Fields  []FieldError
+	// catalog message the response text is localized from, the code when empty
+	MessageID string
+	// named values the localized message interpolates, Count also picks the plural form
+	Params map[string]interface{}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
 }
+
+// NOTE - errors with the same kind and code are the same error, whatever values they carry
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind && t.Code == e.Code
+}
+
+// NOTE - copy of the error carrying a named value for its localized message, e.g. the ID of a missing user
+func (e *Error) With(name string, value interface{}) *Error {
+	copied := *e
+	copied.Params = make(map[string]interface{}, len(e.Params)+1)
+	for k, v := range e.Params {
+		copied.Params[k] = v
+	}
+	copied.Params[name] = value
+	return &copied
+}
+
+// NOTE - copy of the error localized from a more specific catalog message than its code, e.g. invalid_body.empty
+func (e *Error) Localized(messageID string) *Error {
+	copied := *e
+	copied.MessageID = messageID
+	return &copied
+}
+
+// NOTE - copy of the error with a more specific English message, e.g. the transition that was refused
+func (e *Error) Explain(format string, args ...interface{}) *Error {
+	copied := *e
+	copied.Message = e.Message + ": " + fmt.Sprintf(format, args...)
+	return &copied
+}
+
+// NOTE - catalog message the error response is localized from
+func (e *Error) LocalizationID() string {
+	if e.MessageID != "" {
+		return e.MessageID
+	}
+	return e.Code
+}
+
+// NOTE - builds a domain error of the given kind
+func NewError(kind error, code, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func BadRequest(code, format string, args ...interface{}) *Error {
+	return NewError(ErrBadRequest, code, format, args...)
+}
+
+func Validation(code, format string, args ...interface{}) *Error {
+	return NewError(ErrValidation, code, format, args...)
+}
+
+func Unauthorized(code, format string, args ...interface{}) *Error {
+	return NewError(ErrUnauthorized, code, format, args...)
+}
+
+func Forbidden(code, format string, args ...interface{}) *Error {
+	return NewError(ErrForbidden, code, format, args...)
+}
+
+func NotFound(code, format string, args ...interface{}) *Error {
+	return NewError(ErrNotFound, code, format, args...)
+}
+
+func Conflict(code, format string, args ...interface{}) *Error {
+	return NewError(ErrConflict, code, format, args...)
+}
+
+// NOTE - validation error listing every rejected field
+func InvalidFields(fields ...FieldError) *Error {
+	err := Validation("validation_failed", "request validation failed")
+	err.Fields = fields
+	return err
+}
+
+var (
+	ErrInvalidPatch    = Validation("invalid_patch", "invalid merge patch")
+	ErrVersionMismatch = NewError(ErrPreconditionFailed, "version_mismatch", "resource version does not match If-Match")
+	ErrVersionConflict = Conflict("version_conflict", "resource was changed by another request while it was written")
+	ErrMissingIfMatch  = NewError(ErrPreconditionRequired, "if_match_required", "If-Match header is required")
+)
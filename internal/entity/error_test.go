@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorsWithTheSameKindAndCodeAreTheSame(t *testing.T) {
+	notFound := NotFound("user_not_found", "user with ID: %d not found", 5).With("ID", 5)
+
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		is     bool
+	}{
+		{name: "same code other values", err: notFound, target: NotFound("user_not_found", "user not found"), is: true},
+		{name: "kind", err: notFound, target: ErrNotFound, is: true},
+		{name: "other code", err: notFound, target: NotFound("subject_not_found", "subject not found")},
+		{name: "other kind", err: notFound, target: ErrConflict},
+		{name: "wrapped", err: fmt.Errorf("operation 2: %w", ErrVersionConflict), target: ErrVersionConflict, is: true},
+		{name: "localized", err: ErrInvalidPatch.Localized("invalid_patch.null").Explain("status cannot be null"), target: ErrInvalidPatch, is: true},
+	}
+
+	for _, tt := range tests {
+		if got := errors.Is(tt.err, tt.target); got != tt.is {
+			t.Errorf("%s: errors.Is is %v, want %v", tt.name, got, tt.is)
+		}
+	}
+}
+
+func TestErrorCopiesLeaveTheSentinelAlone(t *testing.T) {
+	explained := ErrVersionMismatch.With("Version", 3).Explain("expected %d", 3)
+
+	if ErrVersionMismatch.Params != nil || ErrVersionMismatch.Message != "resource version does not match If-Match" {
+		t.Errorf("the sentinel changed to %+v", ErrVersionMismatch)
+	}
+	if explained.Params["Version"] != 3 || explained.Message != "resource version does not match If-Match: expected 3" {
+		t.Errorf("copy is %+v", explained)
+	}
+	if explained.LocalizationID() != "version_mismatch" || explained.Localized("version_mismatch.detail").LocalizationID() != "version_mismatch.detail" {
+		t.Errorf("localization id of %+v", explained)
+	}
+}
@@ -0,0 +1,100 @@
+package entity
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Resources a change event can be about.
+const (
+	EventResourceUser    = "user"
+	EventResourceSubject = "subject"
+)
+
+// Writes a change event reports, the event type is "<resource>.<action>".
+const (
+	EventActionCreated = "created"
+	EventActionUpdated = "updated"
+	EventActionDeleted = "deleted"
+)
+
+// ChangeEvent tells stream clients that a user or subject was written.
+type ChangeEvent struct {
+	// position in the event history, clients resume after it with Last-Event-ID
+	ID         string          `json:"id" example:"1760832000000-0"`
+	Type       string          `json:"type" example:"user.updated"`
+	Resource   string          `json:"resource" example:"user"`
+	ResourceID int             `json:"resource_id" example:"5"`
+	Data       json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	// school the resource belongs to, streams only carry the events of their own
+	TenantID int `json:"tenant_id" example:"1"`
+}
+
+// NOTE - event for a committed write, data is the resource as it is now and nil once deleted
+func NewChangeEvent(resource, action string, id int, data interface{}) ChangeEvent {
+	event := ChangeEvent{
+		Type:       resource + "." + action,
+		Resource:   resource,
+		ResourceID: id,
+		OccurredAt: time.Now(),
+	}
+	if data != nil {
+		event.Data, _ = json.Marshal(data)
+	}
+	return event
+}
+
+// NOTE - reports whether the event comes after the event with id in the history
+func (e ChangeEvent) After(id string) bool {
+	ms, seq, ok := parseEventID(e.ID)
+	afterMS, afterSeq, afterOK := parseEventID(id)
+	if !ok || !afterOK {
+		return true
+	}
+	return ms > afterMS || (ms == afterMS && seq > afterSeq)
+}
+
+// NOTE - reports whether id has the "<milliseconds>-<sequence>" shape of an event id
+func ValidEventID(id string) bool {
+	_, _, ok := parseEventID(id)
+	return ok
+}
+
+func parseEventID(id string) (uint64, uint64, bool) {
+	msPart, seqPart, found := strings.Cut(id, "-")
+	if !found {
+		return 0, 0, false
+	}
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return ms, seq, true
+}
+
+// EventFilter picks the events a stream client wants, only ever of its own tenant.
+// Without Resources and ResourceID every event of the tenant matches.
+type EventFilter struct {
+	TenantID   int
+	Resources  []string
+	ResourceID int
+}
+
+// NOTE - reports whether the event passes the filter
+func (f EventFilter) Matches(event ChangeEvent) bool {
+	if event.TenantID != f.TenantID {
+		return false
+	}
+	if len(f.Resources) > 0 && !slices.Contains(f.Resources, event.Resource) {
+		return false
+	}
+	return f.ResourceID == 0 || f.ResourceID == event.ResourceID
+}
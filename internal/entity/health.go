@@ -0,0 +1,25 @@
+package entity
+
+// Overall health of the service.
+const (
+	HealthOK          = "ok"
+	HealthDegraded    = "degraded"
+	HealthUnavailable = "unavailable"
+	HealthDraining    = "draining"
+)
+
+// HealthReport is the readiness of the service and of every dependency it was checked against.
+type HealthReport struct {
+	Status       string             `json:"status" example:"degraded"`
+	Dependencies []DependencyHealth `json:"dependencies,omitempty"`
+}
+
+// DependencyHealth is the outcome of pinging one dependency.
+type DependencyHealth struct {
+	Name string `json:"name" example:"redis"`
+	// a critical dependency being down makes the service not ready, any other only degrades it
+	Critical  bool    `json:"critical" example:"false"`
+	Status    string  `json:"status" example:"down"`
+	LatencyMS float64 `json:"latency_ms" example:"1.25"`
+	Error     string  `json:"error,omitempty" example:"dial tcp 127.0.0.1:6379: connect: connection refused"`
+}
@@ -1,11 +1,25 @@
 package entity
 
 type LoginRequest struct {
-	Name     string `json:"name"`
-	Password string `json:"password"`
+	Name     string `json:"name" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 type Tokens struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 }
+
+// ProfileResponse is the authenticated user.
+type ProfileResponse struct {
+	User *User `json:"user"`
+}
+
+var (
+	ErrMissingAuthorization = Unauthorized("missing_authorization", "Authorization header is missing")
+	ErrInvalidToken         = Unauthorized("invalid_token", "invalid token or expired token")
+)
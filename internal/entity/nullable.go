@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Nullable keeps track of whether a JSON member was absent, explicitly null
+// or set to a value, which is what RFC 7396 merge-patch needs to tell apart.
+type Nullable[T any] struct {
+	Present bool
+	Null    bool
+	Value   T
+}
+
+// NOTE - only called by encoding/json when the member is present in the body
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Present = true
+
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		n.Null = true
+		return nil
+	}
+
+	return json.Unmarshal(data, &n.Value)
+}
+
+// NOTE - true when the member was sent with a non-null value
+func (n Nullable[T]) IsSet() bool {
+	return n.Present && !n.Null
+}
+
+// NOTE - builds a present, non-null value
+func NewNullable[T any](value T) Nullable[T] {
+	return Nullable[T]{Present: true, Value: value}
+}
+
+// NOTE - adds the member to a merge patch document when it is present, as null when it clears the value
+func (n Nullable[T]) addTo(document map[string]interface{}, member string) {
+	switch {
+	case n.Null:
+		document[member] = nil
+	case n.Present:
+		document[member] = n.Value
+	}
+}
@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUserPatchTellsAbsentNullAndFalseApart(t *testing.T) {
+	tests := []struct {
+		body   string
+		status Nullable[bool]
+		// subject_id was sent null
+		unlink bool
+	}{
+		{body: `{}`},
+		{body: `{"status":false}`, status: Nullable[bool]{Present: true}},
+		{body: `{"status":true}`, status: NewNullable(true)},
+		{body: `{"status":null}`, status: Nullable[bool]{Present: true, Null: true}},
+		{body: `{"subject_id":null}`, unlink: true},
+	}
+
+	for _, tt := range tests {
+		var patch UserPatch
+		if err := json.Unmarshal([]byte(tt.body), &patch); err != nil {
+			t.Fatalf("%s: %v", tt.body, err)
+		}
+		if patch.Status != tt.status {
+			t.Errorf("%s: status is %+v, want %+v", tt.body, patch.Status, tt.status)
+		}
+		if patch.SubjectID.Null != tt.unlink || patch.Name.Present {
+			t.Errorf("%s: decoded %+v", tt.body, patch)
+		}
+	}
+}
+
+func TestUserPatchMarshalsOnlyPresentMembers(t *testing.T) {
+	patch := UserPatch{
+		Name:      NewNullable("Noy"),
+		SubjectID: Nullable[int]{Present: true, Null: true},
+		Status:    NewNullable(false),
+	}
+
+	body, err := json.Marshal(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":"Noy","status":false,"subject_id":null}`; string(body) != want {
+		t.Errorf("got %s, want %s", body, want)
+	}
+}
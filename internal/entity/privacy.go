@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type PrivacyRequestType string
+
+const (
+	PrivacyRequestExport  PrivacyRequestType = "EXPORT"
+	PrivacyRequestErasure PrivacyRequestType = "ERASURE"
+)
+
+// NOTE - prev_hash of the first record in the chain
+var PrivacyGenesisHash = strings.Repeat("0", 64)
+
+var (
+	ErrAlreadyErased        = Conflict("already_erased", "user data has already been erased")
+	ErrPrivacyChainConflict = Conflict("privacy_log_conflict", "privacy log was appended concurrently")
+)
+
+type PrivacyRecord struct {
+	ID          int                `json:"id"`
+	UserID      int                `json:"user_id"`
+	Type        PrivacyRequestType `json:"type"`
+	ActorID     *int               `json:"actor_id"`
+	Details     string             `json:"details"`
+	CompletedAt time.Time          `json:"completed_at"`
+	PrevHash    string             `json:"prev_hash"`
+	Hash        string             `json:"hash"`
+}
+
+// NOTE - sha256 over every field of the record and the hash it links to
+func (r PrivacyRecord) ComputeHash() string {
+	actor := "-"
+	if r.ActorID != nil {
+		actor = fmt.Sprint(*r.ActorID)
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%s|%s|%s|%s|%s",
+		r.UserID, r.Type, actor, r.CompletedAt.UTC().Format(time.RFC3339Nano), r.Details, r.PrevHash,
+	)))
+	return hex.EncodeToString(sum[:])
+}
+
+type PrivacyChainStatus struct {
+	Valid         bool `json:"valid"`
+	Records       int  `json:"records"`
+	FirstBrokenID *int `json:"first_broken_id,omitempty"`
+}
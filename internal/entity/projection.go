@@ -0,0 +1,105 @@
+package entity
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Relations a read can include.
+const (
+	IncludeSubject = "subject"
+	IncludeUsers   = "users"
+)
+
+// Fields a read can be projected to, by their json name.
+var (
+	UserFields = []string{
+		"id", "name", "email", "avatar_url", "subject_id", "status", "state", "suspended_reason",
+		"reactivate_at", "version", "erased_at", "day", "month", "year", "created_at", "updated_at",
+	}
+	SubjectFields = []string{"id", "name", "status", "created_at", "updated_at"}
+)
+
+// Projection is the sparse fieldset and the relations a read asked for.
+// Without fields every field is read, relations are only read when included.
+type Projection struct {
+	Fields  []string
+	Include []string
+}
+
+// NOTE - parses the comma separated `fields` and `include` query values against what the resource offers
+func ParseProjection(fields, include string, selectable, includable []string) (Projection, error) {
+	var projection Projection
+	var errs []FieldError
+
+	for _, field := range splitList(fields) {
+		if !slices.Contains(selectable, field) {
+			options := strings.Join(selectable, ", ")
+			errs = append(errs, FieldError{
+				Field:     "fields",
+				Code:      "unknown_field",
+				Message:   fmt.Sprintf("%s is not a field, use one of %s", field, options),
+				MessageID: "projection.unknown_field",
+				Params:    map[string]interface{}{"Name": field, "Options": options},
+			})
+			continue
+		}
+		projection.Fields = append(projection.Fields, field)
+	}
+
+	for _, relation := range splitList(include) {
+		if !slices.Contains(includable, relation) {
+			options := strings.Join(includable, ", ")
+			errs = append(errs, FieldError{
+				Field:     "include",
+				Code:      "unknown_relation",
+				Message:   fmt.Sprintf("%s cannot be included, use one of %s", relation, options),
+				MessageID: "projection.unknown_relation",
+				Params:    map[string]interface{}{"Name": relation, "Options": options},
+			})
+			continue
+		}
+		projection.Include = append(projection.Include, relation)
+	}
+
+	if len(errs) > 0 {
+		return Projection{}, InvalidFields(errs...)
+	}
+
+	return projection, nil
+}
+
+// NOTE - true when the field is part of the projection
+func (p Projection) Selects(field string) bool {
+	return len(p.Fields) == 0 || slices.Contains(p.Fields, field)
+}
+
+// NOTE - true when the relation was asked for
+func (p Projection) Includes(relation string) bool {
+	return slices.Contains(p.Include, relation)
+}
+
+// NOTE - stable text form for cache keys, empty for a projection of every field without relations
+func (p Projection) Key() string {
+	var parts []string
+	if len(p.Fields) > 0 {
+		parts = append(parts, "fields="+strings.Join(p.Fields, ","))
+	}
+	if len(p.Include) > 0 {
+		parts = append(parts, "include="+strings.Join(p.Include, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+// NOTE - sorted, deduplicated members of a comma separated list, so equal projections share a cache key
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	slices.Sort(items)
+	return slices.Compact(items)
+}
@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseProjection(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  string
+		include string
+		key     string
+		invalid []string
+	}{
+		{name: "everything"},
+		{name: "fields", fields: "name, id", key: "fields=id,name"},
+		{name: "same fields in another order", fields: "id,name,id,", key: "fields=id,name"},
+		{name: "include", include: "subject", key: "include=subject"},
+		{name: "both", fields: "email", include: "subject", key: "fields=email;include=subject"},
+		{name: "unknown", fields: "id,password", include: "users,subject", invalid: []string{"fields:unknown_field", "include:unknown_relation"}},
+	}
+
+	for _, tt := range tests {
+		projection, err := ParseProjection(tt.fields, tt.include, UserFields, []string{IncludeSubject})
+		if len(tt.invalid) > 0 {
+			var domainErr *Error
+			if !errors.As(err, &domainErr) || !errors.Is(err, ErrValidation) || len(domainErr.Fields) != len(tt.invalid) {
+				t.Errorf("%s: got %v, want %v", tt.name, err, tt.invalid)
+				continue
+			}
+			for i, field := range domainErr.Fields {
+				if field.Field+":"+field.Code != tt.invalid[i] {
+					t.Errorf("%s: field error %d is %+v, want %s", tt.name, i, field, tt.invalid[i])
+				}
+			}
+			continue
+		}
+		if err != nil || projection.Key() != tt.key {
+			t.Errorf("%s: got key %q, %v, want %q", tt.name, projection.Key(), err, tt.key)
+		}
+	}
+}
+
+func TestProjectionSelectsAndIncludes(t *testing.T) {
+	all := Projection{}
+	sparse := Projection{Fields: []string{"id", "name"}, Include: []string{IncludeSubject}}
+
+	if !all.Selects("email") || all.Includes(IncludeSubject) {
+		t.Errorf("an empty projection selects every field and includes no relation")
+	}
+	if !sparse.Selects("name") || sparse.Selects("email") || !sparse.Includes(IncludeSubject) || sparse.Includes(IncludeUsers) {
+		t.Errorf("projection %+v selects or includes the wrong members", sparse)
+	}
+}
@@ -0,0 +1,14 @@
+package entity
+
+// MessageResponse is the body of an action that has nothing to return but a confirmation.
+type MessageResponse struct {
+	Message string `json:"message" example:"User caches cleared successfully"`
+}
+
+// PageMeta describes the page of a paginated list.
+type PageMeta struct {
+	Page       int `json:"page"`
+	Limit      int `json:"limit"`
+	Total      int `json:"total"`
+	TotalPages int `json:"totalPages"`
+}
@@ -1,6 +1,9 @@
 package entity
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Subject struct {
 	ID        int       `json:"id"`
@@ -8,14 +11,28 @@ type Subject struct {
 	Status    bool      `json:"status"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
-	User      []User    `json:"user"`
+	User      []User    `json:"user,omitempty"`
 }
 
 type CreateSubjectRequest struct {
-	Name string `json:"name,omitempty"`
+	Name string `json:"name" binding:"required,min=2,max=100"`
 }
 
 type UpdateSubjectRequest struct {
-	Name   string `json:"name,omitempty"`
-	Status bool   `json:"status,omitempty"`
+	Name   string `json:"name,omitempty" binding:"omitempty,min=2,max=100"`
+	Status *bool  `json:"status,omitempty"`
+}
+
+// SubjectPatch is a JSON merge-patch (RFC 7396) document for a subject.
+type SubjectPatch struct {
+	Name   Nullable[string] `json:"name" swaggertype:"string" binding:"omitempty,min=2,max=100"`
+	Status Nullable[bool]   `json:"status" swaggertype:"boolean"`
+}
+
+// NOTE - the merge patch document, absent members are left out
+func (p SubjectPatch) MarshalJSON() ([]byte, error) {
+	document := map[string]interface{}{}
+	p.Name.addTo(document, "name")
+	p.Status.addTo(document, "status")
+	return json.Marshal(document)
 }
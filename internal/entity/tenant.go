@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+// Tenant is a school. Users and subjects belong to exactly one and are only ever visible inside it.
+type Tenant struct {
+	ID        int       `json:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	Host      string    `json:"host,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TenantCredentials are what a request can name its tenant with besides its access token, whose claims
+// travel in the context. Every one that is set has to name the same tenant.
+type TenantCredentials struct {
+	APIKey string
+	// host the request was sent to, with or without port
+	Host string
+}
+
+var (
+	ErrTenantRequired = Unauthorized("tenant_required", "the request does not name a school, send an X-API-Key or use the host of the school")
+	ErrTenantMismatch = Forbidden("tenant_mismatch", "the token, API key and host belong to different schools")
+	ErrInvalidAPIKey  = Unauthorized("invalid_api_key", "invalid API key")
+	// every token is issued inside a school, one without its tenant_id claim cannot be bound to any
+	ErrTokenWithoutTenant = Unauthorized("token_without_tenant", "the token does not name a school, log in again")
+)
+
+type tenantKey struct{}
+
+// NOTE - returns a copy of ctx scoped to the tenant, every query made with it only sees that tenant's data
+func WithTenant(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// NOTE - tenant ctx is scoped to, false when the request did not name one
+func TenantID(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(tenantKey{}).(int)
+	return id, ok && id != 0
+}
@@ -1,56 +1,95 @@
 package entity
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type User struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Password  string    `json:"password"`
-	SubjectID int       `json:"subject_id,omitempty"`
-	Status    bool      `json:"status"`
-	Day       int       `json:"day"`
-	Month     int       `json:"month"`
-	Year      int       `json:"year"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              int        `json:"id"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	Password        string     `json:"password"`
+	AvatarURL       string     `json:"avatar_url,omitempty"`
+	SubjectID       int        `json:"subject_id,omitempty"`
+	Status          bool       `json:"status"`
+	State           UserState  `json:"state"`
+	SuspendedReason string     `json:"suspended_reason,omitempty"`
+	ReactivateAt    *time.Time `json:"reactivate_at,omitempty"`
+	Version         int        `json:"version"`
+	ErasedAt        *time.Time `json:"erased_at,omitempty"`
+	Day             int        `json:"day"`
+	Month           int        `json:"month"`
+	Year            int        `json:"year"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	Subject         *Subject   `json:"subject,omitempty"`
 }
 
 type CreateUserRequest struct {
-	Name      string `json:"name"`
-	Email     string `json:"email"`
-	Password  string `json:"password"`
-	SubjectID int    `json:"subject_id,omitempty"`
+	Name      string `json:"name" binding:"required,min=2,max=100"`
+	Email     string `json:"email" binding:"required,email,max=255,email_unique"`
+	Password  string `json:"password" binding:"required,min=8,max=72"`
+	SubjectID int    `json:"subject_id,omitempty" binding:"omitempty,min=1,subject_exists"`
 }
 
 type UpdateUserRequest struct {
-	Name      string `json:"name,omitempty"`
-	Email     string `json:"email,omitempty"`
-	Password  string `json:"password,omitempty"`
-	SubjectID int    `json:"subject_id,omitempty"`
-	Status    bool   `json:"status,omitempty"`
+	Name      string `json:"name,omitempty" binding:"omitempty,min=2,max=100"`
+	Email     string `json:"email,omitempty" binding:"omitempty,email,max=255"`
+	Password  string `json:"password,omitempty" binding:"omitempty,min=8,max=72"`
+	SubjectID int    `json:"subject_id,omitempty" binding:"omitempty,min=1,subject_exists"`
+	Status    *bool  `json:"status,omitempty"`
+}
+
+// UserPatch is a JSON merge-patch (RFC 7396) document for a user. Members
+// that are absent are left untouched and `subject_id: null` unlinks the subject.
+type UserPatch struct {
+	Name      Nullable[string] `json:"name" swaggertype:"string" binding:"omitempty,min=2,max=100"`
+	Email     Nullable[string] `json:"email" swaggertype:"string" binding:"omitempty,email,max=255"`
+	Password  Nullable[string] `json:"password" swaggertype:"string" binding:"omitempty,min=8,max=72"`
+	SubjectID Nullable[int]    `json:"subject_id" swaggertype:"integer" binding:"omitempty,min=1,subject_exists"`
+	Status    Nullable[bool]   `json:"status" swaggertype:"boolean"`
+}
+
+// NOTE - the merge patch document, absent members are left out
+func (p UserPatch) MarshalJSON() ([]byte, error) {
+	document := map[string]interface{}{}
+	p.Name.addTo(document, "name")
+	p.Email.addTo(document, "email")
+	p.Password.addTo(document, "password")
+	p.SubjectID.addTo(document, "subject_id")
+	p.Status.addTo(document, "status")
+	return json.Marshal(document)
 }
 
 type UserResponse struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Password  string    `json:"password"`
-	SubjectID int       `json:"subject_id,omitempty"`
-	Status    bool      `json:"status"`
-	Day       int       `json:"day"`
-	Month     int       `json:"month"`
-	Year      int       `json:"year"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              int        `json:"id"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	Password        string     `json:"password"`
+	AvatarURL       string     `json:"avatar_url,omitempty"`
+	SubjectID       int        `json:"subject_id,omitempty"`
+	Status          bool       `json:"status"`
+	State           UserState  `json:"state"`
+	SuspendedReason string     `json:"suspended_reason,omitempty"`
+	ReactivateAt    *time.Time `json:"reactivate_at,omitempty"`
+	Version         int        `json:"version"`
+	ErasedAt        *time.Time `json:"erased_at,omitempty"`
+	Day             int        `json:"day"`
+	Month           int        `json:"month"`
+	Year            int        `json:"year"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	Subject         *Subject   `json:"subject,omitempty"`
 }
 
+type AvatarResponse struct {
+	URL        string            `json:"url"`
+	Thumbnails map[string]string `json:"thumbnails"`
+}
+
+// UserListResponse is a page of users. Data holds the users, projected to the requested fields.
 type UserListResponse struct {
-	Data []UserResponse `json:"data"`
-	Meta struct {
-		Limit      int `json:"limit"`
-		Page       int `json:"page"`
-		Total      int `json:"total"`
-		TotalPages int `json:"totalPages"`
-	} `json:"meta"`
-}
\ No newline at end of file
+	Data interface{} `json:"data" swaggertype:"array,object"`
+	Meta PageMeta    `json:"meta"`
+}
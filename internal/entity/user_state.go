@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+type UserState string
+
+const (
+	UserStatePending   UserState = "PENDING"
+	UserStateActive    UserState = "ACTIVE"
+	UserStateSuspended UserState = "SUSPENDED"
+	UserStateGraduated UserState = "GRADUATED"
+)
+
+// NOTE - allowed lifecycle transitions, graduated accounts are final
+var userStateTransitions = map[UserState][]UserState{
+	UserStatePending:   {UserStateActive, UserStateSuspended},
+	UserStateActive:    {UserStateSuspended, UserStateGraduated},
+	UserStateSuspended: {UserStateActive, UserStateGraduated},
+	UserStateGraduated: {},
+}
+
+var (
+	ErrInvalidStateTransition = Conflict("invalid_state_transition", "invalid user state transition")
+	ErrAccountNotActive       = Forbidden("account_not_active", "account is not active")
+)
+
+// NOTE - reports whether the value is a known user state
+func (s UserState) IsValid() bool {
+	_, ok := userStateTransitions[s]
+	return ok
+}
+
+// NOTE - states are matched case-insensitively on input
+func (s *UserState) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*s = UserState(strings.ToUpper(value))
+	return nil
+}
+
+// NOTE - reports whether a user in state s may move to next
+func (s UserState) CanTransitionTo(next UserState) bool {
+	for _, allowed := range userStateTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+type UserStateTransition struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	FromState UserState `json:"from_state"`
+	ToState   UserState `json:"to_state"`
+	Reason    string    `json:"reason,omitempty"`
+	ActorID   *int      `json:"actor_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TransitionUserStateRequest struct {
+	State        UserState  `json:"state" binding:"required,user_state"`
+	Reason       string     `json:"reason,omitempty" binding:"required_if=State SUSPENDED,max=500"`
+	ReactivateAt *time.Time `json:"reactivate_at,omitempty" binding:"excluded_unless=State SUSPENDED,omitempty,future"`
+}
@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUserStateTransitions(t *testing.T) {
+	tests := []struct {
+		from, to UserState
+		allowed  bool
+	}{
+		{from: UserStatePending, to: UserStateActive, allowed: true},
+		{from: UserStatePending, to: UserStateSuspended, allowed: true},
+		{from: UserStatePending, to: UserStateGraduated},
+		{from: UserStateActive, to: UserStateSuspended, allowed: true},
+		{from: UserStateActive, to: UserStateGraduated, allowed: true},
+		{from: UserStateActive, to: UserStatePending},
+		{from: UserStateActive, to: UserStateActive},
+		{from: UserStateSuspended, to: UserStateActive, allowed: true},
+		{from: UserStateSuspended, to: UserStateGraduated, allowed: true},
+		{from: UserStateSuspended, to: UserStatePending},
+		{from: UserStateGraduated, to: UserStateActive},
+		{from: UserStateGraduated, to: UserStateSuspended},
+		{from: UserState("DELETED"), to: UserStateActive},
+	}
+
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.allowed {
+			t.Errorf("%s to %s: allowed is %v, want %v", tt.from, tt.to, got, tt.allowed)
+		}
+	}
+}
+
+func TestUserStateIsReadCaseInsensitively(t *testing.T) {
+	var req TransitionUserStateRequest
+	if err := json.Unmarshal([]byte(`{"state":"suspended","reason":"late fees"}`), &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.State != UserStateSuspended || !req.State.IsValid() {
+		t.Errorf("state is %q", req.State)
+	}
+	if UserState("DELETED").IsValid() {
+		t.Error("an unknown state is valid")
+	}
+}
@@ -0,0 +1,507 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sample-project/internal/config/cache"
+	"sample-project/internal/entity"
+	"sample-project/internal/utils"
+	"sample-project/prisma/db"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NOTE - batch repository interface
+type BatchRepository interface {
+	ExecuteBatch(ctx context.Context, steps []entity.BatchStep) ([]entity.BatchResult, error)
+}
+
+// NOTE - batch repository struct. Batches are written through pool, a prisma transaction is sent
+// as a whole and cannot look at what a write matched before the next one runs
+type batchRepository struct {
+	client *db.PrismaClient
+	pool   *pgxpool.Pool
+}
+
+// NOTE - new batch repository
+func NewBatchRepository(client *db.PrismaClient, pool *pgxpool.Pool) BatchRepository {
+	return &batchRepository{client: client, pool: pool}
+}
+
+// change event action of every batch operation
+var batchEventAction = map[string]string{
+	entity.BatchOpCreate: entity.EventActionCreated,
+	entity.BatchOpUpdate: entity.EventActionUpdated,
+	entity.BatchOpDelete: entity.EventActionDeleted,
+}
+
+// NOTE - runs every step in one interactive transaction. Creates get their IDs reserved up front,
+// which is what lets later steps reference them. A step whose record changed or went away since
+// checkTargets read it fails the transaction with the error of that step
+func (r *batchRepository) ExecuteBatch(ctx context.Context, steps []entity.BatchStep) ([]entity.BatchResult, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.checkTargets(ctx, tenantID, steps); err != nil {
+		return nil, err
+	}
+
+	ids, refs, err := r.resolveIDs(ctx, steps)
+	if err != nil {
+		return nil, err
+	}
+
+	err = pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		for i, step := range steps {
+			if err := r.applyStep(ctx, tx, step, tenantID, ids[i], refs); err != nil {
+				return &entity.BatchOperationError{Index: i, Err: err}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	users, subjects, err := r.readWritten(ctx, tenantID, steps, ids)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read the records a batch wrote", "error", err)
+	}
+
+	results := make([]entity.BatchResult, 0, len(steps))
+	for i, step := range steps {
+		result := entity.BatchResult{
+			Index:    i,
+			Op:       step.Op,
+			Resource: step.Resource,
+			Ref:      step.Ref,
+			ID:       ids[i],
+			Status:   http.StatusOK,
+		}
+		switch step.Op {
+		case entity.BatchOpCreate:
+			result.Status = http.StatusCreated
+		case entity.BatchOpDelete:
+			result.Status = http.StatusNoContent
+		}
+		if user, ok := users[ids[i]]; ok && step.Resource == entity.BatchResourceUser {
+			result.Data = user
+		}
+		if subject, ok := subjects[ids[i]]; ok && step.Resource == entity.BatchResourceSubject {
+			result.Data = subject
+		}
+		results = append(results, result)
+	}
+
+	purgeBatchCaches(ctx, results)
+	for _, result := range results {
+		if user, ok := result.Data.(*entity.User); ok {
+			publishChange(ctx, userEvent(batchEventAction[result.Op], *user))
+			continue
+		}
+		publishChange(ctx, entity.NewChangeEvent(result.Resource, batchEventAction[result.Op], result.ID, result.Data))
+	}
+
+	return results, nil
+}
+
+// NOTE - the ID every step writes and the ID behind every create ref. Creates get
+// a fresh one from the sequence of their table
+func (r *batchRepository) resolveIDs(ctx context.Context, steps []entity.BatchStep) ([]int, map[string]int, error) {
+	creates := map[string]int{}
+	for _, step := range steps {
+		if step.Op == entity.BatchOpCreate {
+			creates[step.Resource]++
+		}
+	}
+
+	reserved := map[string][]int{}
+	for resource, count := range creates {
+		ids, err := r.reserveIDs(ctx, batchTable(resource), count)
+		if err != nil {
+			return nil, nil, err
+		}
+		reserved[resource] = ids
+	}
+
+	refs := map[string]int{}
+	ids := make([]int, 0, len(steps))
+	for _, step := range steps {
+		id := step.ID.Value
+		switch {
+		case step.Op == entity.BatchOpCreate:
+			id, reserved[step.Resource] = reserved[step.Resource][0], reserved[step.Resource][1:]
+			if step.Ref != "" {
+				refs[step.Ref] = id
+			}
+		case step.ID.Ref != "":
+			id = refs[step.ID.Ref]
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, refs, nil
+}
+
+// NOTE - takes count values off the ID sequence of table. A rolled back batch leaves a gap,
+// just like a failed insert does
+func (r *batchRepository) reserveIDs(ctx context.Context, table string, count int) ([]int, error) {
+	var rows []struct {
+		ID int `json:"id"`
+	}
+	err := r.client.Prisma.QueryRaw(
+		`SELECT nextval(pg_get_serial_sequence($1, 'id'))::int AS id FROM generate_series(1, $2)`,
+		table, count,
+	).Exec(ctx, &rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve %s ids: %w", table, err)
+	}
+	if len(rows) != count {
+		return nil, fmt.Errorf("failed to reserve %s ids: got %d of %d", table, len(rows), count)
+	}
+
+	ids := make([]int, 0, count)
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	return ids, nil
+}
+
+// NOTE - reports missing records and stale versions per step before anything is written.
+// applyStep checks them again, a record changed in between fails the whole batch.
+// Records and subjects of other tenants are reported missing, a batch never reaches into another school
+func (r *batchRepository) checkTargets(ctx context.Context, tenantID int, steps []entity.BatchStep) error {
+	var userIDs, subjectIDs []int
+	for _, step := range steps {
+		if subjectID := linkedSubjectID(step); subjectID != 0 {
+			subjectIDs = append(subjectIDs, subjectID)
+		}
+		if step.ID.Value == 0 {
+			continue
+		}
+		if step.Resource == entity.BatchResourceUser {
+			userIDs = append(userIDs, step.ID.Value)
+		} else {
+			subjectIDs = append(subjectIDs, step.ID.Value)
+		}
+	}
+
+	versions := map[int]int{}
+	if len(userIDs) > 0 {
+		users, err := r.client.User.FindMany(db.User.TenantID.Equals(tenantID), db.User.ID.In(userIDs)).Exec(ctx)
+		if err != nil {
+			return translateError(err, "user")
+		}
+		for _, u := range users {
+			versions[u.ID] = u.Version
+		}
+	}
+
+	subjects := map[int]bool{}
+	if len(subjectIDs) > 0 {
+		found, err := r.client.Subject.FindMany(db.Subject.TenantID.Equals(tenantID), db.Subject.ID.In(subjectIDs)).Exec(ctx)
+		if err != nil {
+			return translateError(err, "subject")
+		}
+		for _, s := range found {
+			subjects[s.ID] = true
+		}
+	}
+
+	var failures []error
+	for i, step := range steps {
+		if subjectID := linkedSubjectID(step); subjectID != 0 && !subjects[subjectID] {
+			failures = append(failures, &entity.BatchOperationError{Index: i, Err: notFoundByID("subject", subjectID)})
+			continue
+		}
+
+		id := step.ID.Value
+		if id == 0 {
+			continue
+		}
+
+		if step.Resource == entity.BatchResourceSubject {
+			if !subjects[id] {
+				failures = append(failures, &entity.BatchOperationError{Index: i, Err: notFoundByID("subject", id)})
+			}
+			continue
+		}
+
+		version, ok := versions[id]
+		switch {
+		case !ok:
+			failures = append(failures, &entity.BatchOperationError{Index: i, Err: notFoundByID("user", id)})
+		case step.Version != 0 && step.Version != version:
+			failures = append(failures, &entity.BatchOperationError{Index: i, Err: entity.ErrVersionMismatch})
+		}
+	}
+
+	return errors.Join(failures...)
+}
+
+// NOTE - existing subject a user step links by id, 0 when it links none or one created by the batch
+func linkedSubjectID(step entity.BatchStep) int {
+	if step.Resource != entity.BatchResourceUser || step.SubjectRef != "" {
+		return 0
+	}
+	if step.Op == entity.BatchOpCreate {
+		return step.User.SubjectID
+	}
+	if step.Op == entity.BatchOpUpdate && step.UserPatch.SubjectID.IsSet() {
+		return step.UserPatch.SubjectID.Value
+	}
+	return 0
+}
+
+// NOTE - writes a step inside the batch transaction. A versioned user write that matches no row
+// fails with ErrVersionConflict, or not found when the user is gone
+func (r *batchRepository) applyStep(ctx context.Context, tx pgx.Tx, step entity.BatchStep, tenantID, id int, refs map[string]int) error {
+	if step.Resource == entity.BatchResourceSubject {
+		return r.applySubjectStep(ctx, tx, step, tenantID, id)
+	}
+
+	subjectID := step.User.SubjectID
+	if step.SubjectRef != "" {
+		subjectID = refs[step.SubjectRef]
+	}
+
+	switch step.Op {
+	case entity.BatchOpCreate:
+		hashedPassword, err := utils.HashPassword(step.User.Password)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %v", err)
+		}
+
+		var subject interface{}
+		if subjectID != 0 {
+			subject = subjectID
+		}
+		currentTime := utils.FormatToVientianeTime(time.Now())
+		_, err = tx.Exec(ctx,
+			`INSERT INTO users (id, name, email, password, status, subject_id, day, month, year, created_at, updated_at, tenant_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10, $11)`,
+			id, step.User.Name, step.User.Email, hashedPassword, step.User.Status, subject,
+			currentTime.Day(), int(currentTime.Month()), currentTime.Year(), currentTime, tenantID,
+		)
+		return translateStepError(err, step.Resource)
+
+	case entity.BatchOpUpdate:
+		var set assignments
+		if step.UserPatch.Name.IsSet() {
+			set.add("name", step.UserPatch.Name.Value)
+		}
+		if step.UserPatch.Email.IsSet() {
+			set.add("email", step.UserPatch.Email.Value)
+		}
+		if step.UserPatch.Password.IsSet() {
+			hashedPassword, err := utils.HashPassword(step.UserPatch.Password.Value)
+			if err != nil {
+				return fmt.Errorf("failed to hash password: %v", err)
+			}
+			set.add("password", hashedPassword)
+		}
+		if step.UserPatch.Status.IsSet() {
+			set.add("status", step.UserPatch.Status.Value)
+		}
+		if step.UserPatch.SubjectID.Null {
+			set.add("subject_id", nil)
+		} else if step.UserPatch.SubjectID.IsSet() || step.SubjectRef != "" {
+			if step.UserPatch.SubjectID.IsSet() {
+				subjectID = step.UserPatch.SubjectID.Value
+			}
+			set.add("subject_id", subjectID)
+		}
+		set.add("updated_at", utils.FormatToVientianeTime(time.Now()))
+
+		args := append(set.args, id, tenantID, step.Version)
+		n := len(set.args)
+		tag, err := tx.Exec(ctx, fmt.Sprintf(
+			`UPDATE users SET %s, version = version + 1 WHERE id = $%d AND tenant_id = $%d AND ($%d = 0 OR version = $%d)`,
+			strings.Join(set.columns, ", "), n+1, n+2, n+3, n+3,
+		), args...)
+		if err != nil {
+			return translateStepError(err, step.Resource)
+		}
+		if tag.RowsAffected() == 0 {
+			return missingOrChanged(ctx, tx, id, tenantID, step.Version)
+		}
+		return nil
+
+	default:
+		tag, err := tx.Exec(ctx,
+			`DELETE FROM users WHERE id = $1 AND tenant_id = $2 AND ($3 = 0 OR version = $3)`,
+			id, tenantID, step.Version,
+		)
+		if err != nil {
+			return translateStepError(err, step.Resource)
+		}
+		if tag.RowsAffected() == 0 {
+			return missingOrChanged(ctx, tx, id, tenantID, step.Version)
+		}
+		return nil
+	}
+}
+
+// NOTE - subject counterpart of applyStep, subjects have no version so a write that matches no row means the subject is gone
+func (r *batchRepository) applySubjectStep(ctx context.Context, tx pgx.Tx, step entity.BatchStep, tenantID, id int) error {
+	var tag pgconn.CommandTag
+	var err error
+
+	switch step.Op {
+	case entity.BatchOpCreate:
+		_, err = tx.Exec(ctx, `INSERT INTO subjects (id, name, tenant_id) VALUES ($1, $2, $3)`, id, step.Subject.Name, tenantID)
+		return translateStepError(err, step.Resource)
+
+	case entity.BatchOpUpdate:
+		var set assignments
+		if step.SubjectPatch.Name.IsSet() {
+			set.add("name", step.SubjectPatch.Name.Value)
+		}
+		if step.SubjectPatch.Status.IsSet() {
+			set.add("status", step.SubjectPatch.Status.Value)
+		}
+		set.add("updated_at", utils.FormatToVientianeTime(time.Now()))
+
+		n := len(set.args)
+		tag, err = tx.Exec(ctx, fmt.Sprintf(
+			`UPDATE subjects SET %s WHERE id = $%d AND tenant_id = $%d`,
+			strings.Join(set.columns, ", "), n+1, n+2,
+		), append(set.args, id, tenantID)...)
+
+	default:
+		// members are unlinked first, see subjectRepository.DeleteSubject
+		_, err = tx.Exec(ctx, `UPDATE users SET subject_id = NULL WHERE subject_id = $1 AND tenant_id = $2`, id, tenantID)
+		if err != nil {
+			return translateStepError(err, step.Resource)
+		}
+		tag, err = tx.Exec(ctx, `DELETE FROM subjects WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	}
+
+	if err != nil {
+		return translateStepError(err, step.Resource)
+	}
+	if tag.RowsAffected() == 0 {
+		return notFoundByID("subject", id)
+	}
+	return nil
+}
+
+// NOTE - why a user write of the batch matched no row: the user is gone, or another request
+// wrote it since checkTargets read its version
+func missingOrChanged(ctx context.Context, tx pgx.Tx, id, tenantID, version int) error {
+	if version == 0 {
+		return notFoundByID("user", id)
+	}
+
+	var exists bool
+	err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND tenant_id = $2)`, id, tenantID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return notFoundByID("user", id)
+	}
+	return entity.ErrVersionConflict
+}
+
+// NOTE - the error of a step that Postgres rejected. A missing reference is a subject removed since
+// checkTargets looked for it
+func translateStepError(err error, resource string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return entity.Conflict("batch_reference_missing", "an operation links a record that does not exist, nothing was applied")
+	}
+	return translatePgError(err, resource)
+}
+
+// assignments is the SET list of an UPDATE, its values are the first arguments of the statement
+type assignments struct {
+	columns []string
+	args    []interface{}
+}
+
+func (a *assignments) add(column string, value interface{}) {
+	a.args = append(a.args, value)
+	a.columns = append(a.columns, fmt.Sprintf("%s = $%d", column, len(a.args)))
+}
+
+// NOTE - reads the users and subjects a committed batch created or updated, by ID
+func (r *batchRepository) readWritten(ctx context.Context, tenantID int, steps []entity.BatchStep, ids []int) (map[int]*entity.User, map[int]*entity.Subject, error) {
+	var userIDs, subjectIDs []int
+	for i, step := range steps {
+		if step.Op == entity.BatchOpDelete {
+			continue
+		}
+		if step.Resource == entity.BatchResourceUser {
+			userIDs = append(userIDs, ids[i])
+		} else {
+			subjectIDs = append(subjectIDs, ids[i])
+		}
+	}
+
+	users := map[int]*entity.User{}
+	if len(userIDs) > 0 {
+		found, err := r.client.User.FindMany(db.User.TenantID.Equals(tenantID), db.User.ID.In(userIDs)).Exec(ctx)
+		if err != nil {
+			return users, nil, translateError(err, "user")
+		}
+		for _, u := range found {
+			user := toUserEntity(u)
+			users[u.ID] = &user
+		}
+	}
+
+	subjects := map[int]*entity.Subject{}
+	if len(subjectIDs) > 0 {
+		found, err := r.client.Subject.FindMany(db.Subject.TenantID.Equals(tenantID), db.Subject.ID.In(subjectIDs)).Exec(ctx)
+		if err != nil {
+			return users, subjects, translateError(err, "subject")
+		}
+		for _, s := range found {
+			subject := toSubjectEntity(s)
+			subjects[s.ID] = &subject
+		}
+	}
+
+	return users, subjects, nil
+}
+
+// NOTE - table holding a batch resource
+func batchTable(resource string) string {
+	if resource == entity.BatchResourceSubject {
+		return "subjects"
+	}
+	return "users"
+}
+
+// NOTE - drops every cached copy of the records a committed batch wrote
+func purgeBatchCaches(ctx context.Context, results []entity.BatchResult) {
+	subjectsChanged, subjectsDeleted := false, false
+	for _, result := range results {
+		if result.Resource == entity.BatchResourceUser {
+			cache.DelWithProjections(ctx, fmt.Sprintf("%s%d", cache.USER_CACHE_KEY, result.ID))
+		} else if result.Op != entity.BatchOpCreate {
+			subjectsChanged = true
+			subjectsDeleted = subjectsDeleted || result.Op == entity.BatchOpDelete
+		}
+	}
+	cache.DelWithPattern(ctx, fmt.Sprintf("%sall*", cache.USER_CACHE_KEY))
+	// subjects embed their users in the cached payload
+	cache.DelWithPattern(ctx, fmt.Sprintf("%s*", cache.SUBJECT_CACHE_KEY))
+	switch {
+	case subjectsDeleted:
+		// a deleted subject unlinked its members, so any cached user may be stale
+		cache.DelWithPattern(ctx, fmt.Sprintf("%s*", cache.USER_CACHE_KEY))
+	case subjectsChanged:
+		purgeUsersWithSubject(ctx)
+	}
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sample-project/internal/config/cache"
+	"sample-project/internal/entity"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestExecuteBatchUpdatesVersionedUser(t *testing.T) {
+	client := useTestDatabase(t)
+	useTestRedis(t)
+	ctx := createTenant(t, client, "school-a")
+
+	user, err := NewUserRepository(client, cache.GetRedisClient()).CreateUser(ctx, entity.User{Name: "Noy", Email: "noy@example.com", Password: "password1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := NewBatchRepository(client, useTestPool(t)).ExecuteBatch(ctx, []entity.BatchStep{{
+		Op:        entity.BatchOpUpdate,
+		Resource:  entity.BatchResourceUser,
+		ID:        entity.BatchID{Value: user.ID},
+		Version:   1,
+		UserPatch: entity.UserPatch{Name: entity.NewNullable("Noy P.")},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, ok := results[0].Data.(*entity.User)
+	if !ok || updated.Name != "Noy P." || updated.Version != 2 {
+		t.Errorf("result is %+v", results[0].Data)
+	}
+}
+
+func TestApplyStepChecksTheVersionedRowCount(t *testing.T) {
+	client := useTestDatabase(t)
+	useTestRedis(t)
+	ctx := createTenant(t, client, "school-a")
+	other := createTenant(t, client, "school-b")
+	tenantID, _ := entity.TenantID(ctx)
+	otherID, _ := entity.TenantID(other)
+
+	user, err := NewUserRepository(client, cache.GetRedisClient()).CreateUser(ctx, entity.User{Name: "Noy", Email: "noy@example.com", Password: "password1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &batchRepository{client: client, pool: useTestPool(t)}
+
+	// the steps skip checkTargets, like a user another request wrote after the batch read it
+	tests := []struct {
+		name     string
+		tenantID int
+		version  int
+		want     error
+	}{
+		{name: "stale version", tenantID: tenantID, version: 2, want: entity.ErrVersionConflict},
+		{name: "other tenant", tenantID: otherID, version: 1, want: entity.ErrNotFound},
+		{name: "current version", tenantID: tenantID, version: 1},
+		{name: "no version", tenantID: tenantID},
+	}
+
+	for _, tt := range tests {
+		step := entity.BatchStep{
+			Op:        entity.BatchOpUpdate,
+			Resource:  entity.BatchResourceUser,
+			Version:   tt.version,
+			UserPatch: entity.UserPatch{Name: entity.NewNullable(tt.name)},
+		}
+		err := pgx.BeginFunc(ctx, repo.pool, func(tx pgx.Tx) error {
+			return repo.applyStep(ctx, tx, step, tt.tenantID, user.ID, nil)
+		})
+		if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	stored, err := client.User.FindUnique(userInTenant(user.ID, tenantID)).Exec(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name != "no version" || stored.Version != 3 {
+		t.Errorf("user is %q at version %d, the failed steps must not have written", stored.Name, stored.Version)
+	}
+}
+
+func TestExecuteBatchReportsTheTakenEmailOfItsOperation(t *testing.T) {
+	client := useTestDatabase(t)
+	useTestRedis(t)
+	ctx := createTenant(t, client, "school-a")
+
+	_, err := NewBatchRepository(client, useTestPool(t)).ExecuteBatch(ctx, []entity.BatchStep{
+		{Op: entity.BatchOpCreate, Resource: entity.BatchResourceSubject, Subject: entity.Subject{Name: "Math"}},
+		{Op: entity.BatchOpCreate, Resource: entity.BatchResourceUser, User: entity.User{Name: "Noy", Email: "noy@example.com", Password: "password1"}},
+		{Op: entity.BatchOpCreate, Resource: entity.BatchResourceUser, User: entity.User{Name: "Noy P.", Email: "noy@example.com", Password: "password1"}},
+	})
+
+	var opErr *entity.BatchOperationError
+	if !errors.As(err, &opErr) || opErr.Index != 2 {
+		t.Fatalf("got %v, want operation 2 to fail", err)
+	}
+	var domainErr *entity.Error
+	if !errors.As(err, &domainErr) || domainErr.Code != "user_email_taken" {
+		t.Errorf("got %v, want user_email_taken", err)
+	}
+	if subjects, _ := client.Subject.FindMany().Exec(context.Background()); len(subjects) != 0 {
+		t.Errorf("the failed batch wrote subjects %+v", subjects)
+	}
+}
+
+func TestStepErrorsAreMappedByConstraint(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code string
+	}{
+		{name: "email", err: &pgconn.PgError{Code: uniqueViolation, ConstraintName: "users_tenant_id_email_key"}, code: "user_email_taken"},
+		{name: "unknown constraint", err: &pgconn.PgError{Code: uniqueViolation, ConstraintName: "users_pkey"}, code: "user_already_exists"},
+		{name: "foreign key", err: &pgconn.PgError{Code: foreignKeyViolation, ConstraintName: "users_subject_id_tenant_id_fkey"}, code: "batch_reference_missing"},
+	}
+
+	for _, tt := range tests {
+		var domainErr *entity.Error
+		if err := translateStepError(tt.err, "user"); !errors.As(err, &domainErr) || domainErr.Code != tt.code {
+			t.Errorf("%s: got %v, want %s", tt.name, err, tt.code)
+		}
+	}
+
+	other := errors.New("connection reset")
+	if err := translatePgError(other, "user"); err != other {
+		t.Errorf("got %v, an error that is not from Postgres must pass through", err)
+	}
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"errors"
+	"sample-project/internal/entity"
+	"sample-project/prisma/db"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Postgres error codes of the writes made without prisma
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// unique constraints of the schema by the name prisma gives them, with the fields they cover
+var uniqueConstraints = map[string][]string{
+	"users_tenant_id_email_key": {"tenant_id", "email"},
+}
+
+// NOTE - translates prisma errors into domain errors, anything unknown is passed through
+func translateError(err error, resource string) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, db.ErrNotFound) {
+		return entity.NotFound(resource+"_not_found", "%s not found", resource)
+	}
+
+	if info, ok := db.IsErrUniqueConstraint(err); ok {
+		fields := make([]string, 0, len(info.Fields))
+		for _, field := range info.Fields {
+			fields = append(fields, string(field))
+		}
+		return uniqueConflict(resource, fields)
+	}
+
+	return err
+}
+
+// NOTE - translates the errors of plain Postgres writes like translateError does for prisma's.
+// A unique violation is told apart by the constraint it names, anything unknown is passed through
+func translatePgError(err error, resource string) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	if pgErr.Code == uniqueViolation {
+		return uniqueConflict(resource, uniqueConstraints[pgErr.ConstraintName])
+	}
+
+	return err
+}
+
+// NOTE - conflict of a write that broke the unique constraint over fields, a constraint of
+// unknown fields only says the resource exists
+func uniqueConflict(resource string, fields []string) error {
+	taken := make([]string, 0, len(fields))
+	for _, field := range fields {
+		// uniqueness is per tenant, the tenant is not something the caller can change
+		if field == "tenant_id" {
+			continue
+		}
+		taken = append(taken, field)
+	}
+
+	if len(taken) == 0 {
+		return entity.Conflict(resource+"_already_exists", "%s already exists", resource)
+	}
+	return entity.Conflict(resource+"_"+strings.Join(taken, "_")+"_taken", "%s with this %s already exists", resource, strings.Join(taken, ", "))
+}
+
+// NOTE - not found error for a resource looked up by id
+func notFoundByID(resource string, id int) error {
+	return entity.NotFound(resource+"_not_found", "%s with ID: %d not found", resource, id).With("ID", id)
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"errors"
+	"sample-project/internal/entity"
+	"sample-project/prisma/db"
+	"testing"
+)
+
+func TestTranslateErrorMapsPrismaErrors(t *testing.T) {
+	if err := translateError(db.ErrNotFound, "subject"); !errors.Is(err, entity.NotFound("subject_not_found", "")) {
+		t.Errorf("got %v, want subject_not_found", err)
+	}
+	if err := translateError(nil, "user"); err != nil {
+		t.Errorf("got %v for no error", err)
+	}
+
+	other := errors.New("connection refused")
+	if err := translateError(other, "user"); err != other {
+		t.Errorf("got %v, an unknown error must pass through", err)
+	}
+}
+
+func TestUniqueConflictNamesTheFieldsTheCallerCanChange(t *testing.T) {
+	tests := []struct {
+		fields []string
+		code   string
+	}{
+		{fields: []string{"tenant_id", "email"}, code: "user_email_taken"},
+		{fields: []string{"email"}, code: "user_email_taken"},
+		{fields: []string{"tenant_id", "name", "email"}, code: "user_name_email_taken"},
+		{fields: []string{"tenant_id"}, code: "user_already_exists"},
+		{code: "user_already_exists"},
+	}
+
+	for _, tt := range tests {
+		err := uniqueConflict("user", tt.fields)
+		var domainErr *entity.Error
+		if !errors.As(err, &domainErr) || domainErr.Code != tt.code || !errors.Is(err, entity.ErrConflict) {
+			t.Errorf("%v: got %v, want a conflict %s", tt.fields, err, tt.code)
+		}
+	}
+}
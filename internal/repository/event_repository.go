@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sample-project/internal/config/cache"
+	"sample-project/internal/entity"
+)
+
+// NOTE - event repository interface
+type EventRepository interface {
+	GetEventsSince(ctx context.Context, lastID string, limit int) ([]entity.ChangeEvent, error)
+	SubscribeEvents(ctx context.Context) (<-chan entity.ChangeEvent, error)
+}
+
+// NOTE - event repository struct
+type eventRepository struct{}
+
+// NOTE - new event repository
+func NewEventRepository() EventRepository {
+	return &eventRepository{}
+}
+
+// NOTE - events after lastID, oldest first. Events trimmed from the history are skipped
+func (r *eventRepository) GetEventsSince(ctx context.Context, lastID string, limit int) ([]entity.ChangeEvent, error) {
+	messages, err := cache.EventsSince(ctx, lastID, int64(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]entity.ChangeEvent, 0, len(messages))
+	for _, message := range messages {
+		payload, _ := message.Values["event"].(string)
+		event, err := decodeEvent(message.ID, payload)
+		if err != nil {
+			slog.WarnContext(ctx, "Skipping malformed event", "id", message.ID, "error", err)
+			continue
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// NOTE - events published by every instance from now on, the channel closes once ctx is done
+func (r *eventRepository) SubscribeEvents(ctx context.Context) (<-chan entity.ChangeEvent, error) {
+	pubsub := cache.SubscribeEvents(ctx)
+	// wait for the subscription, so no event published after this returns is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	events := make(chan entity.ChangeEvent)
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				id, payload, found := cache.SplitEventMessage(message.Payload)
+				if !found {
+					continue
+				}
+				event, err := decodeEvent(id, payload)
+				if err != nil {
+					slog.WarnContext(ctx, "Skipping malformed event", "id", id, "error", err)
+					continue
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// NOTE - records a committed write for the event stream, for the tenant of ctx. The write already happened,
+// so a failure is logged and the caller carries on
+func publishChange(ctx context.Context, event entity.ChangeEvent) {
+	event.TenantID, _ = entity.TenantID(ctx)
+	payload, err := json.Marshal(event)
+	if err == nil {
+		_, err = cache.PublishEvent(context.WithoutCancel(ctx), payload)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to publish change event", "type", event.Type, "resource_id", event.ResourceID, "error", err)
+	}
+}
+
+// NOTE - user as it is sent to stream clients, without the password hash
+func userEvent(action string, user entity.User) entity.ChangeEvent {
+	data := struct {
+		entity.User
+		Password string `json:"password,omitempty"`
+	}{User: user}
+	return entity.NewChangeEvent(entity.EventResourceUser, action, user.ID, data)
+}
+
+func decodeEvent(id, payload string) (entity.ChangeEvent, error) {
+	var event entity.ChangeEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		return event, err
+	}
+	event.ID = id
+	return event, nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sample-project/internal/entity"
+	"sample-project/prisma/db"
+)
+
+// NOTE - privacy repository interface
+type PrivacyRepository interface {
+	GetLastPrivacyRecord(ctx context.Context) (*entity.PrivacyRecord, error)
+	CreatePrivacyRecord(ctx context.Context, record entity.PrivacyRecord) (*entity.PrivacyRecord, error)
+	GetPrivacyRecords(ctx context.Context, userID int) ([]entity.PrivacyRecord, error)
+}
+
+// NOTE - privacy repository struct
+type privacyRepository struct {
+	client *db.PrismaClient
+}
+
+// NOTE - new privacy repository
+func NewPrivacyRepository(client *db.PrismaClient) PrivacyRepository {
+	return &privacyRepository{client: client}
+}
+
+// NOTE - newest record of the tenant's chain, nil when its log is still empty
+func (r *privacyRepository) GetLastPrivacyRecord(ctx context.Context) (*entity.PrivacyRecord, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := r.client.PrivacyRequestRecord.FindFirst(
+		db.PrivacyRequestRecord.TenantID.Equals(tenantID),
+	).OrderBy(
+		db.PrivacyRequestRecord.ID.Order(db.SortOrderDesc),
+	).Exec(ctx)
+
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, translateError(err, "privacy_record")
+	}
+
+	result := toPrivacyRecordEntity(*record)
+	return &result, nil
+}
+
+// NOTE - appends a record to the tenant's chain, prev_hash is unique per tenant so two writers racing on
+// the same tail cannot fork the chain
+func (r *privacyRepository) CreatePrivacyRecord(ctx context.Context, record entity.PrivacyRecord) (*entity.PrivacyRecord, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := []db.PrivacyRequestRecordSetParam{}
+	if record.ActorID != nil {
+		params = append(params, db.PrivacyRequestRecord.ActorID.Set(*record.ActorID))
+	}
+
+	created, err := r.client.PrivacyRequestRecord.CreateOne(
+		db.PrivacyRequestRecord.UserID.Set(record.UserID),
+		db.PrivacyRequestRecord.Type.Set(db.PrivacyRequestType(record.Type)),
+		db.PrivacyRequestRecord.Details.Set(record.Details),
+		db.PrivacyRequestRecord.CompletedAt.Set(record.CompletedAt),
+		db.PrivacyRequestRecord.PrevHash.Set(record.PrevHash),
+		db.PrivacyRequestRecord.Hash.Set(record.Hash),
+		db.PrivacyRequestRecord.Tenant.Link(db.Tenant.ID.Equals(tenantID)),
+		params...,
+	).Exec(ctx)
+
+	if err != nil {
+		if _, ok := db.IsErrUniqueConstraint(err); ok {
+			return nil, entity.ErrPrivacyChainConflict
+		}
+		return nil, translateError(err, "privacy_record")
+	}
+
+	result := toPrivacyRecordEntity(*created)
+	return &result, nil
+}
+
+// NOTE - records of the tenant in chain order, userID 0 returns the tenant's whole chain
+func (r *privacyRepository) GetPrivacyRecords(ctx context.Context, userID int) ([]entity.PrivacyRecord, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	where := []db.PrivacyRequestRecordWhereParam{db.PrivacyRequestRecord.TenantID.Equals(tenantID)}
+	if userID != 0 {
+		where = append(where, db.PrivacyRequestRecord.UserID.Equals(userID))
+	}
+
+	records, err := r.client.PrivacyRequestRecord.FindMany(where...).OrderBy(
+		db.PrivacyRequestRecord.ID.Order(db.SortOrderAsc),
+	).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err, "privacy_record")
+	}
+
+	result := []entity.PrivacyRecord{}
+	for _, record := range records {
+		result = append(result, toPrivacyRecordEntity(record))
+	}
+
+	return result, nil
+}
+
+// NOTE - maps a prisma privacy record model to the entity, times stay in UTC so hashes can be recomputed
+func toPrivacyRecordEntity(r db.PrivacyRequestRecordModel) entity.PrivacyRecord {
+	record := entity.PrivacyRecord{
+		ID:          r.ID,
+		UserID:      r.UserID,
+		Type:        entity.PrivacyRequestType(r.Type),
+		Details:     r.Details,
+		CompletedAt: r.CompletedAt.UTC(),
+		PrevHash:    r.PrevHash,
+		Hash:        r.Hash,
+	}
+
+	if actorID, ok := r.ActorID(); ok {
+		record.ActorID = &actorID
+	}
+
+	return record
+}
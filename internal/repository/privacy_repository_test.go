@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"sample-project/internal/config/cache"
+	"sample-project/internal/entity"
+	"testing"
+	"time"
+)
+
+func TestPrivacyChainsArePerSchool(t *testing.T) {
+	client := useTestDatabase(t)
+	useTestRedis(t)
+	schoolA := createTenant(t, client, "school-a")
+	schoolB := createTenant(t, client, "school-b")
+	users := NewUserRepository(client, cache.GetRedisClient())
+	repo := NewPrivacyRepository(client)
+
+	appendRecord := func(ctx context.Context, user *entity.User, prevHash string) *entity.PrivacyRecord {
+		t.Helper()
+		record := entity.PrivacyRecord{UserID: user.ID, Type: entity.PrivacyRequestExport, Details: "export", CompletedAt: time.Now().UTC().Truncate(time.Microsecond), PrevHash: prevHash}
+		record.Hash = record.ComputeHash()
+		created, err := repo.CreatePrivacyRecord(ctx, record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return created
+	}
+
+	noy, err := users.CreateUser(schoolA, entity.User{Name: "Noy", Email: "noy@example.com", Password: "password1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dan, err := users.CreateUser(schoolB, entity.User{Name: "Dan", Email: "dan@example.com", Password: "password1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// both chains start at the genesis hash, interleaved writes do not link them
+	first := appendRecord(schoolA, noy, entity.PrivacyGenesisHash)
+	appendRecord(schoolB, dan, entity.PrivacyGenesisHash)
+	appendRecord(schoolA, noy, first.Hash)
+
+	last, err := repo.GetLastPrivacyRecord(schoolB)
+	if err != nil || last == nil || last.UserID != dan.ID {
+		t.Errorf("last record of school B is %+v, %v", last, err)
+	}
+
+	records, err := repo.GetPrivacyRecords(schoolA, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].PrevHash != entity.PrivacyGenesisHash || records[1].PrevHash != records[0].Hash {
+		t.Errorf("chain of school A is %+v", records)
+	}
+	if records, err := repo.GetPrivacyRecords(schoolA, dan.ID); err != nil || len(records) != 0 {
+		t.Errorf("school A read %+v of the user of school B: %v", records, err)
+	}
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"sample-project/internal/entity"
+	"sample-project/prisma/db"
+	"slices"
+)
+
+// column pairs the json name of a field with the prisma field that selects it
+type column[F any] struct {
+	name  string
+	field F
+}
+
+func columnOf[F any](name string, field F) column[F] {
+	return column[F]{name: name, field: field}
+}
+
+func columns[F any](all ...column[F]) []column[F] {
+	return all
+}
+
+// selectable columns of every model, matching entity.UserFields and entity.SubjectFields
+var (
+	userColumns = columns(
+		columnOf("id", db.User.ID.Field()),
+		columnOf("name", db.User.Name.Field()),
+		columnOf("email", db.User.Email.Field()),
+		columnOf("avatar_url", db.User.AvatarURL.Field()),
+		columnOf("subject_id", db.User.SubjectID.Field()),
+		columnOf("status", db.User.Status.Field()),
+		columnOf("state", db.User.State.Field()),
+		columnOf("suspended_reason", db.User.SuspendedReason.Field()),
+		columnOf("reactivate_at", db.User.ReactivateAt.Field()),
+		columnOf("version", db.User.Version.Field()),
+		columnOf("erased_at", db.User.ErasedAt.Field()),
+		columnOf("day", db.User.Day.Field()),
+		columnOf("month", db.User.Month.Field()),
+		columnOf("year", db.User.Year.Field()),
+		columnOf("created_at", db.User.CreatedAt.Field()),
+		columnOf("updated_at", db.User.UpdatedAt.Field()),
+	)
+	subjectColumns = columns(
+		columnOf("id", db.Subject.ID.Field()),
+		columnOf("name", db.Subject.Name.Field()),
+		columnOf("status", db.Subject.Status.Field()),
+		columnOf("created_at", db.Subject.CreatedAt.Field()),
+		columnOf("updated_at", db.Subject.UpdatedAt.Field()),
+	)
+)
+
+// columns read whatever the projection, cache purges, ETag and Last-Modified depend on them
+var (
+	userRequiredColumns    = []string{"id", "version", "updated_at"}
+	subjectRequiredColumns = []string{"id", "updated_at"}
+)
+
+// NOTE - prisma fields a projection reads, required ones included
+func selectColumns[F any](available []column[F], projection entity.Projection, required []string) []F {
+	fields := make([]F, 0, len(available))
+	for _, c := range available {
+		if slices.Contains(required, c.name) || projection.Selects(c.name) {
+			fields = append(fields, c.field)
+		}
+	}
+	return fields
+}
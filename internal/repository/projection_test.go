@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"reflect"
+	"sample-project/internal/entity"
+	"testing"
+)
+
+func TestSelectColumnsKeepsTheRequiredOnes(t *testing.T) {
+	available := columns(
+		columnOf("id", "users.id"),
+		columnOf("name", "users.name"),
+		columnOf("email", "users.email"),
+		columnOf("version", "users.version"),
+		columnOf("updated_at", "users.updated_at"),
+	)
+
+	tests := []struct {
+		name       string
+		projection entity.Projection
+		want       []string
+	}{
+		{name: "every field", want: []string{"users.id", "users.name", "users.email", "users.version", "users.updated_at"}},
+		{name: "sparse", projection: entity.Projection{Fields: []string{"email"}}, want: []string{"users.id", "users.email", "users.version", "users.updated_at"}},
+		{name: "relation only", projection: entity.Projection{Fields: []string{"name"}, Include: []string{entity.IncludeSubject}}, want: []string{"users.id", "users.name", "users.version", "users.updated_at"}},
+	}
+
+	for _, tt := range tests {
+		if got := selectColumns(available, tt.projection, userRequiredColumns); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: selected %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if len(userColumns) != len(entity.UserFields) || len(subjectColumns) != len(entity.SubjectFields) {
+		t.Errorf("the selectable columns do not match entity.UserFields and entity.SubjectFields")
+	}
+	for i, c := range userColumns {
+		if c.name != entity.UserFields[i] {
+			t.Errorf("user column %d is %s, entity.UserFields has %s", i, c.name, entity.UserFields[i])
+		}
+	}
+	for i, c := range subjectColumns {
+		if c.name != entity.SubjectFields[i] {
+			t.Errorf("subject column %d is %s, entity.SubjectFields has %s", i, c.name, entity.SubjectFields[i])
+		}
+	}
+}
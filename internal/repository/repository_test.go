@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"sample-project/internal/config"
+	"sample-project/internal/config/cache"
+	"sample-project/internal/entity"
+	"sample-project/prisma/db"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NOTE - points the cache at an in-memory redis for the test
+func useTestRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	t.Setenv("REDIS_URL", "redis://"+server.Addr())
+	cache.ConnectRedis()
+	t.Cleanup(func() { cache.CloseRedis() })
+	return server
+}
+
+// NOTE - prisma client on TEST_DATABASE_URL with every table emptied. The database has to have the
+// current schema (prisma db push), tests that need it are skipped when the variable is not set
+func useTestDatabase(t *testing.T) *db.PrismaClient {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	t.Setenv("DATABASE_URL", url)
+
+	client := db.NewClient()
+	if err := client.Prisma.Connect(); err != nil {
+		t.Fatalf("failed to connect to the test database: %v", err)
+	}
+	t.Cleanup(func() { client.Prisma.Disconnect() })
+
+	_, err := client.Prisma.ExecuteRaw(`TRUNCATE users, subjects, user_state_transitions, privacy_request_records, tenants RESTART IDENTITY CASCADE`).Exec(context.Background())
+	if err != nil {
+		t.Fatalf("failed to empty the test database: %v", err)
+	}
+	return client
+}
+
+// NOTE - creates a tenant and returns a context scoped to it
+func createTenant(t *testing.T, client *db.PrismaClient, slug string) context.Context {
+	t.Helper()
+
+	tenant, err := client.Tenant.CreateOne(
+		db.Tenant.Slug.Set(slug),
+		db.Tenant.Name.Set(slug),
+	).Exec(context.Background())
+	if err != nil {
+		t.Fatalf("failed to create tenant %s: %v", slug, err)
+	}
+	return entity.WithTenant(context.Background(), tenant.ID)
+}
+
+// NOTE - pool on the test database, for the writes made without prisma. Call it after useTestDatabase
+func useTestPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	pool, err := config.ConnectPool(context.Background())
+	if err != nil {
+		t.Fatalf("failed to connect the pool to the test database: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
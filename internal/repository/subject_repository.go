@@ -8,6 +8,7 @@ import (
 	"sample-project/internal/entity"
 	"sample-project/internal/utils"
 	"sample-project/prisma/db"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -15,12 +16,16 @@ import (
 
 // NOTE - subject repository interface
 type SubjectRepository interface {
-	GetAllSubjects(ctx context.Context) ([]entity.Subject, error)
-	GetSubjectByID(ctx context.Context, id int) (*entity.Subject, error)
+	GetAllSubjects(ctx context.Context, projection entity.Projection) ([]entity.Subject, error)
+	GetSubjectsPage(ctx context.Context, page, limit int, name string, status *bool) ([]entity.Subject, int, error)
+	GetSubjectByID(ctx context.Context, id int, projection entity.Projection) (*entity.Subject, error)
+	GetSubjectsByIDs(ctx context.Context, ids []int) ([]entity.Subject, error)
 	CreateSubject(ctx context.Context, subject entity.Subject) (*entity.Subject, error)
-	UpdateSubject(ctx context.Context, id int, subject entity.Subject) (*entity.Subject, error)
+	UpdateSubject(ctx context.Context, id int, patch entity.SubjectPatch) (*entity.Subject, error)
 	DeleteSubject(ctx context.Context, id int) error
 	ClearSubjectCache(ctx context.Context) error
+	GetSubjectsETag(ctx context.Context, projection entity.Projection) string
+	GetSubjectETag(ctx context.Context, id int, projection entity.Projection) string
 }
 
 // NOTE - subject repository struct
@@ -34,10 +39,15 @@ func NewSubjectRepository(client *db.PrismaClient, redisClient *redis.Client) Su
 }
 
 // NOTE - get all subjects repository
-func (r *subjectRepository) GetAllSubjects(ctx context.Context) ([]entity.Subject, error) {
-	allSubjectsCacheKey := fmt.Sprintf("%sall", cache.SUBJECT_CACHE_KEY)
+func (r *subjectRepository) GetAllSubjects(ctx context.Context, projection entity.Projection) ([]entity.Subject, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	cachedSubjects, err := r.redisClient.Get(ctx, allSubjectsCacheKey).Result()
+	allSubjectsCacheKey := cache.ProjectedKey(fmt.Sprintf("%sall", cache.SUBJECT_CACHE_KEY), projection.Key())
+
+	cachedSubjects, err := cache.Get(ctx, allSubjectsCacheKey)
 	if err == nil && cachedSubjects != "" {
 		var subjects []entity.Subject
 		if json.Unmarshal([]byte(cachedSubjects), &subjects) == nil {
@@ -45,45 +55,90 @@ func (r *subjectRepository) GetAllSubjects(ctx context.Context) ([]entity.Subjec
 		}
 	}
 
-	subjects, err := r.client.Subject.FindMany().With(db.Subject.User.Fetch()).Exec(ctx)
+	query := r.client.Subject.FindMany(
+		db.Subject.TenantID.Equals(tenantID),
+	)
+	// select replaces the whole output, so relations are added after it
+	if len(projection.Fields) > 0 {
+		query = query.Select(selectColumns(subjectColumns, projection, subjectRequiredColumns)...)
+	}
+	if projection.Includes(entity.IncludeUsers) {
+		query = query.With(db.Subject.User.Fetch())
+	}
+
+	subjects, err := query.Exec(ctx)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err, "subject")
 	}
 
 	var result []entity.Subject
 	for _, s := range subjects {
-		var users []entity.User
-		for _, u := range s.User() {
-			users = append(users, entity.User{
-				ID:        u.ID,
-				Name:      u.Name,
-				Email:     u.Email,
-				Status:    u.Status,
-				CreatedAt: utils.FormatToVientianeTime(u.CreatedAt),
-				UpdatedAt: utils.FormatToVientianeTime(u.UpdatedAt),
-			})
+		subject := toSubjectEntity(s)
+		if projection.Includes(entity.IncludeUsers) {
+			subject.User = toMemberEntities(s.User())
 		}
-		result = append(result, entity.Subject{
-			ID:        s.ID,
-			Name:      s.Name,
-			User:      users,
-			Status:    s.Status,
-			CreatedAt: utils.FormatToVientianeTime(s.CreatedAt),
-			UpdatedAt: utils.FormatToVientianeTime(s.UpdatedAt),
-		})
+		result = append(result, subject)
 	}
 
 	subjectsJSON, _ := json.Marshal(result)
-	r.redisClient.Set(ctx, allSubjectsCacheKey, string(subjectsJSON), time.Duration(cache.SUBJECT_CACHE_KEY_TTL)*time.Second)
+	cache.SetWithETag(ctx, allSubjectsCacheKey, string(subjectsJSON), time.Duration(cache.SUBJECT_CACHE_KEY_TTL)*time.Second)
 
 	return result, nil
 }
 
+// NOTE - one page of the subjects whose name contains name and whose status is status, with the total
+// of all matching subjects. Both filters are optional
+func (r *subjectRepository) GetSubjectsPage(ctx context.Context, page, limit int, name string, status *bool) ([]entity.Subject, int, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	whereClause := []db.SubjectWhereParam{db.Subject.TenantID.Equals(tenantID)}
+	conditions, params := []string{"tenant_id = $1"}, []any{tenantID}
+	if name != "" {
+		whereClause = append(whereClause, db.Subject.Name.Contains(name), db.Subject.Name.Mode(db.QueryModeInsensitive))
+		params = append(params, likePattern(name))
+		conditions = append(conditions, fmt.Sprintf("name ILIKE $%d", len(params)))
+	}
+	if status != nil {
+		whereClause = append(whereClause, db.Subject.Status.Equals(*status))
+		params = append(params, *status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(params)))
+	}
+
+	subjects, err := r.client.Subject.FindMany(whereClause...).
+		Skip((page - 1) * limit).
+		Take(limit).
+		OrderBy(db.Subject.ID.Order(db.SortOrderAsc)).
+		Exec(ctx)
+	if err != nil {
+		return nil, 0, translateError(err, "subject")
+	}
+
+	total, err := countRows(ctx, r.client, "subjects", conditions, params...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]entity.Subject, 0, len(subjects))
+	for _, s := range subjects {
+		result = append(result, toSubjectEntity(s))
+	}
+
+	return result, total, nil
+}
+
 // NOTE - get subject by id repository
-func (r *subjectRepository) GetSubjectByID(ctx context.Context, id int) (*entity.Subject, error) {
-	subjectCacheKey := fmt.Sprintf("%s%d", cache.SUBJECT_CACHE_KEY, id)
+func (r *subjectRepository) GetSubjectByID(ctx context.Context, id int, projection entity.Projection) (*entity.Subject, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	subjectCacheKey := cache.ProjectedKey(fmt.Sprintf("%s%d", cache.SUBJECT_CACHE_KEY, id), projection.Key())
 
-	cachedSubject, err := r.redisClient.Get(ctx, subjectCacheKey).Result()
+	cachedSubject, err := cache.Get(ctx, subjectCacheKey)
 	if err == nil && cachedSubject != "" {
 		var subject entity.Subject
 		if json.Unmarshal([]byte(cachedSubject), &subject) == nil {
@@ -91,115 +146,247 @@ func (r *subjectRepository) GetSubjectByID(ctx context.Context, id int) (*entity
 		}
 	}
 
-	subject, err := r.client.Subject.FindUnique(
-		db.Subject.ID.Equals(id),
-	).With(
-		db.Subject.User.Fetch(),
-	).Exec(ctx)
+	query := r.client.Subject.FindUnique(
+		subjectInTenant(id, tenantID),
+	)
+	if len(projection.Fields) > 0 {
+		query = query.Select(selectColumns(subjectColumns, projection, subjectRequiredColumns)...)
+	}
+	if projection.Includes(entity.IncludeUsers) {
+		query = query.With(db.Subject.User.Fetch())
+	}
+
+	subject, err := query.Exec(ctx)
+	if err != nil {
+		return nil, translateError(err, "subject")
+	}
+
+	result := toSubjectEntity(*subject)
+	if projection.Includes(entity.IncludeUsers) {
+		result.User = toMemberEntities(subject.User())
+	}
+
+	subjectData, _ := json.Marshal(result)
+	cache.SetWithETag(ctx, subjectCacheKey, string(subjectData), time.Duration(cache.SUBJECT_CACHE_KEY_TTL)*time.Second)
+
+	return &result, nil
+}
+
+// NOTE - subjects without their users in one query, for batched loading
+func (r *subjectRepository) GetSubjectsByIDs(ctx context.Context, ids []int) ([]entity.Subject, error) {
+	tenantID, err := tenantOf(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	var users []entity.User
-	for _, u := range subject.User() {
-		users = append(users, entity.User{
-			ID:        u.ID,
-			Name:      u.Name,
-			Email:     u.Email,
-			Status:    u.Status,
-			CreatedAt: utils.FormatToVientianeTime(u.CreatedAt),
-			UpdatedAt: utils.FormatToVientianeTime(u.UpdatedAt),
-		})
+	subjects, err := r.client.Subject.FindMany(
+		db.Subject.TenantID.Equals(tenantID),
+		db.Subject.ID.In(ids),
+	).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err, "subject")
 	}
 
-	subjectData, _ := json.Marshal(subject)
-	r.redisClient.Set(ctx, subjectCacheKey, string(subjectData), time.Duration(cache.SUBJECT_CACHE_KEY_TTL)*time.Second)
+	result := make([]entity.Subject, 0, len(subjects))
+	for _, s := range subjects {
+		result = append(result, entity.Subject{
+			ID:        s.ID,
+			Name:      s.Name,
+			Status:    s.Status,
+			CreatedAt: utils.FormatToVientianeTime(s.CreatedAt),
+			UpdatedAt: utils.FormatToVientianeTime(s.UpdatedAt),
+		})
+	}
 
-	return &entity.Subject{
-		ID:        subject.ID,
-		Name:      subject.Name,
-		User:      users,
-		Status:    subject.Status,
-		CreatedAt: utils.FormatToVientianeTime(subject.CreatedAt),
-		UpdatedAt: utils.FormatToVientianeTime(subject.UpdatedAt),
-	}, nil
+	return result, nil
 }
 
 // NOTE - create subject repository
 func (r *subjectRepository) CreateSubject(ctx context.Context, subject entity.Subject) (*entity.Subject, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	newSubject, err := r.client.Subject.CreateOne(
 		db.Subject.Name.Set(subject.Name),
+		db.Subject.Tenant.Link(db.Tenant.ID.Equals(tenantID)),
 	).Exec(ctx)
 
 	if err != nil {
-		return nil, err
+		return nil, translateError(err, "subject")
 	}
 
-	r.redisClient.Del(ctx, fmt.Sprintf("%sall", cache.SUBJECT_CACHE_KEY))
+	cache.DelWithProjections(ctx, fmt.Sprintf("%sall", cache.SUBJECT_CACHE_KEY))
 
-	return &entity.Subject{
+	result := &entity.Subject{
 		ID:        newSubject.ID,
 		Name:      newSubject.Name,
 		Status:    newSubject.Status,
 		CreatedAt: utils.FormatToVientianeTime(newSubject.CreatedAt),
 		UpdatedAt: utils.FormatToVientianeTime(newSubject.UpdatedAt),
-	}, nil
+	}
+	publishChange(ctx, entity.NewChangeEvent(entity.EventResourceSubject, entity.EventActionCreated, result.ID, result))
+	return result, nil
 }
 
-// NOTE - update subject repository
-func (r *subjectRepository) UpdateSubject(ctx context.Context, id int, subject entity.Subject) (*entity.Subject, error) {
+// NOTE - update subject repository, only the members present in the patch are written
+func (r *subjectRepository) UpdateSubject(ctx context.Context, id int, patch entity.SubjectPatch) (*entity.Subject, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []db.SubjectSetParam
+
+	if patch.Name.IsSet() {
+		updates = append(updates, db.Subject.Name.Set(patch.Name.Value))
+	}
+	if patch.Status.IsSet() {
+		updates = append(updates, db.Subject.Status.Set(patch.Status.Value))
+	}
+
+	updates = append(updates, db.Subject.UpdatedAt.Set(utils.FormatToVientianeTime(time.Now())))
+
 	updateSubject, err := r.client.Subject.FindUnique(
-		db.Subject.ID.Equals(id),
+		subjectInTenant(id, tenantID),
 	).Update(
-		db.Subject.Name.Set(subject.Name),
-		db.Subject.Status.Set(subject.Status),
-		db.Subject.UpdatedAt.Set(utils.FormatToVientianeTime(time.Now())),
+		updates...,
 	).Exec(ctx)
 
 	if err != nil {
-		return nil, err
+		return nil, translateError(err, "subject")
 	}
 
 	subjectCacheKey := fmt.Sprintf("%s%d", cache.SUBJECT_CACHE_KEY, id)
-	r.redisClient.Del(ctx, subjectCacheKey)
-	r.redisClient.Del(ctx, fmt.Sprintf("%sall", cache.SUBJECT_CACHE_KEY))
+	cache.DelWithProjections(ctx, subjectCacheKey)
+	cache.DelWithProjections(ctx, fmt.Sprintf("%sall", cache.SUBJECT_CACHE_KEY))
+	purgeUsersWithSubject(ctx)
 
-	return &entity.Subject{
+	result := &entity.Subject{
 		ID:        updateSubject.ID,
 		Name:      updateSubject.Name,
 		Status:    updateSubject.Status,
 		CreatedAt: utils.FormatToVientianeTime(updateSubject.CreatedAt),
 		UpdatedAt: utils.FormatToVientianeTime(updateSubject.UpdatedAt),
-	}, nil
+	}
+	publishChange(ctx, entity.NewChangeEvent(entity.EventResourceSubject, entity.EventActionUpdated, result.ID, result))
+	return result, nil
 }
 
 // NOTE - delete subject repository
 func (r *subjectRepository) DeleteSubject(ctx context.Context, id int) error {
-	_, err := r.client.Subject.FindUnique(
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the foreign key of users names the tenant too, so its members are unlinked here and not by SET NULL
+	unlinkUsers := r.client.User.FindMany(
+		db.User.SubjectID.Equals(id),
+		db.User.TenantID.Equals(tenantID),
+	).Update(
+		db.User.SubjectID.SetOptional(nil),
+	).Tx()
+	deleteSubject := r.client.Subject.FindMany(
 		db.Subject.ID.Equals(id),
-	).Delete().Exec(ctx)
+		db.Subject.TenantID.Equals(tenantID),
+	).Delete().Tx()
+
+	err = r.client.Prisma.Transaction(unlinkUsers, deleteSubject).Exec(ctx)
 
 	subjectCacheKey := fmt.Sprintf("%s%d", cache.SUBJECT_CACHE_KEY, id)
-	r.redisClient.Del(ctx, subjectCacheKey)
-	r.redisClient.Del(ctx, fmt.Sprintf("%sall", cache.SUBJECT_CACHE_KEY))
+	cache.DelWithProjections(ctx, subjectCacheKey)
+	cache.DelWithProjections(ctx, fmt.Sprintf("%sall", cache.SUBJECT_CACHE_KEY))
+	// the unlinked members changed, not only the reads that include their subject
+	cache.DelWithPattern(ctx, fmt.Sprintf("%s*", cache.USER_CACHE_KEY))
 
-	return err
+	if err != nil {
+		return translateError(err, "subject")
+	}
+	if deleteSubject.Result().Count == 0 {
+		return entity.NotFound("subject_not_found", "subject not found")
+	}
+
+	publishChange(ctx, entity.NewChangeEvent(entity.EventResourceSubject, entity.EventActionDeleted, id, nil))
+	return nil
 }
 
 // NOTE - clear subject cache repository
 func (r *subjectRepository) ClearSubjectCache(ctx context.Context) error {
-	pattern := fmt.Sprintf("%s*", cache.SUBJECT_CACHE_KEY)
-	iter := r.redisClient.Scan(ctx, 0, pattern, 0).Iterator()
+	if _, err := tenantOf(ctx); err != nil {
+		return err
+	}
 
-	for iter.Next(ctx) {
-		if err := r.redisClient.Del(ctx, iter.Val()).Err(); err != nil {
-			return err
-		}
+	// only the subjects the tenant cached, the pattern is namespaced like every other key
+	return cache.DelWithPattern(ctx, fmt.Sprintf("%s*", cache.SUBJECT_CACHE_KEY))
+}
+
+// NOTE - entity tag of the cached subject list, empty when the list is not cached
+func (r *subjectRepository) GetSubjectsETag(ctx context.Context, projection entity.Projection) string {
+	etag, _ := cache.GetETag(ctx, cache.ProjectedKey(fmt.Sprintf("%sall", cache.SUBJECT_CACHE_KEY), projection.Key()))
+	return etag
+}
+
+// NOTE - entity tag of a cached subject, empty when the subject is not cached
+func (r *subjectRepository) GetSubjectETag(ctx context.Context, id int, projection entity.Projection) string {
+	etag, _ := cache.GetETag(ctx, cache.ProjectedKey(fmt.Sprintf("%s%d", cache.SUBJECT_CACHE_KEY, id), projection.Key()))
+	return etag
+}
+
+// NOTE - unique where of a subject of the tenant
+func subjectInTenant(id, tenantID int) db.SubjectEqualsUniqueWhereParam {
+	return db.Subject.IDTenantID(db.Subject.ID.Equals(id), db.Subject.TenantID.Equals(tenantID))
+}
+
+// NOTE - number of rows of table matching all conditions, the conditions refer to params as $1, $2, ...
+func countRows(ctx context.Context, client *db.PrismaClient, table string, conditions []string, params ...any) (int, error) {
+	var rows []struct {
+		Count int `json:"count"`
+	}
+	query := fmt.Sprintf("SELECT COUNT(*)::int AS count FROM %s WHERE %s", table, strings.Join(conditions, " AND "))
+	if err := client.Prisma.QueryRaw(query, params...).Exec(ctx, &rows); err != nil {
+		return 0, fmt.Errorf("failed to count %s: %w", table, err)
+	}
+	if len(rows) == 0 {
+		return 0, nil
 	}
+	return rows[0].Count, nil
+}
 
-	if err := iter.Err(); err != nil {
-		return err
+// NOTE - LIKE pattern matching values that contain s, its wildcards are matched literally
+func likePattern(s string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s) + "%"
+}
+
+// NOTE - maps a prisma subject model to the subject entity, without its users
+func toSubjectEntity(s db.SubjectModel) entity.Subject {
+	return entity.Subject{
+		ID:        s.ID,
+		Name:      s.Name,
+		Status:    s.Status,
+		CreatedAt: utils.FormatToVientianeTime(s.CreatedAt),
+		UpdatedAt: utils.FormatToVientianeTime(s.UpdatedAt),
 	}
+}
 
-	return nil
+// NOTE - members of a subject as embedded in its payload, without credentials or lifecycle details
+func toMemberEntities(users []db.UserModel) []entity.User {
+	members := make([]entity.User, 0, len(users))
+	for _, u := range users {
+		members = append(members, entity.User{
+			ID:        u.ID,
+			Name:      u.Name,
+			Email:     u.Email,
+			Status:    u.Status,
+			CreatedAt: utils.FormatToVientianeTime(u.CreatedAt),
+			UpdatedAt: utils.FormatToVientianeTime(u.UpdatedAt),
+		})
+	}
+	return members
+}
+
+// NOTE - drops the cached users that embed their subject, after a subject changed
+func purgeUsersWithSubject(ctx context.Context) {
+	cache.DelWithPattern(ctx, fmt.Sprintf("%s*%s*include=*%s*", cache.USER_CACHE_KEY, cache.PROJECTION_SEPARATOR, entity.IncludeSubject))
 }
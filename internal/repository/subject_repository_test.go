@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"errors"
+	"sample-project/internal/config/cache"
+	"sample-project/internal/entity"
+	"testing"
+)
+
+func TestLikePatternMatchesWildcardsLiterally(t *testing.T) {
+	if got := likePattern(`50%_off\`); got != `%50\%\_off\\%` {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestGetSubjectsPageFiltersAndCountsInTheDatabase(t *testing.T) {
+	client := useTestDatabase(t)
+	useTestRedis(t)
+	ctx := createTenant(t, client, "school-a")
+	repo := NewSubjectRepository(client, cache.GetRedisClient())
+
+	for _, name := range []string{"Math", "Applied math", "History", "Math club"} {
+		if _, err := repo.CreateSubject(ctx, entity.Subject{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	inactive := false
+	if _, err := repo.UpdateSubject(ctx, 4, entity.SubjectPatch{Status: entity.NewNullable(inactive)}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		status *bool
+		page   int
+		want   []string
+		total  int
+	}{
+		{name: "MATH", page: 1, want: []string{"Math", "Applied math"}, total: 3},
+		{name: "math", page: 2, want: []string{"Math club"}, total: 3},
+		{name: "math", status: &inactive, page: 1, want: []string{"Math club"}, total: 1},
+		{page: 3, want: []string{}, total: 4},
+	}
+
+	for _, tt := range tests {
+		subjects, total, err := repo.GetSubjectsPage(ctx, tt.page, 2, tt.name, tt.status)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := []string{}
+		for _, s := range subjects {
+			names = append(names, s.Name)
+		}
+		if total != tt.total || len(names) != len(tt.want) {
+			t.Errorf("%q page %d: got %v of %d, want %v of %d", tt.name, tt.page, names, total, tt.want, tt.total)
+			continue
+		}
+		for i := range names {
+			if names[i] != tt.want[i] {
+				t.Errorf("%q page %d: got %v, want %v", tt.name, tt.page, names, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestSubjectRepositoryKeepsSchoolsApart(t *testing.T) {
+	client := useTestDatabase(t)
+	useTestRedis(t)
+	schoolA := createTenant(t, client, "school-a")
+	schoolB := createTenant(t, client, "school-b")
+	repo := NewSubjectRepository(client, cache.GetRedisClient())
+
+	own, err := repo.CreateSubject(schoolA, entity.Subject{Name: "Math"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := repo.CreateSubject(schoolB, entity.Subject{Name: "History"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.GetSubjectByID(schoolB, other.ID, entity.Projection{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetSubjectByID(schoolA, other.ID, entity.Projection{}); !errors.Is(err, entity.ErrNotFound) {
+		t.Errorf("school A read the subject of school B: %v", err)
+	}
+	if subjects, err := repo.GetSubjectsByIDs(schoolA, []int{own.ID, other.ID}); err != nil || len(subjects) != 1 || subjects[0].ID != own.ID {
+		t.Errorf("school A got subjects %+v by ids: %v", subjects, err)
+	}
+	if subjects, err := repo.GetAllSubjects(schoolA, entity.Projection{}); err != nil || len(subjects) != 1 || subjects[0].ID != own.ID {
+		t.Errorf("school A listed subjects %+v: %v", subjects, err)
+	}
+	if _, err := repo.UpdateSubject(schoolA, other.ID, entity.SubjectPatch{Name: entity.NewNullable("Mallory")}); !errors.Is(err, entity.ErrNotFound) {
+		t.Errorf("school A updated the subject of school B: %v", err)
+	}
+	if err := repo.DeleteSubject(schoolA, other.ID); !errors.Is(err, entity.ErrNotFound) {
+		t.Errorf("school A deleted the subject of school B: %v", err)
+	}
+
+	stored, err := repo.GetSubjectByID(schoolB, other.ID, entity.Projection{})
+	if err != nil || stored.Name != "History" {
+		t.Errorf("subject of school B is %+v, %v after the writes of school A", stored, err)
+	}
+}
+
+func TestDeleteSubjectUnlinksItsMembers(t *testing.T) {
+	client := useTestDatabase(t)
+	useTestRedis(t)
+	ctx := createTenant(t, client, "school-a")
+	repo := NewSubjectRepository(client, cache.GetRedisClient())
+	users := NewUserRepository(client, cache.GetRedisClient())
+
+	subject, err := repo.CreateSubject(ctx, entity.Subject{Name: "Math"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	member, err := users.CreateUser(ctx, entity.User{Name: "Noy", Email: "noy@example.com", Password: "password1", SubjectID: subject.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// cached with its subject, the delete has to drop it
+	if _, err := users.GetUserByID(ctx, member.ID, entity.Projection{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.DeleteSubject(ctx, subject.ID); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := users.GetUserByID(ctx, member.ID, entity.Projection{})
+	if err != nil || stored.SubjectID != 0 {
+		t.Errorf("member is %+v, %v after its subject was deleted", stored, err)
+	}
+}
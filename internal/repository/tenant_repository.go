@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sample-project/internal/config/cache"
+	"sample-project/internal/entity"
+	"sample-project/prisma/db"
+	"time"
+)
+
+// cached for a host or API key no tenant has
+const noTenant = "none"
+
+// NOTE - tenant repository interface
+type TenantRepository interface {
+	GetAllTenants(ctx context.Context) ([]entity.Tenant, error)
+	GetTenantByHost(ctx context.Context, host string) (*entity.Tenant, error)
+	GetTenantByKeyHash(ctx context.Context, keyHash string) (*entity.Tenant, error)
+}
+
+// NOTE - tenant repository struct
+type tenantRepository struct {
+	client *db.PrismaClient
+}
+
+// NOTE - new tenant repository
+func NewTenantRepository(client *db.PrismaClient) TenantRepository {
+	return &tenantRepository{client: client}
+}
+
+// NOTE - every tenant, for the jobs that run once per school
+func (r *tenantRepository) GetAllTenants(ctx context.Context) ([]entity.Tenant, error) {
+	tenants, err := r.client.Tenant.FindMany().OrderBy(
+		db.Tenant.ID.Order(db.SortOrderAsc),
+	).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err, "tenant")
+	}
+
+	result := make([]entity.Tenant, 0, len(tenants))
+	for _, t := range tenants {
+		result = append(result, toTenantEntity(t))
+	}
+
+	return result, nil
+}
+
+// NOTE - tenant served on host. Every request resolves its host, so hosts of no school are cached as well
+func (r *tenantRepository) GetTenantByHost(ctx context.Context, host string) (*entity.Tenant, error) {
+	return r.cachedTenant(ctx, "host:"+host, db.Tenant.Host.Equals(host), true)
+}
+
+// NOTE - tenant of the API key with the given hash
+func (r *tenantRepository) GetTenantByKeyHash(ctx context.Context, keyHash string) (*entity.Tenant, error) {
+	// unknown keys are not remembered, anyone can send any number of them
+	return r.cachedTenant(ctx, "key:"+keyHash, db.Tenant.KeyHash.Equals(keyHash), false)
+}
+
+// NOTE - looks a tenant up in redis first and in the database after it, rememberMissing caches that there is none too.
+// Tenants are looked up before the request has one, so the key is shared by every tenant
+func (r *tenantRepository) cachedTenant(ctx context.Context, key string, where db.TenantEqualsUniqueWhereParam, rememberMissing bool) (*entity.Tenant, error) {
+	tenantCacheKey := cache.TENANT_CACHE_KEY + key
+
+	cachedTenant, err := cache.Get(ctx, tenantCacheKey)
+	if err == nil && cachedTenant == noTenant {
+		return nil, translateError(db.ErrNotFound, "tenant")
+	}
+	if err == nil && cachedTenant != "" {
+		var tenant entity.Tenant
+		if json.Unmarshal([]byte(cachedTenant), &tenant) == nil {
+			return &tenant, nil
+		}
+	}
+
+	tenant, err := r.client.Tenant.FindUnique(where).Exec(ctx)
+	if rememberMissing && errors.Is(err, db.ErrNotFound) {
+		cache.SetWithTTL(ctx, tenantCacheKey, noTenant, time.Duration(cache.TENANT_CACHE_KEY_TTL)*time.Second)
+	}
+	if err != nil {
+		return nil, translateError(err, "tenant")
+	}
+
+	result := toTenantEntity(*tenant)
+	tenantData, _ := json.Marshal(result)
+	cache.SetWithTTL(ctx, tenantCacheKey, string(tenantData), time.Duration(cache.TENANT_CACHE_KEY_TTL)*time.Second)
+
+	return &result, nil
+}
+
+// NOTE - maps a prisma tenant model to the tenant entity
+func toTenantEntity(t db.TenantModel) entity.Tenant {
+	tenant := entity.Tenant{
+		ID:        t.ID,
+		Slug:      t.Slug,
+		Name:      t.Name,
+		CreatedAt: t.CreatedAt,
+	}
+
+	if host, ok := t.Host(); ok {
+		tenant.Host = host
+	}
+
+	return tenant
+}
+
+// NOTE - tenant every query of ctx is scoped to. A query without one fails instead of reading across tenants
+func tenantOf(ctx context.Context) (int, error) {
+	id, ok := entity.TenantID(ctx)
+	if !ok {
+		return 0, entity.ErrTenantRequired
+	}
+	return id, nil
+}
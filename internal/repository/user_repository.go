@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sample-project/internal/config/cache"
@@ -16,15 +17,28 @@ import (
 
 // NOTE - user repository interface
 type UserRepository interface {
-	GetAllUsers(ctx context.Context, page, limit int, name string, startDate, endDate string) ([]entity.User, int, error)
-	GetUserByID(ctx context.Context, id int) (*entity.User, error)
+	GetAllUsers(ctx context.Context, page, limit int, name string, startDate, endDate string, state entity.UserState, projection entity.Projection) ([]entity.User, int, error)
+	GetUserByID(ctx context.Context, id int, projection entity.Projection) (*entity.User, error)
 	GetUserByName(ctx context.Context, name string) (*entity.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*entity.User, error)
+	GetUsersBySubjectIDs(ctx context.Context, subjectIDs []int) ([]entity.User, error)
 	CreateUser(ctx context.Context, user entity.User) (*entity.User, error)
-	UpdateUser(ctx context.Context, id int, user entity.User) (*entity.User, error)
-	DeleteUser(ctx context.Context, id int) error
+	UpdateUser(ctx context.Context, id int, versions []int, patch entity.UserPatch) (*entity.User, error)
+	UpdateUserAvatar(ctx context.Context, id int, url string) (*entity.User, error)
+	TransitionUserState(ctx context.Context, id int, from, to entity.UserState, reason string, reactivateAt *time.Time, actorID *int) (*entity.User, error)
+	GetUserStateTransitions(ctx context.Context, id int) ([]entity.UserStateTransition, error)
+	GetUsersDueForReactivation(ctx context.Context, now time.Time) ([]entity.User, error)
+	EraseUser(ctx context.Context, id int, anonymized entity.User) (*entity.User, error)
+	DeleteUser(ctx context.Context, id int, versions []int) error
 	ClearUserCache(ctx context.Context) error
 }
 
+// NOTE - a cached page of users with the count of every user matching its filters
+type userPage struct {
+	Users []entity.User `json:"users"`
+	Total int           `json:"total"`
+}
+
 // NOTE - user repository struct
 type userRepository struct {
 	client      *db.PrismaClient
@@ -37,22 +51,37 @@ func NewUserRepository(client *db.PrismaClient, redisClient *redis.Client) UserR
 }
 
 // NOTE - get all users repository
-func (r *userRepository) GetAllUsers(ctx context.Context, page, limit int, name string, startDate, endDate string) ([]entity.User, int, error) {
+func (r *userRepository) GetAllUsers(ctx context.Context, page, limit int, name string, startDate, endDate string, state entity.UserState, projection entity.Projection) ([]entity.User, int, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	offset := (page - 1) * limit
-	allUsersCacheKey := fmt.Sprintf("%sall_page%d_limit%d_name%s_start%s_end%s", cache.USER_CACHE_KEY, page, limit, name, startDate, endDate)
+	allUsersCacheKey := cache.ProjectedKey(fmt.Sprintf("%sall_page%d_limit%d_name%s_start%s_end%s_state%s", cache.USER_CACHE_KEY, page, limit, name, startDate, endDate, state), projection.Key())
 
 	// Check Redis Cache First
-	cachedUsers, err := r.redisClient.Get(ctx, allUsersCacheKey).Result()
+	cachedUsers, err := cache.Get(ctx, allUsersCacheKey)
 	if err == nil && cachedUsers != "" {
-		var users []entity.User
-		if json.Unmarshal([]byte(cachedUsers), &users) == nil {
-			return users, len(users), nil
+		var cached userPage
+		if json.Unmarshal([]byte(cachedUsers), &cached) == nil {
+			return cached.Users, cached.Total, nil
 		}
 	}
 
-	whereClause := []db.UserWhereParam{}
+	// the count runs the same filters in SQL, so the total covers every page and not only this one
+	whereClause := []db.UserWhereParam{db.User.TenantID.Equals(tenantID)}
+	conditions, params := []string{"tenant_id = $1"}, []any{tenantID}
 	if name != "" {
 		whereClause = append(whereClause, db.User.Name.Contains(name))
+		params = append(params, likePattern(name))
+		conditions = append(conditions, fmt.Sprintf("name LIKE $%d", len(params)))
+	}
+
+	if state != "" {
+		whereClause = append(whereClause, db.User.State.Equals(db.UserState(state)))
+		params = append(params, string(state))
+		conditions = append(conditions, fmt.Sprintf(`state = $%d::"UserState"`, len(params)))
 	}
 
 	if startDate != "" {
@@ -61,6 +90,8 @@ func (r *userRepository) GetAllUsers(ctx context.Context, page, limit int, name
 			// Ensure start time is the beginning of the day (00:00:00)
 			startTime = time.Date(startTime.Year(), startTime.Month(), startTime.Day(), 0, 0, 0, 0, time.UTC)
 			whereClause = append(whereClause, db.User.CreatedAt.Gte(startTime))
+			params = append(params, startTime)
+			conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(params)))
 		}
 	}
 
@@ -70,51 +101,57 @@ func (r *userRepository) GetAllUsers(ctx context.Context, page, limit int, name
 			// Ensure end time is the last second of the day (23:59:59)
 			endTime = time.Date(endTime.Year(), endTime.Month(), endTime.Day(), 23, 59, 59, 999999999, time.UTC)
 			whereClause = append(whereClause, db.User.CreatedAt.Lte(endTime))
+			params = append(params, endTime)
+			conditions = append(conditions, fmt.Sprintf("created_at <= $%d", len(params)))
 		}
 	}
 
 	// Fetch users from DB
-	users, err := r.client.User.FindMany(whereClause...).
+	query := r.client.User.FindMany(whereClause...).
 		Skip(offset).
 		Take(limit).
-		OrderBy(db.User.CreatedAt.Order(db.SortOrderDesc)).
-		Exec(ctx)
+		OrderBy(db.User.CreatedAt.Order(db.SortOrderDesc))
+	// select replaces the whole output, so relations are added after it
+	if len(projection.Fields) > 0 {
+		query = query.Select(selectColumns(userColumns, projection, userRequiredColumns)...)
+	}
+	if projection.Includes(entity.IncludeSubject) {
+		query = query.With(db.User.Subject.Fetch())
+	}
+
+	users, err := query.Exec(ctx)
+	if err != nil {
+		return nil, 0, translateError(err, "user")
+	}
+
+	total, err := countRows(ctx, r.client, "users", conditions, params...)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	var result []entity.User
 	for _, u := range users {
-		var subjectID int
-		if id, ok := u.SubjectID(); ok {
-			subjectID = id
-		}
-
-		result = append(result, entity.User{
-			ID:        u.ID,
-			Name:      u.Name,
-			Email:     u.Email,
-			Password:  u.Password,
-			SubjectID: subjectID,
-			Status:    u.Status,
-			CreatedAt: utils.FormatToVientianeTime(u.CreatedAt),
-			UpdatedAt: utils.FormatToVientianeTime(u.UpdatedAt),
-		})
+		result = append(result, toUserEntity(u))
 	}
 
 	// Store in Redis Cache
-	usersJSON, _ := json.Marshal(result)
-	r.redisClient.Set(ctx, allUsersCacheKey, string(usersJSON), time.Duration(cache.USER_CACHE_KEY_TTL)*time.Second)
+	usersJSON, _ := json.Marshal(userPage{Users: result, Total: total})
+	cache.SetWithTTL(ctx, allUsersCacheKey, string(usersJSON), time.Duration(cache.USER_CACHE_KEY_TTL)*time.Second)
 
-	return result, len(result), nil
+	return result, total, nil
 }
 
 // NOTE - get user by id repository
-func (r *userRepository) GetUserByID(ctx context.Context, id int) (*entity.User, error) {
-	userCacheKey := fmt.Sprintf("%s%d", cache.USER_CACHE_KEY, id)
+func (r *userRepository) GetUserByID(ctx context.Context, id int, projection entity.Projection) (*entity.User, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userCacheKey := cache.ProjectedKey(fmt.Sprintf("%s%d", cache.USER_CACHE_KEY, id), projection.Key())
 
 	// Check if user exists in cache
-	cachedUser, err := r.redisClient.Get(ctx, userCacheKey).Result()
+	cachedUser, err := cache.Get(ctx, userCacheKey)
 	if err == nil && cachedUser != "" {
 		var user entity.User
 		if json.Unmarshal([]byte(cachedUser), &user) == nil {
@@ -122,52 +159,76 @@ func (r *userRepository) GetUserByID(ctx context.Context, id int) (*entity.User,
 		}
 	}
 
-	user, err := r.client.User.FindUnique(
-		db.User.ID.Equals(id),
-	).Exec(ctx)
+	query := r.client.User.FindUnique(
+		userInTenant(id, tenantID),
+	)
+	if len(projection.Fields) > 0 {
+		query = query.Select(selectColumns(userColumns, projection, userRequiredColumns)...)
+	}
+	if projection.Includes(entity.IncludeSubject) {
+		query = query.With(db.User.Subject.Fetch())
+	}
+
+	user, err := query.Exec(ctx)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err, "user")
 	}
 
-	// Store in Redis cache with TTL
-	userData, _ := json.Marshal(user)
-	r.redisClient.Set(ctx, userCacheKey, string(userData), time.Duration(cache.USER_CACHE_KEY_TTL))
+	result := toUserEntity(*user)
+
+	// the entity is cached, so a hit decodes to the same user with its version for the ETag
+	userData, _ := json.Marshal(result)
+	cache.SetWithTTL(ctx, userCacheKey, string(userData), time.Duration(cache.USER_CACHE_KEY_TTL)*time.Second)
 
-	return &entity.User{
-		ID:        user.ID,
-		Name:      user.Name,
-		Email:     user.Email,
-		Password:  user.Password,
-		Status:    user.Status,
-		CreatedAt: utils.FormatToVientianeTime(user.CreatedAt),
-		UpdatedAt: utils.FormatToVientianeTime(user.UpdatedAt),
-	}, nil
+	return &result, nil
 }
 
 // NOTE - get user by email
 func (r *userRepository) GetUserByName(ctx context.Context, name string) (*entity.User, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	user, err := r.client.User.FindFirst(
+		db.User.TenantID.Equals(tenantID),
 		db.User.Name.Equals(name),
 	).Exec(ctx)
 	if err != nil {
-		slog.Error("Failed to fetch user by name", "name", name, "error", err)
+		slog.ErrorContext(ctx, "Failed to fetch user by name", "name", name, "error", err)
+		return nil, translateError(err, "user")
+	}
+
+	slog.InfoContext(ctx, "Fetched user by name", "name", name, "user_id", user.ID)
+	result := toUserEntity(*user)
+	return &result, nil
+}
+
+// NOTE - get user by email
+func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	slog.Info("Fetched user by name", "name", name, "user", user)
-	return &entity.User{
-		ID:        user.ID,
-		Name:      user.Name,
-		Email:     user.Email,
-		Password:  user.Password,
-		Status:    user.Status,
-		CreatedAt: utils.FormatToVientianeTime(user.CreatedAt),
-		UpdatedAt: utils.FormatToVientianeTime(user.UpdatedAt),
-	}, nil
+	user, err := r.client.User.FindUnique(
+		db.User.TenantIDEmail(db.User.TenantID.Equals(tenantID), db.User.Email.Equals(email)),
+	).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err, "user")
+	}
+
+	result := toUserEntity(*user)
+	return &result, nil
 }
 
 // NOTE - create user repository
 func (r *userRepository) CreateUser(ctx context.Context, user entity.User) (*entity.User, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Hash the password
 	hashedPassword, err := utils.HashPassword(user.Password)
 	if err != nil {
@@ -176,13 +237,13 @@ func (r *userRepository) CreateUser(ctx context.Context, user entity.User) (*ent
 
 	// Check if subject_id exists if it's provided and not zero
 	if user.SubjectID != 0 {
-		// Check if the subject exists
+		// Check if the subject exists, a subject of another school is as good as missing
 		subject, err := r.client.Subject.FindUnique(
-			db.Subject.ID.Equals(user.SubjectID),
+			subjectInTenant(user.SubjectID, tenantID),
 		).Exec(ctx)
 
 		if err != nil || subject == nil {
-			return nil, fmt.Errorf("subject with ID: %d not found", user.SubjectID)
+			return nil, notFoundByID("subject", user.SubjectID)
 		}
 	}
 
@@ -201,6 +262,7 @@ func (r *userRepository) CreateUser(ctx context.Context, user entity.User) (*ent
 		db.User.Day.Set(day),
 		db.User.Month.Set(month),
 		db.User.Year.Set(year),
+		db.User.Tenant.Link(db.Tenant.ID.Equals(tenantID)),
 		db.User.SubjectID.Set(user.SubjectID),
 		db.User.Status.Set(user.Status),
 		db.User.CreatedAt.Set(currentTime),
@@ -208,101 +270,465 @@ func (r *userRepository) CreateUser(ctx context.Context, user entity.User) (*ent
 	).Exec(ctx)
 
 	if err != nil {
-		return nil, err
+		return nil, translateError(err, "user")
 	}
 
 	// Clear cache after create
 	cache.DelWithPattern(ctx, fmt.Sprintf("%sall*", cache.USER_CACHE_KEY))
+	// subjects embed their users in the cached payload
+	cache.DelWithPattern(ctx, fmt.Sprintf("%s*", cache.SUBJECT_CACHE_KEY))
 
-	return &entity.User{
-		ID:        newUser.ID,
-		Name:      newUser.Name,
-		Email:     newUser.Email,
-		Password:  hashedPassword,
-		SubjectID: user.SubjectID,
-		Status:    user.Status,
-		Day:       newUser.Day,
-		Month:     newUser.Month,
-		Year:      newUser.Year,
-		CreatedAt: utils.FormatToVientianeTime(newUser.CreatedAt),
-		UpdatedAt: utils.FormatToVientianeTime(newUser.UpdatedAt),
-	}, nil
+	result := toUserEntity(*newUser)
+	publishChange(ctx, userEvent(entity.EventActionCreated, result))
+	return &result, nil
 }
 
-// NOTE - update user repository
-func (r *userRepository) UpdateUser(ctx context.Context, id int, user entity.User) (*entity.User, error) {
-	var updates []db.UserSetParam
+// NOTE - update user repository, only the members present in the patch are written.
+// When versions is not empty the write only happens if the stored version is one of them.
+func (r *userRepository) UpdateUser(ctx context.Context, id int, versions []int, patch entity.UserPatch) (*entity.User, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	updates = append(updates, db.User.Name.Set(user.Name))
-	updates = append(updates, db.User.Email.Set(user.Email))
-	updates = append(updates, db.User.Status.Set(user.Status))
-	updates = append(updates, db.User.UpdatedAt.Set(utils.FormatToVientianeTime(time.Now())))
+	updates, err := userPatchParams(patch)
+	if err != nil {
+		return nil, err
+	}
 
-	if user.SubjectID != 0 {
+	if patch.SubjectID.Null {
+		updates = append(updates, db.User.SubjectID.SetOptional(nil))
+	} else if patch.SubjectID.IsSet() {
 		subject, err := r.client.Subject.FindUnique(
-			db.Subject.ID.Equals(user.SubjectID),
+			subjectInTenant(patch.SubjectID.Value, tenantID),
 		).Exec(ctx)
 
 		if err != nil || subject == nil {
-			return nil, fmt.Errorf("subject with ID: %d not found", user.SubjectID)
+			return nil, notFoundByID("subject", patch.SubjectID.Value)
 		}
 
-		updates = append(updates, db.User.SubjectID.Set(user.SubjectID))
+		updates = append(updates, db.User.SubjectID.Set(patch.SubjectID.Value))
 	}
 
-	updateUser, err := r.client.User.FindUnique(
-		db.User.ID.Equals(id),
+	updates = append(updates, db.User.Version.Increment(1))
+	updates = append(updates, db.User.UpdatedAt.Set(utils.FormatToVientianeTime(time.Now())))
+
+	// the version check and the write happen in a single UPDATE ... WHERE statement
+	updated, err := r.client.User.FindMany(
+		versionedUserWhere(id, tenantID, versions)...,
 	).Update(
 		updates...,
 	).Exec(ctx)
 
 	if err != nil {
-		return nil, err
+		return nil, translateError(err, "user")
+	}
+
+	if updated.Count == 0 {
+		return nil, r.missingOrStale(ctx, id, tenantID)
+	}
+
+	updateUser, err := r.client.User.FindUnique(
+		userInTenant(id, tenantID),
+	).Exec(ctx)
+
+	if err != nil {
+		return nil, translateError(err, "user")
 	}
 
 	// clear cache after updating
 	userCacheKey := fmt.Sprintf("%s%d", cache.USER_CACHE_KEY, id)
-	cache.Del(ctx, userCacheKey)
+	cache.DelWithProjections(ctx, userCacheKey)
 	cache.DelWithPattern(ctx, fmt.Sprintf("%sall*", cache.USER_CACHE_KEY))
+	// subjects embed their users in the cached payload
+	cache.DelWithPattern(ctx, fmt.Sprintf("%s*", cache.SUBJECT_CACHE_KEY))
 
-	return &entity.User{
-		ID:        updateUser.ID,
-		Name:      updateUser.Name,
-		Email:     updateUser.Email,
-		SubjectID: user.SubjectID,
-		Status:    updateUser.Status,
-		CreatedAt: utils.FormatToVientianeTime(updateUser.CreatedAt),
-		UpdatedAt: utils.FormatToVientianeTime(updateUser.UpdatedAt),
-	}, nil
+	result := toUserEntity(*updateUser)
+	publishChange(ctx, userEvent(entity.EventActionUpdated, result))
+	return &result, nil
 }
 
-// NOTE - delete user repository
-func (r *userRepository) DeleteUser(ctx context.Context, id int) error {
-	_, err := r.client.User.FindUnique(
+// NOTE - update user avatar repository
+func (r *userRepository) UpdateUserAvatar(ctx context.Context, id int, url string) (*entity.User, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	updateUser, err := r.client.User.FindUnique(
+		userInTenant(id, tenantID),
+	).Update(
+		db.User.AvatarURL.Set(url),
+		db.User.Version.Increment(1),
+		db.User.UpdatedAt.Set(utils.FormatToVientianeTime(time.Now())),
+	).Exec(ctx)
+
+	if err != nil {
+		return nil, translateError(err, "user")
+	}
+
+	userCacheKey := fmt.Sprintf("%s%d", cache.USER_CACHE_KEY, id)
+	cache.DelWithProjections(ctx, userCacheKey)
+	cache.DelWithPattern(ctx, fmt.Sprintf("%sall*", cache.USER_CACHE_KEY))
+	// subjects embed their users in the cached payload
+	cache.DelWithPattern(ctx, fmt.Sprintf("%s*", cache.SUBJECT_CACHE_KEY))
+
+	result := toUserEntity(*updateUser)
+	publishChange(ctx, userEvent(entity.EventActionUpdated, result))
+	return &result, nil
+}
+
+// NOTE - moves a user from one lifecycle state to another and records the transition.
+// The update only applies while the user is still in the from state, and the audit row
+// is written by the same statement so the two can never disagree.
+func (r *userRepository) TransitionUserState(ctx context.Context, id int, from, to entity.UserState, reason string, reactivateAt *time.Time, actorID *int) (*entity.User, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var suspendedReason, transitionReason, reactivate, actor interface{}
+	if reason != "" {
+		transitionReason = reason
+		if to == entity.UserStateSuspended {
+			suspendedReason = reason
+		}
+	}
+	if reactivateAt != nil {
+		reactivate = reactivateAt.UTC().Format(time.RFC3339)
+	}
+	if actorID != nil {
+		actor = *actorID
+	}
+
+	inserted, err := r.client.Prisma.ExecuteRaw(`
+		WITH updated AS (
+			UPDATE users
+			SET state = $1::"UserState",
+				status = $2,
+				suspended_reason = $3,
+				reactivate_at = $4::timestamptz,
+				version = version + 1,
+				updated_at = now()
+			WHERE id = $5 AND state = $6::"UserState" AND tenant_id = $9
+			RETURNING id
+		)
+		INSERT INTO user_state_transitions (user_id, from_state, to_state, reason, actor_id, created_at)
+		SELECT id, $6::"UserState", $1::"UserState", $7, $8, now() FROM updated`,
+		string(to), to == entity.UserStateActive, suspendedReason, reactivate, id, string(from), transitionReason, actor, tenantID,
+	).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err, "user")
+	}
+
+	if inserted.Count == 0 {
+		if _, err := r.client.User.FindUnique(userInTenant(id, tenantID)).Exec(ctx); errors.Is(err, db.ErrNotFound) {
+			return nil, notFoundByID("user", id)
+		}
+		return nil, entity.ErrInvalidStateTransition.With("State", from).Localized("invalid_state_transition.stale").
+			Explain("user is no longer %s", from)
+	}
+
+	userCacheKey := fmt.Sprintf("%s%d", cache.USER_CACHE_KEY, id)
+	cache.DelWithProjections(ctx, userCacheKey)
+	cache.DelWithPattern(ctx, fmt.Sprintf("%sall*", cache.USER_CACHE_KEY))
+	// subjects embed their users in the cached payload
+	cache.DelWithPattern(ctx, fmt.Sprintf("%s*", cache.SUBJECT_CACHE_KEY))
+
+	user, err := r.client.User.FindUnique(
+		userInTenant(id, tenantID),
+	).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err, "user")
+	}
+
+	result := toUserEntity(*user)
+	publishChange(ctx, userEvent(entity.EventActionUpdated, result))
+	return &result, nil
+}
+
+// NOTE - lifecycle history of a user, newest first
+func (r *userRepository) GetUserStateTransitions(ctx context.Context, id int) ([]entity.UserStateTransition, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	transitions, err := r.client.UserStateTransition.FindMany(
+		db.UserStateTransition.UserID.Equals(id),
+		db.UserStateTransition.User.Where(db.User.TenantID.Equals(tenantID)),
+	).OrderBy(
+		db.UserStateTransition.CreatedAt.Order(db.SortOrderDesc),
+	).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err, "user")
+	}
+
+	result := []entity.UserStateTransition{}
+	for _, t := range transitions {
+		transition := entity.UserStateTransition{
+			ID:        t.ID,
+			UserID:    t.UserID,
+			FromState: entity.UserState(t.FromState),
+			ToState:   entity.UserState(t.ToState),
+			CreatedAt: utils.FormatToVientianeTime(t.CreatedAt),
+		}
+		if reason, ok := t.Reason(); ok {
+			transition.Reason = reason
+		}
+		if actorID, ok := t.ActorID(); ok {
+			transition.ActorID = &actorID
+		}
+		result = append(result, transition)
+	}
+
+	return result, nil
+}
+
+// NOTE - members of the given subjects in one query, for batched loading
+func (r *userRepository) GetUsersBySubjectIDs(ctx context.Context, subjectIDs []int) ([]entity.User, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := r.client.User.FindMany(
+		db.User.TenantID.Equals(tenantID),
+		db.User.SubjectID.In(subjectIDs),
+	).OrderBy(
+		db.User.ID.Order(db.SortOrderAsc),
+	).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err, "user")
+	}
+
+	result := make([]entity.User, 0, len(users))
+	for _, u := range users {
+		result = append(result, toUserEntity(u))
+	}
+
+	return result, nil
+}
+
+// NOTE - suspended users of the tenant whose automatic reactivation time has passed
+func (r *userRepository) GetUsersDueForReactivation(ctx context.Context, now time.Time) ([]entity.User, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := r.client.User.FindMany(
+		db.User.TenantID.Equals(tenantID),
+		db.User.State.Equals(db.UserStateSuspended),
+		db.User.ReactivateAt.Lte(now),
+	).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err, "user")
+	}
+
+	var result []entity.User
+	for _, u := range users {
+		result = append(result, toUserEntity(u))
+	}
+
+	return result, nil
+}
+
+// NOTE - anonymizes a user in place so foreign keys and reports keep working, then purges every cached copy
+func (r *userRepository) EraseUser(ctx context.Context, id int, anonymized entity.User) (*entity.User, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	erasedAt := utils.FormatToVientianeTime(time.Now())
+
+	userUpdate := r.client.User.FindMany(
 		db.User.ID.Equals(id),
+		db.User.TenantID.Equals(tenantID),
+		db.User.ErasedAt.IsNull(),
+	).Update(
+		db.User.Name.Set(anonymized.Name),
+		db.User.Email.Set(anonymized.Email),
+		db.User.Password.Set(anonymized.Password),
+		db.User.AvatarURL.SetOptional(nil),
+		db.User.SuspendedReason.SetOptional(nil),
+		db.User.ReactivateAt.SetOptional(nil),
+		db.User.Status.Set(false),
+		db.User.ErasedAt.Set(erasedAt),
+		db.User.Version.Increment(1),
+		db.User.UpdatedAt.Set(erasedAt),
+	).Tx()
+
+	// free text reasons can hold personal data, the transitions themselves stay for the audit trail
+	transitionsUpdate := r.client.UserStateTransition.FindMany(
+		db.UserStateTransition.UserID.Equals(id),
+		db.UserStateTransition.User.Where(db.User.TenantID.Equals(tenantID)),
+	).Update(
+		db.UserStateTransition.Reason.SetOptional(nil),
+	).Tx()
+
+	if err := r.client.Prisma.Transaction(userUpdate, transitionsUpdate).Exec(ctx); err != nil {
+		return nil, translateError(err, "user")
+	}
+
+	if userUpdate.Result().Count == 0 {
+		if _, err := r.client.User.FindUnique(userInTenant(id, tenantID)).Exec(ctx); errors.Is(err, db.ErrNotFound) {
+			return nil, notFoundByID("user", id)
+		}
+		return nil, entity.ErrAlreadyErased
+	}
+
+	userCacheKey := fmt.Sprintf("%s%d", cache.USER_CACHE_KEY, id)
+	cache.DelWithProjections(ctx, userCacheKey)
+	cache.DelWithPattern(ctx, fmt.Sprintf("%sall*", cache.USER_CACHE_KEY))
+	// subjects embed their users in the cached payload
+	cache.DelWithPattern(ctx, fmt.Sprintf("%s*", cache.SUBJECT_CACHE_KEY))
+
+	user, err := r.client.User.FindUnique(
+		userInTenant(id, tenantID),
+	).Exec(ctx)
+	if err != nil {
+		return nil, translateError(err, "user")
+	}
+
+	result := toUserEntity(*user)
+	publishChange(ctx, userEvent(entity.EventActionUpdated, result))
+	return &result, nil
+}
+
+// NOTE - delete user repository
+func (r *userRepository) DeleteUser(ctx context.Context, id int, versions []int) error {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.client.User.FindMany(
+		versionedUserWhere(id, tenantID, versions)...,
 	).Delete().Exec(ctx)
 
 	userCacheKey := fmt.Sprintf("%s%d", cache.USER_CACHE_KEY, id)
-	cache.Del(ctx, userCacheKey)
+	cache.DelWithProjections(ctx, userCacheKey)
 	cache.DelWithPattern(ctx, fmt.Sprintf("%sall*", cache.USER_CACHE_KEY))
+	// subjects embed their users in the cached payload
+	cache.DelWithPattern(ctx, fmt.Sprintf("%s*", cache.SUBJECT_CACHE_KEY))
 
-	return err
+	if err != nil {
+		return translateError(err, "user")
+	}
+
+	if result.Count == 0 {
+		return r.missingOrStale(ctx, id, tenantID)
+	}
+
+	publishChange(ctx, entity.NewChangeEvent(entity.EventResourceUser, entity.EventActionDeleted, id, nil))
+	return nil
 }
 
-// NOTE - clear user cache repository
-func (r *userRepository) ClearUserCache(ctx context.Context) error {
-	pattern := fmt.Sprintf("%s*", cache.USER_CACHE_KEY)
-	iter := r.redisClient.Scan(ctx, 0, pattern, 0).Iterator()
+// NOTE - writes for the scalar members of a user patch, the subject is left to the caller
+func userPatchParams(patch entity.UserPatch) ([]db.UserSetParam, error) {
+	var updates []db.UserSetParam
 
-	for iter.Next(ctx) {
-		if err := r.redisClient.Del(ctx, iter.Val()).Err(); err != nil {
-			return err
+	if patch.Name.IsSet() {
+		updates = append(updates, db.User.Name.Set(patch.Name.Value))
+	}
+	if patch.Email.IsSet() {
+		updates = append(updates, db.User.Email.Set(patch.Email.Value))
+	}
+	if patch.Password.IsSet() {
+		hashedPassword, err := utils.HashPassword(patch.Password.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %v", err)
 		}
+		updates = append(updates, db.User.Password.Set(hashedPassword))
+	}
+	if patch.Status.IsSet() {
+		updates = append(updates, db.User.Status.Set(patch.Status.Value))
+	}
+
+	return updates, nil
+}
+
+// NOTE - unique where of a user of the tenant
+func userInTenant(id, tenantID int) db.UserEqualsUniqueWhereParam {
+	return db.User.IDTenantID(db.User.ID.Equals(id), db.User.TenantID.Equals(tenantID))
+}
+
+// NOTE - where clause matching a user id of the tenant and, if given, one of the expected versions
+func versionedUserWhere(id, tenantID int, versions []int) []db.UserWhereParam {
+	where := []db.UserWhereParam{db.User.ID.Equals(id), db.User.TenantID.Equals(tenantID)}
+	if len(versions) > 0 {
+		where = append(where, db.User.Version.In(versions))
+	}
+	return where
+}
+
+// NOTE - maps a prisma user model to the user entity
+func toUserEntity(u db.UserModel) entity.User {
+	user := entity.User{
+		ID:        u.ID,
+		Name:      u.Name,
+		Email:     u.Email,
+		Password:  u.Password,
+		Status:    u.Status,
+		State:     entity.UserState(u.State),
+		Version:   u.Version,
+		Day:       u.Day,
+		Month:     u.Month,
+		Year:      u.Year,
+		CreatedAt: utils.FormatToVientianeTime(u.CreatedAt),
+		UpdatedAt: utils.FormatToVientianeTime(u.UpdatedAt),
+	}
+
+	if url, ok := u.AvatarURL(); ok {
+		user.AvatarURL = url
+	}
+	if subjectID, ok := u.SubjectID(); ok {
+		user.SubjectID = subjectID
+	}
+	if reason, ok := u.SuspendedReason(); ok {
+		user.SuspendedReason = reason
+	}
+	if reactivateAt, ok := u.ReactivateAt(); ok {
+		t := utils.FormatToVientianeTime(reactivateAt)
+		user.ReactivateAt = &t
+	}
+	if erasedAt, ok := u.ErasedAt(); ok {
+		t := utils.FormatToVientianeTime(erasedAt)
+		user.ErasedAt = &t
+	}
+	// only set when the subject was fetched
+	if subject, ok := u.Subject(); ok {
+		s := toSubjectEntity(*subject)
+		user.Subject = &s
 	}
 
-	if err := iter.Err(); err != nil {
+	return user
+}
+
+// NOTE - tells apart a missing user from a version mismatch after a conditional write touched no rows
+func (r *userRepository) missingOrStale(ctx context.Context, id, tenantID int) error {
+	_, err := r.client.User.FindUnique(
+		userInTenant(id, tenantID),
+	).Exec(ctx)
+
+	if errors.Is(err, db.ErrNotFound) {
+		return notFoundByID("user", id)
+	}
+	if err != nil {
+		return translateError(err, "user")
+	}
+
+	return entity.ErrVersionMismatch
+}
+
+// NOTE - clear user cache repository
+func (r *userRepository) ClearUserCache(ctx context.Context) error {
+	if _, err := tenantOf(ctx); err != nil {
 		return err
 	}
 
-	return nil
+	// only the users the tenant cached, the pattern is namespaced like every other key
+	return cache.DelWithPattern(ctx, fmt.Sprintf("%s*", cache.USER_CACHE_KEY))
 }
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sample-project/internal/config/cache"
+	"sample-project/internal/entity"
+	"testing"
+	"time"
+)
+
+func TestGetUserByIDServesCachedEntity(t *testing.T) {
+	redis := useTestRedis(t)
+	ctx := entity.WithTenant(context.Background(), 1)
+
+	cached, _ := json.Marshal(entity.User{ID: 5, Name: "Noy", Version: 3})
+	redis.Set("tenant:1:users:5", string(cached))
+
+	// no prisma client, a cache miss would panic
+	user, err := NewUserRepository(nil, nil).GetUserByID(ctx, 5, entity.Projection{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != "Noy" || user.Version != 3 {
+		t.Errorf("got %+v from the cache", user)
+	}
+}
+
+func TestGetUserByIDCachesUntilVersionChanges(t *testing.T) {
+	client := useTestDatabase(t)
+	redis := useTestRedis(t)
+	ctx := createTenant(t, client, "school-a")
+	repo := NewUserRepository(client, cache.GetRedisClient())
+
+	created, err := repo.CreateUser(ctx, entity.User{Name: "Noy", Email: "noy@example.com", Password: "password1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenantID, _ := entity.TenantID(ctx)
+	key := fmt.Sprintf("tenant:%d:users:%d", tenantID, created.ID)
+
+	assertCached := func(version int) {
+		t.Helper()
+		if _, err := repo.GetUserByID(ctx, created.ID, entity.Projection{}); err != nil {
+			t.Fatal(err)
+		}
+		raw, err := redis.Get(key)
+		if err != nil {
+			t.Fatalf("user is not cached: %v", err)
+		}
+		var user entity.User
+		if err := json.Unmarshal([]byte(raw), &user); err != nil || user.ID != created.ID || user.Version != version {
+			t.Errorf("cached %s, want the entity at version %d", raw, version)
+		}
+		if ttl := redis.TTL(key); ttl != time.Duration(cache.USER_CACHE_KEY_TTL)*time.Second {
+			t.Errorf("cache TTL is %v", ttl)
+		}
+	}
+	assertDropped := func(write string) {
+		t.Helper()
+		if redis.Exists(key) {
+			t.Errorf("user is still cached after %s", write)
+		}
+	}
+
+	assertCached(1)
+
+	if _, err := repo.UpdateUser(ctx, created.ID, []int{1}, entity.UserPatch{Name: entity.NewNullable("Noy P.")}); err != nil {
+		t.Fatal(err)
+	}
+	assertDropped("UpdateUser")
+	assertCached(2)
+
+	if _, err := repo.TransitionUserState(ctx, created.ID, entity.UserStatePending, entity.UserStateActive, "", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	assertDropped("TransitionUserState")
+	assertCached(3)
+
+	if err := repo.DeleteUser(ctx, created.ID, []int{3}); err != nil {
+		t.Fatal(err)
+	}
+	assertDropped("DeleteUser")
+}
+
+func TestGetAllUsersServesCachedTotal(t *testing.T) {
+	redis := useTestRedis(t)
+	ctx := entity.WithTenant(context.Background(), 1)
+
+	cached, _ := json.Marshal(userPage{Users: []entity.User{{ID: 3, Name: "Noy"}}, Total: 7})
+	redis.Set("tenant:1:users:all_page2_limit1_name_start_end_state", string(cached))
+
+	// no prisma client, a cache miss would panic
+	users, total, err := NewUserRepository(nil, nil).GetAllUsers(ctx, 2, 1, "", "", "", "", entity.Projection{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || total != 7 {
+		t.Errorf("got %d users of %d, want the cached page of 7", len(users), total)
+	}
+}
+
+func TestGetAllUsersCountsEveryPage(t *testing.T) {
+	client := useTestDatabase(t)
+	useTestRedis(t)
+	ctx := createTenant(t, client, "school-a")
+	other := createTenant(t, client, "school-b")
+	repo := NewUserRepository(client, cache.GetRedisClient())
+
+	for i, name := range []string{"Noy", "Noa", "Dan", "Noga_"} {
+		if _, err := repo.CreateUser(ctx, entity.User{Name: name, Email: fmt.Sprintf("user%d@example.com", i), Password: "password1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repo.CreateUser(other, entity.User{Name: "Noam", Email: "noam@example.com", Password: "password1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		page  int
+		users int
+		total int
+	}{
+		{name: "", page: 1, users: 2, total: 4},
+		{name: "", page: 2, users: 2, total: 4},
+		{name: "", page: 3, users: 0, total: 4},
+		{name: "No", page: 1, users: 2, total: 3},
+		{name: "_", page: 1, users: 1, total: 1},
+	}
+
+	for _, tt := range tests {
+		users, total, err := repo.GetAllUsers(ctx, tt.page, 2, tt.name, "", "", "", entity.Projection{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != tt.users || total != tt.total {
+			t.Errorf("name %q page %d: got %d users of %d, want %d of %d", tt.name, tt.page, len(users), total, tt.users, tt.total)
+		}
+	}
+}
+
+func TestUserRepositoryKeepsSchoolsApart(t *testing.T) {
+	client := useTestDatabase(t)
+	useTestRedis(t)
+	schoolA := createTenant(t, client, "school-a")
+	schoolB := createTenant(t, client, "school-b")
+	repo := NewUserRepository(client, cache.GetRedisClient())
+	subjects := NewSubjectRepository(client, cache.GetRedisClient())
+
+	own, err := repo.CreateUser(schoolA, entity.User{Name: "Noy", Email: "noy@example.com", Password: "password1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := repo.CreateUser(schoolB, entity.User{Name: "Dan", Email: "dan@example.com", Password: "password1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSubject, err := subjects.CreateSubject(schoolB, entity.Subject{Name: "History"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// read through the cache too, a user cached by school B must not be served to school A
+	if _, err := repo.GetUserByID(schoolB, other.ID, entity.Projection{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetUserByID(schoolA, other.ID, entity.Projection{}); !errors.Is(err, entity.ErrNotFound) {
+		t.Errorf("school A read the user of school B: %v", err)
+	}
+	if _, err := repo.GetUserByEmail(schoolA, other.Email); !errors.Is(err, entity.ErrNotFound) {
+		t.Errorf("school A found the user of school B by email: %v", err)
+	}
+	if users, total, err := repo.GetAllUsers(schoolA, 1, 10, "", "", "", "", entity.Projection{}); err != nil || total != 1 || len(users) != 1 || users[0].ID != own.ID {
+		t.Errorf("school A listed %+v of %d: %v", users, total, err)
+	}
+	if _, err := repo.UpdateUser(schoolA, other.ID, nil, entity.UserPatch{Name: entity.NewNullable("Mallory")}); !errors.Is(err, entity.ErrNotFound) {
+		t.Errorf("school A updated the user of school B: %v", err)
+	}
+	if _, err := repo.UpdateUser(schoolA, own.ID, nil, entity.UserPatch{SubjectID: entity.NewNullable(otherSubject.ID)}); !errors.Is(err, entity.ErrNotFound) {
+		t.Errorf("school A linked a subject of school B: %v", err)
+	}
+	if err := repo.DeleteUser(schoolA, other.ID, nil); !errors.Is(err, entity.ErrNotFound) {
+		t.Errorf("school A deleted the user of school B: %v", err)
+	}
+
+	stored, err := repo.GetUserByID(schoolB, other.ID, entity.Projection{})
+	if err != nil || stored.Name != "Dan" || stored.Version != 1 {
+		t.Errorf("user of school B is %+v, %v after the writes of school A", stored, err)
+	}
+}
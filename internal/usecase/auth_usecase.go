@@ -6,12 +6,20 @@ import (
 	"sample-project/internal/entity"
 	"sample-project/internal/repository"
 	"sample-project/internal/utils"
+	"strings"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	errInvalidCredentials  = entity.Unauthorized("invalid_credentials", "invalid credentials")
+	errInvalidRefreshToken = entity.Unauthorized("invalid_refresh_token", "invalid refresh token or expired refresh token")
+)
+
 type AuthUseCase interface {
 	Login(ctx context.Context, name, password string) (string, string, error)
+	Refresh(ctx context.Context, refreshToken string) (string, string, error)
 	GetUserProfile(ctx context.Context, token string) (*entity.User, error)
 }
 
@@ -25,15 +33,64 @@ func NewAuthUsecase(userRepo repository.UserRepository) AuthUseCase {
 
 func (u *authUsecase) Login(ctx context.Context, name, password string) (string, string, error) {
 	user, err := u.userRepo.GetUserByName(ctx, name)
+	if errors.Is(err, entity.ErrNotFound) {
+		return "", "", errInvalidCredentials
+	}
 	if err != nil {
-		return "", "", errors.New("invalid credentials")
+		return "", "", err
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", "", errors.New("invalid credentials")
+		return "", "", errInvalidCredentials
+	}
+
+	// the reactivation worker may not have run yet
+	if user.State == entity.UserStateSuspended && user.ReactivateAt != nil && !user.ReactivateAt.After(time.Now()) {
+		if reactivated, err := u.userRepo.TransitionUserState(ctx, user.ID, entity.UserStateSuspended, entity.UserStateActive, "automatic reactivation", nil, nil); err == nil {
+			user = reactivated
+		}
+	}
+
+	return issueTokens(ctx, user)
+}
+
+// NOTE - trades a refresh token for a new token pair, as long as the account is still active
+func (u *authUsecase) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
+	claims, err := utils.ValidateToken(refreshToken, true)
+	if err != nil {
+		return "", "", errInvalidRefreshToken
+	}
+
+	// the refresh token names its school, a request sent to another school's host or key is refused
+	if tenantID, ok := entity.TenantID(ctx); ok && tenantID != claims.TenantID {
+		return "", "", errInvalidRefreshToken
+	}
+	ctx = entity.WithTenant(ctx, claims.TenantID)
+
+	user, err := u.userRepo.GetUserByID(ctx, claims.UserID, entity.Projection{})
+	if errors.Is(err, entity.ErrNotFound) || errors.Is(err, entity.ErrTenantRequired) {
+		return "", "", errInvalidRefreshToken
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	return issueTokens(ctx, user)
+}
+
+// NOTE - token pair of an active user, bound to the tenant the user was found in
+func issueTokens(ctx context.Context, user *entity.User) (string, string, error) {
+	if user.State != entity.UserStateActive {
+		state := strings.ToLower(string(user.State))
+		return "", "", entity.ErrAccountNotActive.With("State", state).Explain("account is %s", state)
+	}
+
+	tenantID, ok := entity.TenantID(ctx)
+	if !ok {
+		return "", "", entity.ErrTenantRequired
 	}
 
-	accessToken, refreshToken, err := utils.GenerateToken(user.ID, user.Name)
+	accessToken, refreshToken, err := utils.GenerateToken(user.ID, tenantID, user.Name)
 	if err != nil {
 		return "", "", err
 	}
@@ -44,12 +101,12 @@ func (u *authUsecase) Login(ctx context.Context, name, password string) (string,
 func (u *authUsecase) GetUserProfile(ctx context.Context, token string) (*entity.User, error) {
 	claims, err := utils.ValidateToken(token, false)
 	if err != nil {
-		return nil, errors.New("invalid token or expired token")
+		return nil, entity.Unauthorized("invalid_token", "invalid token or expired token")
 	}
 
-	user, err := u.userRepo.GetUserByID(ctx, claims.UserID)
+	user, err := u.userRepo.GetUserByID(ctx, claims.UserID, entity.Projection{})
 	if err != nil {
-		return nil, errors.New("user not found")
+		return nil, err
 	}
 
 	return user, nil
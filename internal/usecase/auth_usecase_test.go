@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sample-project/internal/entity"
+	"sample-project/internal/repository"
+	"sample-project/internal/utils"
+	"testing"
+	"time"
+)
+
+// lifecycleUserRepo keeps users and the audit log of their transitions in memory, like the user repository
+// it refuses a transition from a state the user has left
+type lifecycleUserRepo struct {
+	repository.UserRepository
+	users       map[int]*entity.User
+	transitions []entity.UserStateTransition
+}
+
+func (r *lifecycleUserRepo) GetUserByID(ctx context.Context, id int, projection entity.Projection) (*entity.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, entity.NotFound("user_not_found", "user with ID: %d not found", id)
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *lifecycleUserRepo) GetUserByName(ctx context.Context, name string) (*entity.User, error) {
+	for _, user := range r.users {
+		if user.Name == name {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, entity.ErrNotFound
+}
+
+func (r *lifecycleUserRepo) TransitionUserState(ctx context.Context, id int, from, to entity.UserState, reason string, reactivateAt *time.Time, actorID *int) (*entity.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, entity.NotFound("user_not_found", "user with ID: %d not found", id)
+	}
+	if user.State != from {
+		return nil, entity.ErrInvalidStateTransition
+	}
+
+	user.State, user.ReactivateAt = to, reactivateAt
+	r.transitions = append(r.transitions, entity.UserStateTransition{UserID: id, FromState: from, ToState: to, Reason: reason, ActorID: actorID})
+	copied := *user
+	return &copied, nil
+}
+
+func (r *lifecycleUserRepo) GetUsersDueForReactivation(ctx context.Context, now time.Time) ([]entity.User, error) {
+	var due []entity.User
+	for _, user := range r.users {
+		if user.State == entity.UserStateSuspended && user.ReactivateAt != nil && !user.ReactivateAt.After(now) {
+			due = append(due, *user)
+		}
+	}
+	return due, nil
+}
+
+func newLifecycleUserRepo(t *testing.T, users ...entity.User) *lifecycleUserRepo {
+	t.Helper()
+	hash, err := utils.HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &lifecycleUserRepo{users: map[int]*entity.User{}}
+	for _, user := range users {
+		user.Password = hash
+		repo.users[user.ID] = &user
+	}
+	return repo
+}
+
+func TestSuspendedUsersCannotLogInOrRefresh(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("JWT_REFRESH_SECRET", "test-refresh-secret")
+	ctx := entity.WithTenant(context.Background(), 1)
+	repo := newLifecycleUserRepo(t, entity.User{ID: 1, Name: "Noy", State: entity.UserStateActive})
+	auth := NewAuthUsecase(repo)
+
+	_, refreshToken, err := auth.Login(ctx, "Noy", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actor := 9
+	if _, err := NewUserUsecase(repo, nil).TransitionState(ctx, 1, entity.TransitionUserStateRequest{State: entity.UserStateSuspended, Reason: "late fees"}, &actor); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := auth.Login(ctx, "Noy", "secret"); !errors.Is(err, entity.ErrAccountNotActive) {
+		t.Errorf("login while suspended: got %v, want account_not_active", err)
+	}
+	if _, _, err := auth.Refresh(ctx, refreshToken); !errors.Is(err, entity.ErrAccountNotActive) {
+		t.Errorf("refresh while suspended: got %v, want account_not_active", err)
+	}
+}
+
+func TestLoginReactivatesAnOverdueSuspension(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	ctx := entity.WithTenant(context.Background(), 1)
+	due := time.Now().Add(-time.Minute)
+	repo := newLifecycleUserRepo(t, entity.User{ID: 1, Name: "Noy", State: entity.UserStateSuspended, ReactivateAt: &due})
+
+	if _, _, err := NewAuthUsecase(repo).Login(ctx, "Noy", "secret"); err != nil {
+		t.Fatalf("login after the suspension ended: %v", err)
+	}
+	if len(repo.transitions) != 1 || repo.transitions[0].ToState != entity.UserStateActive || repo.transitions[0].ActorID != nil {
+		t.Errorf("transitions are %+v, want one automatic reactivation", repo.transitions)
+	}
+}
+
+func TestAccessTokensLiveAtMostTheMaximumLifetime(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("JWT_EXPIRATION_TIME", "24h")
+
+	token, _, err := utils.GenerateToken(1, 1, "Noy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := utils.ValidateToken(token, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a suspended user keeps a token issued before at most this long
+	if lifetime := time.Until(claims.ExpiresAt.Time); lifetime > utils.MaxAccessTokenLifetime {
+		t.Errorf("access token lives %s, want at most %s", lifetime, utils.MaxAccessTokenLifetime)
+	}
+}
@@ -0,0 +1,356 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sample-project/internal/entity"
+	"sample-project/internal/repository"
+	"sample-project/internal/validation"
+	"strings"
+)
+
+// NOTE - batch use case interface
+type BatchUseCase interface {
+	ExecuteBatch(ctx context.Context, operations []entity.BatchOperation) ([]entity.BatchResult, error)
+}
+
+// NOTE - batch use case struct
+type batchUseCase struct {
+	repo repository.BatchRepository
+}
+
+// NOTE - new batch use case
+func NewBatchUseCase(repo repository.BatchRepository) BatchUseCase {
+	return &batchUseCase{repo: repo}
+}
+
+// NOTE - checks every operation, then writes them all in one transaction. When anything
+// fails nothing is written and the error lists every failed operation
+func (u *batchUseCase) ExecuteBatch(ctx context.Context, operations []entity.BatchOperation) ([]entity.BatchResult, error) {
+	plan := batchPlan{created: map[string]string{}, emails: map[string]bool{}}
+
+	steps := make([]entity.BatchStep, 0, len(operations))
+	var fields []entity.FieldError
+	for i, operation := range operations {
+		step, err := plan.step(ctx, operation)
+		if err != nil {
+			opFields, ok := operationFields(i, err)
+			if !ok {
+				return nil, err
+			}
+			fields = append(fields, opFields...)
+			continue
+		}
+		steps = append(steps, step)
+	}
+
+	if len(fields) > 0 {
+		return nil, batchFailed(fields)
+	}
+
+	results, err := u.repo.ExecuteBatch(ctx, steps)
+	if err != nil {
+		return nil, batchError(err)
+	}
+
+	return results, nil
+}
+
+// batchPlan is what the operations checked so far tell about the later ones
+type batchPlan struct {
+	// resource of every create ref
+	created map[string]string
+	// emails the batch gives to users, they have to stay unique within the batch too
+	emails map[string]bool
+}
+
+// NOTE - checks an operation against the ones before it and decodes its body
+func (p *batchPlan) step(ctx context.Context, operation entity.BatchOperation) (entity.BatchStep, error) {
+	if err := validation.Struct(ctx, &operation); err != nil {
+		return entity.BatchStep{}, err
+	}
+
+	step := entity.BatchStep{
+		Op:       operation.Op,
+		Resource: operation.Resource,
+		Ref:      operation.Ref,
+		ID:       operation.ID,
+		Version:  operation.Version,
+	}
+
+	var fields []entity.FieldError
+	if operation.Op == entity.BatchOpCreate {
+		if operation.Ref != "" {
+			if _, taken := p.created[operation.Ref]; taken {
+				fields = append(fields, entity.FieldError{Field: "ref", Code: "unique", Message: "is already used by an earlier operation", MessageID: "batch.ref_taken"})
+			}
+			// registered even if the body is rejected, so later references are not reported as well
+			p.created[operation.Ref] = operation.Resource
+		}
+		if !operation.ID.IsZero() {
+			fields = append(fields, entity.FieldError{Field: "id", Code: "excluded", Message: "is not allowed on create", MessageID: "batch.create_excluded"})
+		}
+		if operation.Version != 0 {
+			fields = append(fields, entity.FieldError{Field: "version", Code: "excluded", Message: "is not allowed on create", MessageID: "batch.create_excluded"})
+		}
+	} else {
+		if operation.Ref != "" {
+			fields = append(fields, entity.FieldError{Field: "ref", Code: "excluded", Message: "is only allowed on create", MessageID: "batch.create_only"})
+		}
+		fields = append(fields, p.checkTarget(&step, operation)...)
+	}
+	if len(fields) > 0 {
+		return entity.BatchStep{}, entity.InvalidFields(fields...)
+	}
+
+	if operation.Op == entity.BatchOpDelete {
+		if len(bytes.TrimSpace(operation.Body)) > 0 {
+			return entity.BatchStep{}, entity.InvalidFields(entity.FieldError{Field: "body", Code: "excluded", Message: "is not allowed on delete", MessageID: "batch.delete_excluded"})
+		}
+		return step, nil
+	}
+
+	if err := p.decodeBody(ctx, &step, operation.Body); err != nil {
+		return entity.BatchStep{}, prefixFields(err, "body.")
+	}
+
+	return step, nil
+}
+
+// NOTE - checks the id and version of an update or delete
+func (p *batchPlan) checkTarget(step *entity.BatchStep, operation entity.BatchOperation) []entity.FieldError {
+	var fields []entity.FieldError
+
+	switch {
+	case operation.ID.IsZero():
+		fields = append(fields, entity.FieldError{Field: "id", Code: "required", Message: "is required", MessageID: "validation.required"})
+	case operation.ID.Ref != "":
+		ref, invalid := p.resolveRef("id", operation.ID.Ref, operation.Resource)
+		if invalid != nil {
+			fields = append(fields, *invalid)
+		}
+		step.ID = entity.BatchID{Ref: ref}
+		// a record created by the batch has no version the client could have read
+		if operation.Version != 0 {
+			fields = append(fields, entity.FieldError{Field: "version", Code: "excluded", Message: "is not allowed for a record created in the batch", MessageID: "batch.created_version"})
+		}
+	case operation.ID.Value < 0:
+		fields = append(fields, entity.FieldError{Field: "id", Code: "min", Message: "must be at least 1", MessageID: "validation.min", Params: map[string]interface{}{"Count": 1}})
+	case operation.Resource == entity.BatchResourceUser && operation.Version == 0:
+		// the batch counterpart of If-Match, which user writes require
+		fields = append(fields, entity.FieldError{Field: "version", Code: "required", Message: "is required to write a user", MessageID: "batch.user_version"})
+	case operation.Resource == entity.BatchResourceSubject && operation.Version != 0:
+		fields = append(fields, entity.FieldError{Field: "version", Code: "excluded", Message: "is only allowed for users", MessageID: "batch.subject_version"})
+	}
+
+	return fields
+}
+
+// NOTE - name behind a `$<ref>` in field, which has to be an earlier create of resource
+func (p *batchPlan) resolveRef(field, value, resource string) (string, *entity.FieldError) {
+	invalidRef := func(messageID string, params map[string]interface{}, format string, args ...interface{}) *entity.FieldError {
+		return &entity.FieldError{Field: field, Code: "invalid_ref", Message: fmt.Sprintf(format, args...), MessageID: messageID, Params: params}
+	}
+
+	ref, ok := strings.CutPrefix(value, "$")
+	if !ok || ref == "" {
+		return "", invalidRef("batch.ref_invalid", nil, "must be an ID or $<ref> of an earlier create")
+	}
+
+	created, ok := p.created[ref]
+	if !ok {
+		return "", invalidRef("batch.ref_unknown", map[string]interface{}{"Ref": ref}, "no earlier create has the ref %s", ref)
+	}
+	if created != resource {
+		return "", invalidRef("batch.ref_resource", map[string]interface{}{"Ref": ref, "Created": created, "Resource": resource},
+			"ref %s is a %s, not a %s", ref, created, resource)
+	}
+
+	return ref, nil
+}
+
+// NOTE - decodes and validates the body the way the matching endpoint does
+func (p *batchPlan) decodeBody(ctx context.Context, step *entity.BatchStep, body json.RawMessage) error {
+	if step.Resource == entity.BatchResourceSubject {
+		if step.Op == entity.BatchOpCreate {
+			var req entity.CreateSubjectRequest
+			if err := decodeStrict(ctx, body, &req); err != nil {
+				return err
+			}
+			step.Subject = entity.Subject{Name: req.Name}
+			return nil
+		}
+
+		if err := decodeStrict(ctx, body, &step.SubjectPatch); err != nil {
+			return err
+		}
+		return checkSubjectPatch(step.SubjectPatch)
+	}
+
+	body, subjectRef, err := takeSubjectRef(body)
+	if err != nil {
+		return err
+	}
+	if subjectRef != "" {
+		ref, invalid := p.resolveRef("subject_id", subjectRef, entity.BatchResourceSubject)
+		if invalid != nil {
+			return entity.InvalidFields(*invalid)
+		}
+		step.SubjectRef = ref
+	}
+
+	var email string
+	if step.Op == entity.BatchOpCreate {
+		var req entity.CreateUserRequest
+		if err := decodeStrict(ctx, body, &req); err != nil {
+			return err
+		}
+		step.User = entity.User{
+			Name:      req.Name,
+			Email:     req.Email,
+			Password:  req.Password,
+			SubjectID: req.SubjectID,
+			Status:    true,
+		}
+		email = req.Email
+	} else {
+		if err := decodeStrict(ctx, body, &step.UserPatch); err != nil {
+			return err
+		}
+		if err := checkUserPatch(step.UserPatch); err != nil {
+			return err
+		}
+		email = step.UserPatch.Email.Value
+	}
+
+	if email != "" {
+		if p.emails[strings.ToLower(email)] {
+			return entity.InvalidFields(entity.FieldError{Field: "email", Code: "email_unique", Message: "is already given to a user earlier in the batch", MessageID: "batch.email_taken"})
+		}
+		p.emails[strings.ToLower(email)] = true
+	}
+
+	return nil
+}
+
+// NOTE - strictly decodes a body and runs its binding rules
+func decodeStrict(ctx context.Context, body json.RawMessage, obj interface{}) error {
+	if err := validation.DecodeJSON(bytes.NewReader(body), obj); err != nil {
+		return err
+	}
+	return validation.Struct(ctx, obj)
+}
+
+// NOTE - takes a `subject_id` given as a string out of a user body, so the rest
+// can be decoded and validated like a regular request
+func takeSubjectRef(body json.RawMessage) (json.RawMessage, string, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil {
+		// not an object, decoding the body reports it
+		return body, "", nil
+	}
+
+	value, ok := members["subject_id"]
+	if !ok || len(value) == 0 || value[0] != '"' {
+		return body, "", nil
+	}
+
+	var ref string
+	if err := json.Unmarshal(value, &ref); err != nil {
+		return nil, "", err
+	}
+	delete(members, "subject_id")
+
+	body, err := json.Marshal(members)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, ref, nil
+}
+
+// NOTE - field errors of a failed operation under `operations[index]`, false when err is not a domain error
+func operationFields(index int, err error) ([]entity.FieldError, bool) {
+	var domainErr *entity.Error
+	if !errors.As(err, &domainErr) {
+		return nil, false
+	}
+
+	path := fmt.Sprintf("operations[%d]", index)
+	if len(domainErr.Fields) == 0 {
+		return []entity.FieldError{{
+			Field:     path,
+			Code:      domainErr.Code,
+			Message:   err.Error(),
+			MessageID: domainErr.LocalizationID(),
+			Params:    domainErr.Params,
+		}}, true
+	}
+
+	fields := make([]entity.FieldError, 0, len(domainErr.Fields))
+	for _, field := range domainErr.Fields {
+		field.Field = path + "." + field.Field
+		fields = append(fields, field)
+	}
+	return fields, true
+}
+
+// NOTE - prefixes the fields of a validation error, so they point into a nested document
+func prefixFields(err error, prefix string) error {
+	var domainErr *entity.Error
+	if !errors.As(err, &domainErr) || len(domainErr.Fields) == 0 {
+		return err
+	}
+
+	fields := make([]entity.FieldError, 0, len(domainErr.Fields))
+	for _, field := range domainErr.Fields {
+		field.Field = prefix + field.Field
+		fields = append(fields, field)
+	}
+	return entity.InvalidFields(fields...)
+}
+
+// NOTE - turns the per operation failures the repository found into the batch error
+func batchError(err error) error {
+	var failures []error
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		failures = joined.Unwrap()
+	} else {
+		failures = []error{err}
+	}
+
+	var fields []entity.FieldError
+	for _, failure := range failures {
+		var opErr *entity.BatchOperationError
+		if !errors.As(failure, &opErr) {
+			return err
+		}
+		opFields, ok := operationFields(opErr.Index, opErr.Err)
+		if !ok {
+			return opErr.Err
+		}
+		fields = append(fields, opFields...)
+	}
+
+	return batchFailed(fields)
+}
+
+// NOTE - error of a batch that was not applied, with the reasons of every failed operation
+func batchFailed(fields []entity.FieldError) error {
+	failed := failedOperations(fields)
+	err := entity.Validation("batch_failed", "the batch was not applied, %d operation(s) failed", failed).With("Count", failed)
+	err.Fields = fields
+	return err
+}
+
+// NOTE - number of distinct operations the field errors are about
+func failedOperations(fields []entity.FieldError) int {
+	operations := map[string]bool{}
+	for _, field := range fields {
+		operation, _, _ := strings.Cut(field.Field, ".")
+		operations[operation] = true
+	}
+	return len(operations)
+}
@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"sample-project/internal/entity"
+	"sample-project/internal/repository"
+	"sync"
+	"time"
+)
+
+const (
+	// events replayed to a client that resumes, older ones are not sent
+	maxReplayedEvents = 1000
+	// live events a client may fall behind by before it is disconnected to resume
+	subscriberBuffer = 256
+	// wait before subscribing again after redis dropped the subscription
+	subscribeRetry = 5 * time.Second
+)
+
+// NOTE - event use case interface
+type EventUseCase interface {
+	Run(ctx context.Context)
+	Subscribe(ctx context.Context, filter entity.EventFilter, lastEventID string) (<-chan entity.ChangeEvent, error)
+}
+
+// NOTE - event use case struct, one redis subscription per instance fans out to every local client
+type eventUseCase struct {
+	repo repository.EventRepository
+
+	mu          sync.Mutex
+	subscribers map[chan entity.ChangeEvent]entity.EventFilter
+	stopped     bool
+}
+
+// NOTE - new event use case
+func NewEventUseCase(repo repository.EventRepository) EventUseCase {
+	return &eventUseCase{repo: repo, subscribers: map[chan entity.ChangeEvent]entity.EventFilter{}}
+}
+
+// NOTE - forwards the events of every instance to the local subscribers until ctx is done,
+// then ends every subscription. A lost subscription is retried
+func (u *eventUseCase) Run(ctx context.Context) {
+	defer u.stop()
+
+	for {
+		events, err := u.repo.SubscribeEvents(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to subscribe to change events, retrying", "error", err)
+		} else {
+			for event := range events {
+				u.broadcast(event)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(subscribeRetry):
+		}
+	}
+}
+
+// NOTE - events of the tenant of ctx matching filter, starting after lastEventID when it is set. The channel
+// closes when ctx is done, when the instance shuts down, or when the client falls too far
+// behind, in which case it resumes from the last event it got
+func (u *eventUseCase) Subscribe(ctx context.Context, filter entity.EventFilter, lastEventID string) (<-chan entity.ChangeEvent, error) {
+	tenantID, ok := entity.TenantID(ctx)
+	if !ok {
+		return nil, entity.ErrTenantRequired
+	}
+	filter.TenantID = tenantID
+
+	if lastEventID != "" && !entity.ValidEventID(lastEventID) {
+		return nil, entity.BadRequest("invalid_last_event_id", "Last-Event-ID is not an event id")
+	}
+
+	// subscribe before reading the history, so nothing published in between is lost
+	live := make(chan entity.ChangeEvent, subscriberBuffer)
+	if !u.add(live, filter) {
+		return nil, entity.NewError(entity.ErrUnavailable, "shutting_down", "the server is shutting down, please reconnect")
+	}
+
+	var history []entity.ChangeEvent
+	if lastEventID != "" {
+		events, err := u.repo.GetEventsSince(ctx, lastEventID, maxReplayedEvents)
+		if err != nil {
+			u.remove(live)
+			return nil, err
+		}
+		history = events
+	}
+
+	out := make(chan entity.ChangeEvent)
+	go func() {
+		defer close(out)
+		defer u.remove(live)
+
+		last := lastEventID
+		for _, event := range history {
+			last = event.ID
+			if !filter.Matches(event) {
+				continue
+			}
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-live:
+				if !ok {
+					return
+				}
+				// already replayed from the history
+				if last != "" && !event.After(last) {
+					continue
+				}
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (u *eventUseCase) add(live chan entity.ChangeEvent, filter entity.EventFilter) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.stopped {
+		return false
+	}
+	u.subscribers[live] = filter
+	return true
+}
+
+func (u *eventUseCase) remove(live chan entity.ChangeEvent) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if _, ok := u.subscribers[live]; ok {
+		delete(u.subscribers, live)
+		close(live)
+	}
+}
+
+func (u *eventUseCase) broadcast(event entity.ChangeEvent) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for live, filter := range u.subscribers {
+		if !filter.Matches(event) {
+			continue
+		}
+		select {
+		case live <- event:
+		default:
+			// too far behind, the client reconnects with Last-Event-ID and replays the rest
+			delete(u.subscribers, live)
+			close(live)
+		}
+	}
+}
+
+func (u *eventUseCase) stop() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.stopped = true
+	for live := range u.subscribers {
+		delete(u.subscribers, live)
+		close(live)
+	}
+}
@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sample-project/internal/entity"
+	"testing"
+	"time"
+)
+
+// channelEvents replays history and delivers whatever is sent on live as the events of every instance
+type channelEvents struct {
+	history []entity.ChangeEvent
+	live    chan entity.ChangeEvent
+}
+
+func (r *channelEvents) GetEventsSince(ctx context.Context, lastID string, limit int) ([]entity.ChangeEvent, error) {
+	return r.history, nil
+}
+
+func (r *channelEvents) SubscribeEvents(ctx context.Context) (<-chan entity.ChangeEvent, error) {
+	return r.live, nil
+}
+
+// NOTE - event of the tenant, id is its position in the history
+func tenantEvent(id string, tenantID, userID int) entity.ChangeEvent {
+	event := entity.NewChangeEvent(entity.EventResourceUser, entity.EventActionUpdated, userID, nil)
+	event.ID = id
+	event.TenantID = tenantID
+	return event
+}
+
+func TestSubscribeOnlyStreamsEventsOfItsSchool(t *testing.T) {
+	repo := &channelEvents{
+		history: []entity.ChangeEvent{tenantEvent("1000-0", 2, 20), tenantEvent("1000-1", 1, 10)},
+		live:    make(chan entity.ChangeEvent),
+	}
+	useCase := NewEventUseCase(repo)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go useCase.Run(ctx)
+
+	// a client of school 1 that also asks for the user id of school 2
+	events, err := useCase.Subscribe(entity.WithTenant(ctx, 1), entity.EventFilter{TenantID: 2}, "999-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := useCase.Subscribe(ctx, entity.EventFilter{}, ""); !errors.Is(err, entity.ErrTenantRequired) {
+		t.Errorf("subscribing without a school: got %v, want tenant_required", err)
+	}
+
+	go func() {
+		for _, event := range []entity.ChangeEvent{tenantEvent("1001-0", 2, 20), tenantEvent("1001-1", 1, 11), tenantEvent("1001-2", 2, 21), tenantEvent("1001-3", 1, 12)} {
+			select {
+			case repo.live <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var got []int
+	for len(got) < 3 {
+		select {
+		case event := <-events:
+			if event.TenantID != 1 {
+				t.Fatalf("school 1 got %+v of school %d", event, event.TenantID)
+			}
+			got = append(got, event.ResourceID)
+		case <-ctx.Done():
+			t.Fatalf("got users %v of school 1 before the timeout, want 10, 11 and 12", got)
+		}
+	}
+	if got[0] != 10 || got[1] != 11 || got[2] != 12 {
+		t.Errorf("got users %v, want 10, 11 and 12", got)
+	}
+}
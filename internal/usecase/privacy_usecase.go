@@ -0,0 +1,209 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sample-project/internal/config/storage"
+	"sample-project/internal/entity"
+	"sample-project/internal/repository"
+	"sample-project/internal/utils"
+	"time"
+)
+
+// NOTE - privacy use case interface for data-subject requests
+type PrivacyUseCase interface {
+	ExportUserData(ctx context.Context, id int, actorID *int) ([]byte, error)
+	EraseUser(ctx context.Context, id int, actorID *int) (*entity.PrivacyRecord, error)
+	VerifyPrivacyLog(ctx context.Context) (*entity.PrivacyChainStatus, error)
+}
+
+// NOTE - privacy use case struct
+type privacyUseCase struct {
+	userRepo    repository.UserRepository
+	subjectRepo repository.SubjectRepository
+	privacyRepo repository.PrivacyRepository
+	storage     storage.Storage
+}
+
+// NOTE - new privacy use case
+func NewPrivacyUseCase(userRepo repository.UserRepository, subjectRepo repository.SubjectRepository, privacyRepo repository.PrivacyRepository, storage storage.Storage) PrivacyUseCase {
+	return &privacyUseCase{userRepo: userRepo, subjectRepo: subjectRepo, privacyRepo: privacyRepo, storage: storage}
+}
+
+// NOTE - builds a zip archive of everything stored about a user
+func (u *privacyUseCase) ExportUserData(ctx context.Context, id int, actorID *int) ([]byte, error) {
+	user, err := u.userRepo.GetUserByID(ctx, id, entity.Projection{})
+	if err != nil {
+		return nil, err
+	}
+
+	// never hand out the password hash, not even to its owner
+	profile := *user
+	profile.Password = ""
+
+	var subject *entity.Subject
+	if user.SubjectID != 0 {
+		// other members of the subject are not part of this user's data, so they are not included
+		subject, err = u.subjectRepo.GetSubjectByID(ctx, user.SubjectID, entity.Projection{})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	transitions, err := u.userRepo.GetUserStateTransitions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	privacyRecords, err := u.privacyRepo.GetPrivacyRecords(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"manifest.json", map[string]interface{}{
+			"user_id":      id,
+			"generated_at": time.Now().UTC(),
+			"files": map[string]string{
+				"profile.json":      "account data stored in the users table, without the password hash",
+				"subject.json":      "the subject the user is a member of",
+				"sessions.json":     "login sessions; access and refresh tokens are stateless JWTs, so none are stored",
+				"audit_events.json": "lifecycle state transitions and earlier data-subject requests",
+			},
+		}},
+		{"profile.json", profile},
+		{"subject.json", subject},
+		{"sessions.json", []interface{}{}},
+		{"audit_events.json", map[string]interface{}{
+			"state_transitions": transitions,
+			"privacy_requests":  privacyRecords,
+		}},
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := archive.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	if _, err := u.appendRecord(ctx, entity.PrivacyRecord{
+		UserID:  id,
+		Type:    entity.PrivacyRequestExport,
+		ActorID: actorID,
+		Details: fmt.Sprintf("export archive of %d bytes", buf.Len()),
+	}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// NOTE - anonymizes a user, removes the avatar files and records the completed erasure
+func (u *privacyUseCase) EraseUser(ctx context.Context, id int, actorID *int) (*entity.PrivacyRecord, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	// nobody knows this password, so the account can never be logged into again
+	password, err := utils.HashPassword(hex.EncodeToString(secret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	erased, err := u.userRepo.EraseUser(ctx, id, entity.User{
+		Name:     fmt.Sprintf("Erased user %d", id),
+		Email:    fmt.Sprintf("erased-%d@invalid", id),
+		Password: password,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{fmt.Sprintf("avatars/%d/original.jpg", id)}
+	for _, size := range utils.AvatarThumbnailSizes {
+		keys = append(keys, fmt.Sprintf("avatars/%d/%d.jpg", id, size))
+	}
+	for _, key := range keys {
+		if err := u.storage.Delete(ctx, key); err != nil {
+			return nil, fmt.Errorf("failed to delete %s: %v", key, err)
+		}
+	}
+
+	return u.appendRecord(ctx, entity.PrivacyRecord{
+		UserID:  id,
+		Type:    entity.PrivacyRequestErasure,
+		ActorID: actorID,
+		Details: fmt.Sprintf("user row anonymized at version %d, avatar files removed, cache purged", erased.Version),
+	})
+}
+
+// NOTE - walks the chain of the request's school and reports the first record whose hash or link does not match.
+// Every school has its own chain starting at the genesis hash, records of other schools are never read
+func (u *privacyUseCase) VerifyPrivacyLog(ctx context.Context) (*entity.PrivacyChainStatus, error) {
+	records, err := u.privacyRepo.GetPrivacyRecords(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &entity.PrivacyChainStatus{Valid: true, Records: len(records)}
+	prevHash := entity.PrivacyGenesisHash
+	for _, record := range records {
+		if record.PrevHash != prevHash || record.ComputeHash() != record.Hash {
+			id := record.ID
+			status.Valid = false
+			status.FirstBrokenID = &id
+			break
+		}
+		prevHash = record.Hash
+	}
+
+	return status, nil
+}
+
+// NOTE - links a record to the current tail of the school's chain, retrying when another writer got there first
+func (u *privacyUseCase) appendRecord(ctx context.Context, record entity.PrivacyRecord) (*entity.PrivacyRecord, error) {
+	for attempt := 0; attempt < 3; attempt++ {
+		last, err := u.privacyRepo.GetLastPrivacyRecord(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		record.PrevHash = entity.PrivacyGenesisHash
+		if last != nil {
+			record.PrevHash = last.Hash
+		}
+		// postgres keeps microseconds, hash what will be read back
+		record.CompletedAt = time.Now().UTC().Truncate(time.Microsecond)
+		record.Hash = record.ComputeHash()
+
+		created, err := u.privacyRepo.CreatePrivacyRecord(ctx, record)
+		if errors.Is(err, entity.ErrPrivacyChainConflict) {
+			continue
+		}
+		return created, err
+	}
+
+	return nil, entity.ErrPrivacyChainConflict
+}
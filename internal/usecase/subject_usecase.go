@@ -8,12 +8,16 @@ import (
 
 // NOTE - subject use case interface
 type SubjectUsecase interface {
-	GetSubject(ctx context.Context) ([]entity.Subject, error)
-	GetSubjectByID(ctx context.Context, id int) (*entity.Subject, error)
+	GetSubject(ctx context.Context, projection entity.Projection) ([]entity.Subject, error)
+	GetSubjectsPage(ctx context.Context, page, limit int, name string, status *bool) ([]entity.Subject, int, error)
+	GetSubjectByID(ctx context.Context, id int, projection entity.Projection) (*entity.Subject, error)
+	GetSubjectsByIDs(ctx context.Context, ids []int) ([]entity.Subject, error)
 	CreateSubject(ctx context.Context, subject entity.Subject) (*entity.Subject, error)
-	UpdateSubject(ctx context.Context, id int, updateSubject entity.Subject) (*entity.Subject, error)
+	UpdateSubject(ctx context.Context, id int, patch entity.SubjectPatch) (*entity.Subject, error)
 	DeleteSubject(ctx context.Context, id int) error
 	ClearSubjectCache(ctx context.Context) error
+	GetSubjectsETag(ctx context.Context, projection entity.Projection) string
+	GetSubjectETag(ctx context.Context, id int, projection entity.Projection) string
 }
 
 // NOTE - subject use case struct
@@ -27,13 +31,23 @@ func NewSubjectUseCase(repo repository.SubjectRepository) SubjectUsecase {
 }
 
 // NOTE - get all subjects use case
-func (u *subjectUseCase) GetSubject(ctx context.Context) ([]entity.Subject, error) {
-	return u.repo.GetAllSubjects(ctx)
+func (u *subjectUseCase) GetSubject(ctx context.Context, projection entity.Projection) ([]entity.Subject, error) {
+	return u.repo.GetAllSubjects(ctx, projection)
+}
+
+// NOTE - filtered page of subjects use case
+func (u *subjectUseCase) GetSubjectsPage(ctx context.Context, page, limit int, name string, status *bool) ([]entity.Subject, int, error) {
+	return u.repo.GetSubjectsPage(ctx, page, limit, name, status)
 }
 
 // NOTE - get subject by id use case
-func (u *subjectUseCase) GetSubjectByID(ctx context.Context, id int) (*entity.Subject, error) {
-	return u.repo.GetSubjectByID(ctx, id)
+func (u *subjectUseCase) GetSubjectByID(ctx context.Context, id int, projection entity.Projection) (*entity.Subject, error) {
+	return u.repo.GetSubjectByID(ctx, id, projection)
+}
+
+// NOTE - get subjects by ids use case
+func (u *subjectUseCase) GetSubjectsByIDs(ctx context.Context, ids []int) ([]entity.Subject, error) {
+	return u.repo.GetSubjectsByIDs(ctx, ids)
 }
 
 // NOTE - create subject use case
@@ -42,19 +56,27 @@ func (u *subjectUseCase) CreateSubject(ctx context.Context, subject entity.Subje
 }
 
 // NOTE - update subject use case
-func (u *subjectUseCase) UpdateSubject(ctx context.Context, id int, updateSubject entity.Subject) (*entity.Subject, error) {
-	subject, err := u.repo.GetSubjectByID(ctx, id)
-	if err != nil {
+func (u *subjectUseCase) UpdateSubject(ctx context.Context, id int, patch entity.SubjectPatch) (*entity.Subject, error) {
+	if err := checkSubjectPatch(patch); err != nil {
 		return nil, err
 	}
 
-	if updateSubject.Name != "" {
-		subject.Name = updateSubject.Name
+	if _, err := u.repo.GetSubjectByID(ctx, id, entity.Projection{}); err != nil {
+		return nil, err
 	}
 
-	subject.Status = updateSubject.Status
+	return u.repo.UpdateSubject(ctx, id, patch)
+}
 
-	return u.repo.UpdateSubject(ctx, id, *subject)
+// NOTE - rejects merge patch members a subject cannot be without
+func checkSubjectPatch(patch entity.SubjectPatch) error {
+	if patch.Name.Null || (patch.Name.Present && patch.Name.Value == "") {
+		return emptyPatchMember("name")
+	}
+	if patch.Status.Null {
+		return nullPatchMember("status")
+	}
+	return nil
 }
 
 // NOTE - delete subject use case
@@ -66,3 +88,13 @@ func (u *subjectUseCase) DeleteSubject(ctx context.Context, id int) error {
 func (u *subjectUseCase) ClearSubjectCache(ctx context.Context) error {
 	return u.repo.ClearSubjectCache(ctx)
 }
+
+// NOTE - cached entity tag of the subject list use case
+func (u *subjectUseCase) GetSubjectsETag(ctx context.Context, projection entity.Projection) string {
+	return u.repo.GetSubjectsETag(ctx, projection)
+}
+
+// NOTE - cached entity tag of a subject use case
+func (u *subjectUseCase) GetSubjectETag(ctx context.Context, id int, projection entity.Projection) string {
+	return u.repo.GetSubjectETag(ctx, id, projection)
+}
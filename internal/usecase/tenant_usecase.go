@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"sample-project/internal/entity"
+	"sample-project/internal/repository"
+	"sample-project/internal/utils"
+	"strings"
+)
+
+// NOTE - tenant use case interface
+type TenantUseCase interface {
+	ResolveTenant(ctx context.Context, credentials entity.TenantCredentials) (int, error)
+	GetAllTenants(ctx context.Context) ([]entity.Tenant, error)
+}
+
+// NOTE - tenant use case struct
+type tenantUseCase struct {
+	repo repository.TenantRepository
+}
+
+// NOTE - new tenant use case
+func NewTenantUseCase(repo repository.TenantRepository) TenantUseCase {
+	return &tenantUseCase{repo: repo}
+}
+
+// NOTE - tenant the credentials name, 0 when none does. The access token ctx was authenticated with binds
+// the request to the school of its principal, an API key or host naming another school is refused and so
+// is a token without a school. An unknown API key is refused
+func (u *tenantUseCase) ResolveTenant(ctx context.Context, credentials entity.TenantCredentials) (int, error) {
+	resolved := 0
+	resolve := func(id int) error {
+		if resolved != 0 && resolved != id {
+			return entity.ErrTenantMismatch
+		}
+		resolved = id
+		return nil
+	}
+
+	if claims := utils.ClaimsFrom(ctx); claims != nil {
+		if claims.TenantID == 0 {
+			return 0, entity.ErrTokenWithoutTenant
+		}
+		resolved = claims.TenantID
+	}
+
+	if credentials.APIKey != "" {
+		tenant, err := u.repo.GetTenantByKeyHash(ctx, apiKeyHash(credentials.APIKey))
+		if errors.Is(err, entity.ErrNotFound) {
+			return 0, entity.ErrInvalidAPIKey
+		}
+		if err != nil {
+			return 0, err
+		}
+		if err := resolve(tenant.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	// hosts no school is served on, e.g. the internal one, leave the tenant to the other credentials
+	if host := normalizeHost(credentials.Host); host != "" {
+		tenant, err := u.repo.GetTenantByHost(ctx, host)
+		if err != nil && !errors.Is(err, entity.ErrNotFound) {
+			return 0, err
+		}
+		if err == nil {
+			if err := resolve(tenant.ID); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return resolved, nil
+}
+
+// NOTE - every tenant
+func (u *tenantUseCase) GetAllTenants(ctx context.Context) ([]entity.Tenant, error) {
+	return u.repo.GetAllTenants(ctx)
+}
+
+// NOTE - what the tenants table stores of an API key, the key itself is never stored
+func apiKeyHash(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// NOTE - host without port and trailing dot, lower case like the hosts in the tenants table
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sample-project/internal/entity"
+	"sample-project/internal/repository"
+	"sample-project/internal/utils"
+	"testing"
+)
+
+// schoolTenants knows two schools, 1 on a.example.com with key-a and 2 on b.example.com with key-b
+type schoolTenants struct {
+	repository.TenantRepository
+}
+
+func (schoolTenants) GetTenantByHost(ctx context.Context, host string) (*entity.Tenant, error) {
+	switch host {
+	case "a.example.com":
+		return &entity.Tenant{ID: 1}, nil
+	case "b.example.com":
+		return &entity.Tenant{ID: 2}, nil
+	}
+	return nil, entity.ErrNotFound
+}
+
+func (schoolTenants) GetTenantByKeyHash(ctx context.Context, keyHash string) (*entity.Tenant, error) {
+	switch keyHash {
+	case apiKeyHash("key-a"):
+		return &entity.Tenant{ID: 1}, nil
+	case apiKeyHash("key-b"):
+		return &entity.Tenant{ID: 2}, nil
+	}
+	return nil, entity.ErrNotFound
+}
+
+func TestResolveTenantBindsTheTokenSchool(t *testing.T) {
+	tokenA, noSchool := &utils.Claims{UserID: 3, TenantID: 1}, &utils.Claims{UserID: 3}
+
+	tests := []struct {
+		name string
+		// claims of the access token the request was authenticated with
+		claims      *utils.Claims
+		credentials entity.TenantCredentials
+		tenantID    int
+		err         error
+	}{
+		{name: "token alone", claims: tokenA, tenantID: 1},
+		{name: "token on its school's host", claims: tokenA, credentials: entity.TenantCredentials{Host: "a.example.com:8080"}, tenantID: 1},
+		{name: "token on an unknown host", claims: tokenA, credentials: entity.TenantCredentials{Host: "internal"}, tenantID: 1},
+		{name: "token on another school's host", claims: tokenA, credentials: entity.TenantCredentials{Host: "b.example.com"}, err: entity.ErrTenantMismatch},
+		{name: "token with another school's key", claims: tokenA, credentials: entity.TenantCredentials{APIKey: "key-b"}, err: entity.ErrTenantMismatch},
+		{name: "token without a school", claims: noSchool, credentials: entity.TenantCredentials{Host: "a.example.com"}, err: entity.ErrTokenWithoutTenant},
+		{name: "key on another school's host", credentials: entity.TenantCredentials{APIKey: "key-a", Host: "b.example.com"}, err: entity.ErrTenantMismatch},
+		{name: "unknown key", credentials: entity.TenantCredentials{APIKey: "key-c"}, err: entity.ErrInvalidAPIKey},
+		{name: "host alone", credentials: entity.TenantCredentials{Host: "B.example.com."}, tenantID: 2},
+		{name: "nothing", credentials: entity.TenantCredentials{}},
+	}
+
+	useCase := NewTenantUseCase(schoolTenants{})
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.claims != nil {
+			ctx = utils.WithClaims(ctx, tt.claims)
+		}
+		tenantID, err := useCase.ResolveTenant(ctx, tt.credentials)
+		if !errors.Is(err, tt.err) || tenantID != tt.tenantID {
+			t.Errorf("%s: got tenant %d and %v, want %d and %v", tt.name, tenantID, err, tt.tenantID, tt.err)
+		}
+	}
+}
@@ -1,77 +1,207 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"log/slog"
+	"sample-project/internal/config/storage"
 	"sample-project/internal/entity"
 	"sample-project/internal/repository"
+	"sample-project/internal/utils"
+	"strconv"
+	"time"
 )
 
 // NOTE - user use case interface
 type UserUseCase interface {
-	GetUsers(ctx context.Context, page, limit int, name string, startDate, endDate string) ([]entity.User, int, error)
-	GetUserByID(ctx context.Context, id int) (*entity.User, error)
+	GetUsers(ctx context.Context, page, limit int, name string, startDate, endDate string, state entity.UserState, projection entity.Projection) ([]entity.User, int, error)
+	GetUserByID(ctx context.Context, id int, projection entity.Projection) (*entity.User, error)
 	GetUserByName(ctx context.Context, name string) (*entity.User, error)
+	GetUsersBySubjectIDs(ctx context.Context, subjectIDs []int) ([]entity.User, error)
 	CreateUser(ctx context.Context, user entity.User) (*entity.User, error)
-	UpdateUser(ctx context.Context, id int, user entity.User) (*entity.User, error)
-	DeleteUser(ctx context.Context, id int) error
+	UpdateUser(ctx context.Context, id int, versions []int, patch entity.UserPatch) (*entity.User, error)
+	UpdateAvatar(ctx context.Context, id int, image []byte) (*entity.AvatarResponse, error)
+	TransitionState(ctx context.Context, id int, req entity.TransitionUserStateRequest, actorID *int) (*entity.User, error)
+	GetStateTransitions(ctx context.Context, id int) ([]entity.UserStateTransition, error)
+	ReactivateDueUsers(ctx context.Context) (int, error)
+	DeleteUser(ctx context.Context, id int, versions []int) error
 	ClearUserCache(ctx context.Context) error
 }
 
 // NOTE - user use case struct
 type userUsecase struct {
-	repo repository.UserRepository
+	repo    repository.UserRepository
+	storage storage.Storage
 }
 
 // NOTE - new user use case
-func NewUserUsecase(repo repository.UserRepository) UserUseCase {
-	return &userUsecase{repo: repo}
+func NewUserUsecase(repo repository.UserRepository, storage storage.Storage) UserUseCase {
+	return &userUsecase{repo: repo, storage: storage}
 }
 
 // NOTE - get all users use case
-func (u *userUsecase) GetUsers(ctx context.Context, page, limit int, name string, startDate, endDate string) ([]entity.User, int, error) {
-	return u.repo.GetAllUsers(ctx, page, limit, name, startDate, endDate)
+func (u *userUsecase) GetUsers(ctx context.Context, page, limit int, name string, startDate, endDate string, state entity.UserState, projection entity.Projection) ([]entity.User, int, error) {
+	return u.repo.GetAllUsers(ctx, page, limit, name, startDate, endDate, state, projection)
 }
 
 // NOTE - get user by id use case
-func (u *userUsecase) GetUserByID(ctx context.Context, id int) (*entity.User, error) {
-	return u.repo.GetUserByID(ctx, id)
+func (u *userUsecase) GetUserByID(ctx context.Context, id int, projection entity.Projection) (*entity.User, error) {
+	return u.repo.GetUserByID(ctx, id, projection)
 }
 
 func (u *userUsecase) GetUserByName(ctx context.Context, name string) (*entity.User, error) {
 	return u.repo.GetUserByName(ctx, name)
 }
 
+// NOTE - get users of several subjects use case
+func (u *userUsecase) GetUsersBySubjectIDs(ctx context.Context, subjectIDs []int) ([]entity.User, error) {
+	return u.repo.GetUsersBySubjectIDs(ctx, subjectIDs)
+}
+
 // NOTE - create user use case
 func (u *userUsecase) CreateUser(ctx context.Context, user entity.User) (*entity.User, error) {
 	return u.repo.CreateUser(ctx, user)
 }
 
 // NOTE - update user use case
-func (u *userUsecase) UpdateUser(ctx context.Context, id int, userUpdate entity.User) (*entity.User, error) {
-	user, err := u.repo.GetUserByID(ctx, id)
+func (u *userUsecase) UpdateUser(ctx context.Context, id int, versions []int, patch entity.UserPatch) (*entity.User, error) {
+	if err := checkUserPatch(patch); err != nil {
+		return nil, err
+	}
+
+	if _, err := u.repo.GetUserByID(ctx, id, entity.Projection{}); err != nil {
+		return nil, err
+	}
+
+	return u.repo.UpdateUser(ctx, id, versions, patch)
+}
+
+// NOTE - rejects merge patch members a user cannot be without
+func checkUserPatch(patch entity.UserPatch) error {
+	if patch.Name.Null || (patch.Name.Present && patch.Name.Value == "") {
+		return emptyPatchMember("name")
+	}
+	if patch.Email.Null || (patch.Email.Present && patch.Email.Value == "") {
+		return emptyPatchMember("email")
+	}
+	if patch.Password.Null || (patch.Password.Present && patch.Password.Value == "") {
+		return emptyPatchMember("password")
+	}
+	if patch.Status.Null {
+		return nullPatchMember("status")
+	}
+	return nil
+}
+
+// NOTE - invalid patch error of a member that was sent null or empty
+func emptyPatchMember(field string) error {
+	return entity.ErrInvalidPatch.With("Field", field).Explain("%s cannot be null or empty", field)
+}
+
+// NOTE - invalid patch error of a member that was sent null
+func nullPatchMember(field string) error {
+	return entity.ErrInvalidPatch.With("Field", field).Localized("invalid_patch.null").Explain("%s cannot be null", field)
+}
+
+// NOTE - update user avatar use case, stores the cleaned original and its thumbnails
+func (u *userUsecase) UpdateAvatar(ctx context.Context, id int, image []byte) (*entity.AvatarResponse, error) {
+	if _, err := u.repo.GetUserByID(ctx, id, entity.Projection{}); err != nil {
+		return nil, err
+	}
+
+	original, thumbnails, err := utils.ProcessAvatar(image)
 	if err != nil {
 		return nil, err
 	}
 
-	if userUpdate.Name != "" {
-		user.Name = userUpdate.Name
+	avatar := &entity.AvatarResponse{Thumbnails: map[string]string{}}
+	// keys are stable per user, the version query busts browser and CDN caches
+	cacheBuster := "?v=" + strconv.FormatInt(time.Now().Unix(), 10)
+
+	for size, data := range thumbnails {
+		key := fmt.Sprintf("avatars/%d/%d.jpg", id, size)
+		if err := u.storage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+			return nil, fmt.Errorf("failed to store avatar thumbnail: %v", err)
+		}
+		avatar.Thumbnails[strconv.Itoa(size)] = u.storage.URL(key) + cacheBuster
 	}
-	if userUpdate.Email != "" {
-		user.Email = userUpdate.Email
+
+	key := fmt.Sprintf("avatars/%d/original.jpg", id)
+	if err := u.storage.Put(ctx, key, bytes.NewReader(original), int64(len(original)), "image/jpeg"); err != nil {
+		return nil, fmt.Errorf("failed to store avatar: %v", err)
 	}
+	avatar.URL = u.storage.URL(key) + cacheBuster
 
-	user.Status = userUpdate.Status
+	if _, err := u.repo.UpdateUserAvatar(ctx, id, avatar.URL); err != nil {
+		return nil, err
+	}
+
+	return avatar, nil
+}
+
+// NOTE - lifecycle transition use case, enforces the state machine and its rules
+func (u *userUsecase) TransitionState(ctx context.Context, id int, req entity.TransitionUserStateRequest, actorID *int) (*entity.User, error) {
+	if !req.State.IsValid() {
+		return nil, entity.Validation("invalid_state", "unknown state %q", req.State).With("State", req.State)
+	}
+	if req.State == entity.UserStateSuspended && req.Reason == "" {
+		return nil, entity.Validation("reason_required", "a reason is required to suspend a user")
+	}
+	if req.ReactivateAt != nil {
+		if req.State != entity.UserStateSuspended {
+			return nil, entity.Validation("invalid_reactivate_at", "reactivate_at is only allowed when suspending").Localized("invalid_reactivate_at.not_suspending")
+		}
+		if !req.ReactivateAt.After(time.Now()) {
+			return nil, entity.Validation("invalid_reactivate_at", "reactivate_at must be in the future")
+		}
+	}
+
+	user, err := u.repo.GetUserByID(ctx, id, entity.Projection{})
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.State.CanTransitionTo(req.State) {
+		return nil, entity.ErrInvalidStateTransition.With("From", user.State).With("To", req.State).
+			Explain("%s to %s is not allowed", user.State, req.State)
+	}
+
+	return u.repo.TransitionUserState(ctx, id, user.State, req.State, req.Reason, req.ReactivateAt, actorID)
+}
+
+// NOTE - lifecycle history use case
+func (u *userUsecase) GetStateTransitions(ctx context.Context, id int) ([]entity.UserStateTransition, error) {
+	if _, err := u.repo.GetUserByID(ctx, id, entity.Projection{}); err != nil {
+		return nil, err
+	}
+
+	return u.repo.GetUserStateTransitions(ctx, id)
+}
+
+// NOTE - reactivates suspended users whose reactivate_at has passed, returns how many were reactivated
+func (u *userUsecase) ReactivateDueUsers(ctx context.Context) (int, error) {
+	users, err := u.repo.GetUsersDueForReactivation(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
 
-	if userUpdate.SubjectID != 0 {
-		user.SubjectID = userUpdate.SubjectID
+	reactivated := 0
+	for _, user := range users {
+		_, err := u.repo.TransitionUserState(ctx, user.ID, entity.UserStateSuspended, entity.UserStateActive, "automatic reactivation", nil, nil)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to reactivate user", "user_id", user.ID, "error", err)
+			continue
+		}
+		reactivated++
 	}
 
-	return u.repo.UpdateUser(ctx, id, *user)
+	return reactivated, nil
 }
 
 // NOTE - delete user use case
-func (u *userUsecase) DeleteUser(ctx context.Context, id int) error {
-	return u.repo.DeleteUser(ctx, id)
+func (u *userUsecase) DeleteUser(ctx context.Context, id int, versions []int) error {
+	return u.repo.DeleteUser(ctx, id, versions)
 }
 
 // NOTE - clear users cache use case
@@ -0,0 +1,243 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"sample-project/internal/entity"
+	"sample-project/internal/repository"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryStorage is an in-memory storage.Storage
+type memoryStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{objects: map[string][]byte{}, types: map[string]string{}}
+}
+
+func (s *memoryStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = data
+	s.types[key] = contentType
+	return nil
+}
+
+func (s *memoryStorage) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	delete(s.types, key)
+	return nil
+}
+
+func (s *memoryStorage) URL(key string) string {
+	return "https://cdn.example.com/" + key
+}
+
+// avatarUserRepo knows a single user, every method the avatar flow does not call panics through the nil interface
+type avatarUserRepo struct {
+	repository.UserRepository
+	userID    int
+	avatarURL string
+}
+
+func (r *avatarUserRepo) GetUserByID(ctx context.Context, id int, projection entity.Projection) (*entity.User, error) {
+	if id != r.userID {
+		return nil, entity.NotFound("user_not_found", "user with ID: %d not found", id)
+	}
+	return &entity.User{ID: id}, nil
+}
+
+func (r *avatarUserRepo) UpdateUserAvatar(ctx context.Context, id int, url string) (*entity.User, error) {
+	r.avatarURL = url
+	return &entity.User{ID: id}, nil
+}
+
+func pngImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestUpdateAvatarStoresOriginalAndThumbnails(t *testing.T) {
+	store := newMemoryStorage()
+	repo := &avatarUserRepo{userID: 7}
+	u := NewUserUsecase(repo, store)
+
+	avatar, err := u.UpdateAvatar(context.Background(), 7, pngImage(t, 300, 200))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"avatars/7/original.jpg", "avatars/7/64.jpg", "avatars/7/128.jpg", "avatars/7/256.jpg"} {
+		if _, ok := store.objects[key]; !ok {
+			t.Errorf("%s was not stored", key)
+		}
+		if store.types[key] != "image/jpeg" {
+			t.Errorf("%s was stored as %q", key, store.types[key])
+		}
+	}
+
+	if !strings.HasPrefix(avatar.URL, "https://cdn.example.com/avatars/7/original.jpg?v=") {
+		t.Errorf("avatar URL is %q", avatar.URL)
+	}
+	if repo.avatarURL != avatar.URL {
+		t.Errorf("user was saved with %q, response has %q", repo.avatarURL, avatar.URL)
+	}
+	if len(avatar.Thumbnails) != 3 || !strings.HasPrefix(avatar.Thumbnails["128"], "https://cdn.example.com/avatars/7/128.jpg?v=") {
+		t.Errorf("thumbnails are %v", avatar.Thumbnails)
+	}
+}
+
+func TestUpdateAvatarStoresNothingOnError(t *testing.T) {
+	tests := map[string]struct {
+		id    int
+		image []byte
+		want  error
+	}{
+		"unknown user":    {id: 8, image: pngImage(t, 10, 10), want: entity.ErrNotFound},
+		"not an image":    {id: 7, image: []byte("plain text"), want: entity.ErrUnsupportedMediaType},
+		"too many pixels": {id: 7, image: pngImageHeader(60000, 60000), want: entity.ErrPayloadTooLarge},
+	}
+
+	for name, tt := range tests {
+		store := newMemoryStorage()
+		repo := &avatarUserRepo{userID: 7}
+
+		_, err := NewUserUsecase(repo, store).UpdateAvatar(context.Background(), tt.id, tt.image)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", name, err, tt.want)
+		}
+		if len(store.objects) != 0 || repo.avatarURL != "" {
+			t.Errorf("%s: stored %d objects", name, len(store.objects))
+		}
+	}
+}
+
+// PNG cut after its header, enough for DecodeConfig to read the declared size
+func pngImageHeader(width, height uint32) []byte {
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], width)
+	binary.BigEndian.PutUint32(ihdr[4:], height)
+	ihdr[8], ihdr[9] = 8, 6
+
+	chunk := append([]byte("IHDR"), ihdr...)
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&buf, binary.BigEndian, uint32(len(ihdr)))
+	buf.Write(chunk)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+	return buf.Bytes()
+}
+
+func TestTransitionStateAuditsEveryChange(t *testing.T) {
+	ctx := context.Background()
+	actor := 9
+	later := time.Now().Add(time.Hour)
+	repo := newLifecycleUserRepo(t, entity.User{ID: 1, State: entity.UserStatePending})
+	u := NewUserUsecase(repo, nil)
+
+	steps := []struct {
+		req  entity.TransitionUserStateRequest
+		want error
+	}{
+		{req: entity.TransitionUserStateRequest{State: entity.UserStateGraduated}, want: entity.ErrInvalidStateTransition},
+		{req: entity.TransitionUserStateRequest{State: entity.UserStateActive}},
+		{req: entity.TransitionUserStateRequest{State: entity.UserStateSuspended}, want: entity.ErrValidation},
+		{req: entity.TransitionUserStateRequest{State: entity.UserStateActive, ReactivateAt: &later}, want: entity.ErrValidation},
+		{req: entity.TransitionUserStateRequest{State: entity.UserStateSuspended, Reason: "late fees", ReactivateAt: &later}},
+		{req: entity.TransitionUserStateRequest{State: entity.UserStateGraduated}},
+		{req: entity.TransitionUserStateRequest{State: entity.UserStateActive}, want: entity.ErrInvalidStateTransition},
+		{req: entity.TransitionUserStateRequest{State: entity.UserState("DELETED")}, want: entity.ErrValidation},
+	}
+	for _, step := range steps {
+		if _, err := u.TransitionState(ctx, 1, step.req, &actor); !errors.Is(err, step.want) {
+			t.Errorf("to %s: got %v, want %v", step.req.State, err, step.want)
+		}
+	}
+
+	// only the accepted transitions are logged, with who made them and why
+	want := []entity.UserStateTransition{
+		{UserID: 1, FromState: entity.UserStatePending, ToState: entity.UserStateActive, ActorID: &actor},
+		{UserID: 1, FromState: entity.UserStateActive, ToState: entity.UserStateSuspended, Reason: "late fees", ActorID: &actor},
+		{UserID: 1, FromState: entity.UserStateSuspended, ToState: entity.UserStateGraduated, ActorID: &actor},
+	}
+	if len(repo.transitions) != len(want) {
+		t.Fatalf("logged %+v, want %+v", repo.transitions, want)
+	}
+	for i, got := range repo.transitions {
+		if got.FromState != want[i].FromState || got.ToState != want[i].ToState || got.Reason != want[i].Reason || got.ActorID == nil || *got.ActorID != actor {
+			t.Errorf("transition %d is %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestReactivateDueUsersLogsAnAutomaticTransition(t *testing.T) {
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	repo := newLifecycleUserRepo(t,
+		entity.User{ID: 1, State: entity.UserStateSuspended, ReactivateAt: &past},
+		entity.User{ID: 2, State: entity.UserStateSuspended, ReactivateAt: &future},
+		entity.User{ID: 3, State: entity.UserStateSuspended},
+	)
+
+	count, err := NewUserUsecase(repo, nil).ReactivateDueUsers(context.Background())
+	if err != nil || count != 1 {
+		t.Fatalf("reactivated %d users, %v, want 1", count, err)
+	}
+	if repo.users[1].State != entity.UserStateActive || repo.users[2].State != entity.UserStateSuspended || repo.users[3].State != entity.UserStateSuspended {
+		t.Errorf("states are %s, %s, %s", repo.users[1].State, repo.users[2].State, repo.users[3].State)
+	}
+	if len(repo.transitions) != 1 || repo.transitions[0].ActorID != nil || repo.transitions[0].Reason != "automatic reactivation" {
+		t.Errorf("transitions are %+v, want one without an actor", repo.transitions)
+	}
+}
+
+func TestPatchesRejectNullRequiredMembers(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		field string
+	}{
+		{name: "user name null", err: checkUserPatch(entity.UserPatch{Name: entity.Nullable[string]{Present: true, Null: true}}), field: "name"},
+		{name: "user email empty", err: checkUserPatch(entity.UserPatch{Email: entity.NewNullable("")}), field: "email"},
+		{name: "user status null", err: checkUserPatch(entity.UserPatch{Status: entity.Nullable[bool]{Present: true, Null: true}}), field: "status"},
+		{name: "subject name null", err: checkSubjectPatch(entity.SubjectPatch{Name: entity.Nullable[string]{Present: true, Null: true}}), field: "name"},
+		{name: "user subject null", err: checkUserPatch(entity.UserPatch{SubjectID: entity.Nullable[int]{Present: true, Null: true}, Status: entity.NewNullable(false)})},
+		{name: "subject absent members", err: checkSubjectPatch(entity.SubjectPatch{})},
+	}
+
+	for _, tt := range tests {
+		if tt.field == "" {
+			if tt.err != nil {
+				t.Errorf("%s: %v", tt.name, tt.err)
+			}
+			continue
+		}
+		var domainErr *entity.Error
+		if !errors.As(tt.err, &domainErr) || !errors.Is(tt.err, entity.ErrInvalidPatch) || domainErr.Params["Field"] != tt.field {
+			t.Errorf("%s: got %v, want an invalid patch of %s", tt.name, tt.err, tt.field)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"sample-project/internal/entity"
+
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+)
+
+var (
+	AvatarThumbnailSizes = []int{64, 128, 256}
+	AvatarMaxSize        = 1024
+	// decoding allocates 4 bytes per pixel whatever the file size is, so bigger images are refused before decoding
+	AvatarMaxMegapixels = 40
+)
+
+var (
+	ErrUnsupportedImage = entity.NewError(entity.ErrUnsupportedMediaType, "unsupported_image", "unsupported image type, only JPEG, PNG and GIF are allowed")
+	ErrImageTooLarge    = entity.NewError(entity.ErrPayloadTooLarge, "image_too_large", "image must be at most %d megapixels", AvatarMaxMegapixels).With("Count", AvatarMaxMegapixels)
+)
+
+var allowedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// NOTE - sniffs the real content type from the bytes instead of trusting the client
+func DetectImageType(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if !allowedImageTypes[contentType] {
+		return "", ErrUnsupportedImage
+	}
+	return contentType, nil
+}
+
+// NOTE - decodes an uploaded avatar and re-encodes it as JPEG. Re-encoding drops
+// every metadata segment (EXIF, GPS, XMP) since only the pixels are written back.
+func ProcessAvatar(data []byte) ([]byte, map[int][]byte, error) {
+	if _, err := DetectImageType(data); err != nil {
+		return nil, nil, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, ErrUnsupportedImage
+	}
+	if config.Width*config.Height > AvatarMaxMegapixels*1_000_000 {
+		return nil, nil, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, ErrUnsupportedImage
+	}
+
+	// the EXIF segment is dropped on re-encoding, so its orientation is applied to the pixels first
+	square := cropSquare(orient(img, jpegOrientation(data)))
+
+	size := square.Bounds().Dx()
+	if size > AvatarMaxSize {
+		size = AvatarMaxSize
+	}
+
+	original, err := encodeJPEG(resize(square, size))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	thumbnails := make(map[int][]byte, len(AvatarThumbnailSizes))
+	for _, s := range AvatarThumbnailSizes {
+		thumbnail, err := encodeJPEG(resize(square, s))
+		if err != nil {
+			return nil, nil, err
+		}
+		thumbnails[s] = thumbnail
+	}
+
+	return original, thumbnails, nil
+}
+
+// NOTE - centered square crop so every thumbnail has the same aspect ratio
+func cropSquare(img image.Image) image.Image {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, side, side))
+	// flatten transparency onto white, JPEG has no alpha channel
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, image.Point{X: x, Y: y}, draw.Over)
+	return dst
+}
+
+// NOTE - EXIF orientation (1 to 8) of a JPEG, 1 when there is none
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		// start of scan, the metadata segments all come before it
+		if marker == 0xDA || length < 2 || i+2+length > len(data) {
+			break
+		}
+
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+
+	return 1
+}
+
+// NOTE - orientation tag of the first IFD of a TIFF structure, 1 when it is missing or malformed
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < entries; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if orientation := int(order.Uint16(tiff[entry+8:])); orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+			return 1
+		}
+	}
+
+	return 1
+}
+
+// NOTE - turns img upright for its EXIF orientation, the way a viewer that honors EXIF shows it
+func orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	// 5 to 8 are rotated by 90 degrees, width and height swap
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = w-1-x, y
+			case 3: // upside down
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored upside down
+				sx, sy = x, h-1-y
+			case 5: // mirrored, rotated 90 counter clockwise
+				sx, sy = y, x
+			case 6: // rotated 90 clockwise
+				sx, sy = y, h-1-x
+			case 7: // mirrored, rotated 90 clockwise
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotated 90 counter clockwise
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+func resize(img image.Image, size int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return dst
+}
+
+func encodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
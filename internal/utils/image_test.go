@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+var (
+	red  = color.RGBA{R: 255, A: 255}
+	blue = color.RGBA{B: 255, A: 255}
+)
+
+// 40x20 image, red on top and blue below, so every orientation puts red on a different side
+func twoToneImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			if y < 10 {
+				img.Set(x, y, red)
+			} else {
+				img.Set(x, y, blue)
+			}
+		}
+	}
+	return img
+}
+
+// JPEG of img with an EXIF segment carrying orientation and marker as extra metadata
+func jpegWithExif(t *testing.T, img image.Image, orientation uint16, marker string) []byte {
+	t.Helper()
+
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	// orientation entry: tag, SHORT, one value, value padded to 4 bytes
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+	tiff.WriteString(marker)
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+
+	data := encoded.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	out = append(out, payload...)
+	return append(out, data[2:]...)
+}
+
+// PNG that is only a header declaring width x height pixels, the shape of a decompression bomb
+func pngHeader(width, height uint32) []byte {
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], width)
+	binary.BigEndian.PutUint32(ihdr[4:], height)
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 6 // RGBA
+
+	chunk := append([]byte("IHDR"), ihdr...)
+	var out bytes.Buffer
+	out.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&out, binary.BigEndian, uint32(len(ihdr)))
+	out.Write(chunk)
+	binary.Write(&out, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+	return out.Bytes()
+}
+
+func decode(t *testing.T, data []byte) image.Image {
+	t.Helper()
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("output is not a JPEG: %v", err)
+	}
+	return img
+}
+
+func isRed(c color.Color) bool {
+	r, _, b, _ := c.RGBA()
+	return r > 0xC000 && b < 0x4000
+}
+
+func TestProcessAvatarStripsMetadata(t *testing.T) {
+	const marker = "GPS 17.9757N 102.6331E"
+	original, thumbnails, err := ProcessAvatar(jpegWithExif(t, twoToneImage(), 1, marker))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := map[string][]byte{"original": original}
+	for size, data := range thumbnails {
+		outputs[fmt.Sprintf("thumbnail %d", size)] = data
+	}
+	for name, data := range outputs {
+		if bytes.Contains(data, []byte("Exif")) || bytes.Contains(data, []byte(marker)) {
+			t.Errorf("%s still carries the EXIF segment", name)
+		}
+	}
+}
+
+func TestProcessAvatarAppliesOrientation(t *testing.T) {
+	// side of the upright image the red half ends up on
+	tests := []struct {
+		orientation uint16
+		redSide     string
+	}{
+		{1, "top"},
+		{2, "top"},
+		{3, "bottom"},
+		{4, "bottom"},
+		{5, "left"},
+		{6, "right"},
+		{7, "right"},
+		{8, "left"},
+	}
+
+	for _, tt := range tests {
+		_, thumbnails, err := ProcessAvatar(jpegWithExif(t, twoToneImage(), tt.orientation, ""))
+		if err != nil {
+			t.Fatalf("orientation %d: %v", tt.orientation, err)
+		}
+
+		img := decode(t, thumbnails[64])
+		sides := map[string]image.Point{"top": {32, 4}, "bottom": {32, 59}, "left": {4, 32}, "right": {59, 32}}
+		opposite := map[string]string{"top": "bottom", "bottom": "top", "left": "right", "right": "left"}
+		if p := sides[tt.redSide]; !isRed(img.At(p.X, p.Y)) {
+			t.Errorf("orientation %d: the %s side is not red", tt.orientation, tt.redSide)
+		}
+		if p := sides[opposite[tt.redSide]]; isRed(img.At(p.X, p.Y)) {
+			t.Errorf("orientation %d: the %s side is red", tt.orientation, opposite[tt.redSide])
+		}
+	}
+}
+
+func TestProcessAvatarThumbnailSizes(t *testing.T) {
+	big := image.NewRGBA(image.Rect(0, 0, 1500, 1200))
+	var data bytes.Buffer
+	if err := png.Encode(&data, big); err != nil {
+		t.Fatal(err)
+	}
+
+	original, thumbnails, err := ProcessAvatar(data.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b := decode(t, original).Bounds(); b.Dx() != AvatarMaxSize || b.Dy() != AvatarMaxSize {
+		t.Errorf("original is %dx%d, want the %d square the crop is capped at", b.Dx(), b.Dy(), AvatarMaxSize)
+	}
+	if len(thumbnails) != len(AvatarThumbnailSizes) {
+		t.Fatalf("got %d thumbnails, want %d", len(thumbnails), len(AvatarThumbnailSizes))
+	}
+	for _, size := range AvatarThumbnailSizes {
+		if b := decode(t, thumbnails[size]).Bounds(); b.Dx() != size || b.Dy() != size {
+			t.Errorf("thumbnail %d is %dx%d", size, b.Dx(), b.Dy())
+		}
+	}
+}
+
+func TestProcessAvatarRejectsDecompressionBomb(t *testing.T) {
+	_, _, err := ProcessAvatar(pngHeader(50000, 50000))
+	if !errors.Is(err, ErrImageTooLarge) {
+		t.Fatalf("got %v, want %v", err, ErrImageTooLarge)
+	}
+}
+
+func TestProcessAvatarRejectsNonImages(t *testing.T) {
+	_, _, err := ProcessAvatar([]byte("%PDF-1.7 not an image"))
+	if !errors.Is(err, ErrUnsupportedImage) {
+		t.Fatalf("got %v, want %v", err, ErrUnsupportedImage)
+	}
+}
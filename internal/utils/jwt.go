@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"errors"
 	"os"
 	"time"
@@ -8,9 +9,15 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// longest an access token lives. Tokens are not checked against the account on every request, suspending
+// a user refuses their next refresh, so this bounds how long a suspended user keeps working.
+// JWT_EXPIRATION_TIME can only shorten it
+const MaxAccessTokenLifetime = 15 * time.Minute
+
 type Claims struct {
-	UserID int    `json:"user_id"`
-	Name   string `json:"name"`
+	UserID   int    `json:"user_id"`
+	TenantID int    `json:"tenant_id"`
+	Name     string `json:"name"`
 	jwt.RegisteredClaims
 }
 
@@ -28,16 +35,17 @@ func getExpirationTime(envVar string, defaultDuration time.Duration) time.Durati
 	return duration
 }
 
-func GenerateToken(userID int, name string) (string, string, error) {
+func GenerateToken(userID, tenantID int, name string) (string, string, error) {
 	accessSecret := []byte(os.Getenv("JWT_SECRET"))
 	refreshSecret := []byte(os.Getenv("JWT_REFRESH_SECRET"))
 
-	accessExpiration := time.Now().Add(getExpirationTime("JWT_EXPIRATION_TIME", 15*time.Minute))
+	accessExpiration := time.Now().Add(min(getExpirationTime("JWT_EXPIRATION_TIME", MaxAccessTokenLifetime), MaxAccessTokenLifetime))
 	refreshExpiration := time.Now().Add(getExpirationTime("JWT_REFRESH_EXPIRATION_TIME", 7*24*time.Hour))
 
 	accessClaims := &Claims{
-		UserID: userID,
-		Name:   name,
+		UserID:   userID,
+		TenantID: tenantID,
+		Name:     name,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(accessExpiration),
 		},
@@ -49,8 +57,9 @@ func GenerateToken(userID int, name string) (string, string, error) {
 	}
 
 	refreshClaims := &Claims{
-		UserID: userID,
-		Name:   name,
+		UserID:   userID,
+		TenantID: tenantID,
+		Name:     name,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(refreshExpiration),
 		},
@@ -67,7 +76,7 @@ func GenerateToken(userID int, name string) (string, string, error) {
 func ValidateToken(tokenString string, isRefresh bool) (*Claims, error) {
 	var secretKey []byte
 	if isRefresh {
-		secretKey = []byte(os.Getenv("JWT_REFRESH_TOKEN_SECRET"))
+		secretKey = []byte(os.Getenv("JWT_REFRESH_SECRET"))
 	} else {
 		secretKey = []byte(os.Getenv("JWT_SECRET"))
 	}
@@ -85,4 +94,17 @@ func ValidateToken(tokenString string, isRefresh bool) (*Claims, error) {
 	}
 
 	return claims, nil
-}
\ No newline at end of file
+}
+
+type claimsKey struct{}
+
+// NOTE - returns a copy of ctx carrying the claims of the access token the request was authenticated with
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// NOTE - claims of the access token ctx was authenticated with, nil when it carried no valid one
+func ClaimsFrom(ctx context.Context) *Claims {
+	claims, _ := ctx.Value(claimsKey{}).(*Claims)
+	return claims
+}
@@ -0,0 +1,209 @@
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sample-project/internal/entity"
+	"sample-project/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// NOTE - registers the custom rules on gin's validator, must run before the first request is bound
+func Register(userRepo repository.UserRepository, subjectRepo repository.SubjectRepository) {
+	validate := engine()
+
+	// report fields by their json name so errors match the request body
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	// merge patch members are validated by their value, absent or null members are skipped by omitempty
+	validate.RegisterCustomTypeFunc(nullableValue,
+		entity.Nullable[string]{}, entity.Nullable[int]{}, entity.Nullable[bool]{})
+
+	validate.RegisterValidation("user_state", func(fl validator.FieldLevel) bool {
+		return entity.UserState(fl.Field().String()).IsValid()
+	})
+
+	validate.RegisterValidation("future", func(fl validator.FieldLevel) bool {
+		t, ok := fl.Field().Interface().(time.Time)
+		return ok && t.After(time.Now())
+	})
+
+	validate.RegisterValidationCtx("subject_exists", func(ctx context.Context, fl validator.FieldLevel) bool {
+		_, err := subjectRepo.GetSubjectByID(ctx, int(fl.Field().Int()), entity.Projection{})
+		return err == nil
+	})
+
+	validate.RegisterValidationCtx("email_unique", func(ctx context.Context, fl validator.FieldLevel) bool {
+		_, err := userRepo.GetUserByEmail(ctx, fl.Field().String())
+		return errors.Is(err, entity.ErrNotFound)
+	})
+}
+
+// NOTE - strictly decodes a JSON body into obj, unknown members are rejected
+func DecodeJSON(body io.Reader, obj interface{}) error {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(obj)
+	if err == nil {
+		return nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr):
+		return entity.InvalidFields(entity.FieldError{
+			Field:     typeErr.Field,
+			Code:      "invalid_type",
+			Message:   fmt.Sprintf("must be of type %s", typeErr.Type),
+			MessageID: "validation.invalid_type",
+			Params:    map[string]interface{}{"Type": typeErr.Type.String()},
+		})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return entity.InvalidFields(entity.FieldError{
+			Field:     field,
+			Code:      "unknown_field",
+			Message:   "is not allowed",
+			MessageID: "validation.unknown_field",
+		})
+	case errors.Is(err, io.EOF):
+		return entity.BadRequest("invalid_body", "request body is empty").Localized("invalid_body.empty")
+	default:
+		return entity.BadRequest("invalid_body", "request body is not valid JSON").Localized("invalid_body.json")
+	}
+}
+
+// NOTE - runs the binding rules of obj and reports every failing field
+func Struct(ctx context.Context, obj interface{}) error {
+	err := engine().StructCtx(ctx, obj)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	fields := make([]entity.FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		messageID, params := catalogMessage(fe)
+		fields = append(fields, entity.FieldError{
+			Field:     fe.Field(),
+			Code:      fe.Tag(),
+			Message:   message(fe),
+			MessageID: messageID,
+			Params:    params,
+		})
+	}
+
+	return entity.InvalidFields(fields...)
+}
+
+// NOTE - gin's validator engine, so `binding` tags mean the same thing everywhere
+func engine() *validator.Validate {
+	return binding.Validator.Engine().(*validator.Validate)
+}
+
+// NOTE - human readable message for a failed rule
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_if":
+		return fmt.Sprintf("is required when %s", condition(fe.Param()))
+	case "excluded_unless":
+		return fmt.Sprintf("is only allowed when %s", condition(fe.Param()))
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of %s", strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "alphanum":
+		return "must contain only letters and digits"
+	case "user_state":
+		return "must be one of PENDING, ACTIVE, SUSPENDED, GRADUATED"
+	case "future":
+		return "must be in the future"
+	case "subject_exists":
+		return "subject does not exist"
+	case "email_unique":
+		return "email is already taken"
+	default:
+		return fmt.Sprintf("failed the %s rule", fe.Tag())
+	}
+}
+
+// NOTE - catalog message of a failed rule and the values it is filled in with, the counterpart of message
+func catalogMessage(fe validator.FieldError) (string, map[string]interface{}) {
+	switch fe.Tag() {
+	case "required_if", "excluded_unless":
+		field, value, _ := strings.Cut(fe.Param(), " ")
+		return "validation." + fe.Tag(), map[string]interface{}{"Field": strings.ToLower(field), "Value": value}
+	case "min", "max":
+		var count interface{} = fe.Param()
+		if n, err := strconv.Atoi(fe.Param()); err == nil {
+			count = n
+		}
+		if fe.Kind() == reflect.String {
+			return "validation." + fe.Tag() + ".length", map[string]interface{}{"Count": count}
+		}
+		return "validation." + fe.Tag(), map[string]interface{}{"Count": count}
+	case "oneof":
+		return "validation.oneof", map[string]interface{}{"Values": strings.ReplaceAll(fe.Param(), " ", ", ")}
+	case "required", "email", "alphanum", "user_state", "future", "subject_exists", "email_unique":
+		return "validation." + fe.Tag(), nil
+	default:
+		return "validation.rule", map[string]interface{}{"Rule": fe.Tag()}
+	}
+}
+
+// NOTE - turns a cross-field param like `State SUSPENDED` into `state is SUSPENDED`
+func condition(param string) string {
+	field, value, _ := strings.Cut(param, " ")
+	return strings.ToLower(field) + " is " + value
+}
+
+// NOTE - unwraps a Nullable so the rules see its value, nil when it was absent or null
+func nullableValue(v reflect.Value) interface{} {
+	switch n := v.Interface().(type) {
+	case entity.Nullable[string]:
+		if n.IsSet() {
+			return n.Value
+		}
+	case entity.Nullable[int]:
+		if n.IsSet() {
+			return n.Value
+		}
+	case entity.Nullable[bool]:
+		if n.IsSet() {
+			return n.Value
+		}
+	}
+	return nil
+}
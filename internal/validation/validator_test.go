@@ -0,0 +1,119 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"sample-project/internal/entity"
+	"sample-project/internal/repository"
+	"strings"
+	"testing"
+	"time"
+)
+
+// knownUsers has a single user, taken@example.com
+type knownUsers struct{ repository.UserRepository }
+
+func (knownUsers) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
+	if email == "taken@example.com" {
+		return &entity.User{ID: 1, Email: email}, nil
+	}
+	return nil, entity.NotFound("user_not_found", "user not found")
+}
+
+// knownSubjects has a single subject, 2
+type knownSubjects struct{ repository.SubjectRepository }
+
+func (knownSubjects) GetSubjectByID(ctx context.Context, id int, projection entity.Projection) (*entity.Subject, error) {
+	if id == 2 {
+		return &entity.Subject{ID: 2}, nil
+	}
+	return nil, entity.NotFound("subject_not_found", "subject not found")
+}
+
+// NOTE - field and rule of every field error err lists, as `field:rule`
+func failedRules(t *testing.T, err error) string {
+	t.Helper()
+	if err == nil {
+		return ""
+	}
+
+	var domainErr *entity.Error
+	if !errors.As(err, &domainErr) || !errors.Is(err, entity.ErrValidation) {
+		t.Fatalf("got %v, want a validation error", err)
+	}
+	rules := make([]string, 0, len(domainErr.Fields))
+	for _, field := range domainErr.Fields {
+		if field.Message == "" || field.MessageID == "" {
+			t.Errorf("%s has no message: %+v", field.Field, field)
+		}
+		rules = append(rules, field.Field+":"+field.Code)
+	}
+	return strings.Join(rules, " ")
+}
+
+func TestStructReportsEveryFailingField(t *testing.T) {
+	Register(knownUsers{}, knownSubjects{})
+	later := time.Now().Add(time.Hour)
+	earlier := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name string
+		obj  interface{}
+		want string
+	}{
+		{name: "empty user", obj: &entity.CreateUserRequest{}, want: "name:required email:required password:required"},
+		{name: "malformed user", obj: &entity.CreateUserRequest{Name: "N", Email: "noy", Password: "short", SubjectID: 2}, want: "name:min email:email password:min"},
+		{name: "taken email", obj: &entity.CreateUserRequest{Name: "Noy", Email: "taken@example.com", Password: "password1"}, want: "email:email_unique"},
+		{name: "missing subject", obj: &entity.CreateUserRequest{Name: "Noy", Email: "noy@example.com", Password: "password1", SubjectID: 3}, want: "subject_id:subject_exists"},
+		{name: "valid user", obj: &entity.CreateUserRequest{Name: "Noy", Email: "noy@example.com", Password: "password1", SubjectID: 2}},
+		{name: "empty subject", obj: &entity.CreateSubjectRequest{}, want: "name:required"},
+		{name: "suspension without reason", obj: &entity.TransitionUserStateRequest{State: entity.UserStateSuspended}, want: "reason:required_if"},
+		{name: "reactivation in the past", obj: &entity.TransitionUserStateRequest{State: entity.UserStateSuspended, Reason: "unpaid fees", ReactivateAt: &earlier}, want: "reactivate_at:future"},
+		{name: "reactivation of an active user", obj: &entity.TransitionUserStateRequest{State: entity.UserStateActive, ReactivateAt: &later}, want: "reactivate_at:excluded_unless"},
+		{name: "unknown state", obj: &entity.TransitionUserStateRequest{State: "DELETED"}, want: "state:user_state"},
+		{name: "patch member too short", obj: &entity.UserPatch{Name: entity.NewNullable("N"), Email: entity.Nullable[string]{Present: true, Null: true}}, want: "name:min"},
+		{name: "patch of a missing subject", obj: &entity.UserPatch{SubjectID: entity.NewNullable(3)}, want: "subject_id:subject_exists"},
+		{name: "empty patch", obj: &entity.UserPatch{}},
+	}
+
+	for _, tt := range tests {
+		if got := failedRules(t, Struct(context.Background(), tt.obj)); got != tt.want {
+			t.Errorf("%s: failed %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeJSONIsStrict(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		code string
+		want string
+	}{
+		{name: "unknown member", body: `{"name":"Noy","role":"admin"}`, code: "validation_failed", want: "role:unknown_field"},
+		{name: "wrong type", body: `{"name":"Noy","subject_id":"two"}`, code: "validation_failed", want: "subject_id:invalid_type"},
+		{name: "empty", body: ``, code: "invalid_body"},
+		{name: "not json", body: `name=Noy`, code: "invalid_body"},
+	}
+
+	for _, tt := range tests {
+		var req entity.CreateUserRequest
+		err := DecodeJSON(strings.NewReader(tt.body), &req)
+
+		var domainErr *entity.Error
+		if !errors.As(err, &domainErr) || domainErr.Code != tt.code {
+			t.Errorf("%s: got %v, want %s", tt.name, err, tt.code)
+			continue
+		}
+		if tt.want != "" {
+			if got := failedRules(t, err); got != tt.want {
+				t.Errorf("%s: failed %q, want %q", tt.name, got, tt.want)
+			}
+		}
+	}
+
+	var req entity.CreateUserRequest
+	if err := DecodeJSON(strings.NewReader(`{"name":"Noy","email":"noy@example.com"}`), &req); err != nil || req.Email != "noy@example.com" {
+		t.Errorf("decoded %+v, %v", req, err)
+	}
+}
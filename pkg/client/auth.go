@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sample-project/internal/entity"
+)
+
+const authPath = "/api/v2/auth"
+
+// NOTE - logs in and keeps the tokens for the following calls
+func (c *Client) Login(ctx context.Context, name, password string) (*Tokens, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tokens, err := c.login(ctx, name, password)
+	if err != nil {
+		return nil, err
+	}
+	c.tokens = tokens
+	return &tokens, nil
+}
+
+// NOTE - trades the refresh token for a new token pair right away, calls do it on their own once the access token expired
+func (c *Client) Refresh(ctx context.Context) (*Tokens, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tokens.RefreshToken == "" {
+		return nil, ErrNoCredentials
+	}
+
+	tokens, err := c.refresh(ctx, c.tokens.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+	c.tokens = tokens
+	return &tokens, nil
+}
+
+// NOTE - current tokens, to hand them to another client with WithTokens
+func (c *Client) Tokens() Tokens {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.tokens
+}
+
+// NOTE - the authenticated user
+func (c *Client) Me(ctx context.Context) (*User, error) {
+	var profile entity.ProfileResponse
+	req := request{method: http.MethodGet, path: authPath + "/me", retryable: true}
+	if err := c.do(ctx, req, &profile); err != nil {
+		return nil, err
+	}
+	return profile.User, nil
+}
+
+// NOTE - access token for the next call, logs in first when there is none yet but the client has credentials
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tokens.AccessToken == "" && c.credentials != nil {
+		tokens, err := c.login(ctx, c.credentials.name, c.credentials.password)
+		if err != nil {
+			return "", err
+		}
+		c.tokens = tokens
+	}
+	return c.tokens.AccessToken, nil
+}
+
+// NOTE - new access token after stale was rejected. The refresh token is tried first, the credentials after it
+func (c *Client) renew(ctx context.Context, stale string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// another call renewed the tokens while this one waited for the lock
+	if c.tokens.AccessToken != stale && c.tokens.AccessToken != "" {
+		return c.tokens.AccessToken, nil
+	}
+
+	var err error = ErrNoCredentials
+	if c.tokens.RefreshToken != "" {
+		var tokens Tokens
+		if tokens, err = c.refresh(ctx, c.tokens.RefreshToken); err == nil {
+			c.tokens = tokens
+			return tokens.AccessToken, nil
+		}
+	}
+
+	if c.credentials != nil {
+		var tokens Tokens
+		if tokens, err = c.login(ctx, c.credentials.name, c.credentials.password); err == nil {
+			c.tokens = tokens
+			return tokens.AccessToken, nil
+		}
+	}
+
+	return "", err
+}
+
+// NOTE - login call, the caller holds mu
+func (c *Client) login(ctx context.Context, name, password string) (Tokens, error) {
+	req, err := jsonRequest(http.MethodPost, authPath+"/login", entity.LoginRequest{Name: name, Password: password})
+	if err != nil {
+		return Tokens{}, err
+	}
+	req.anonymous = true
+
+	var tokens Tokens
+	if err := c.do(ctx, req, &tokens); err != nil {
+		return Tokens{}, err
+	}
+	return tokens, nil
+}
+
+// NOTE - refresh call, the caller holds mu
+func (c *Client) refresh(ctx context.Context, refreshToken string) (Tokens, error) {
+	req, err := jsonRequest(http.MethodPost, authPath+"/refresh", entity.RefreshRequest{RefreshToken: refreshToken})
+	if err != nil {
+		return Tokens{}, err
+	}
+	req.anonymous = true
+
+	var tokens Tokens
+	if err := c.do(ctx, req, &tokens); err != nil {
+		return Tokens{}, err
+	}
+	return tokens, nil
+}
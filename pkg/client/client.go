@@ -0,0 +1,284 @@
+// Package client is the Go client of the v2 HTTP API. It logs in and refreshes tokens on its own,
+// retries calls that are safe to repeat and decodes error responses into *Error.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RetryPolicy decides how often and how fast a failed call is sent again.
+// Only calls a replay cannot change the outcome of are retried, after transport errors, 429, 502, 503 and 504.
+type RetryPolicy struct {
+	// attempts including the first one, 1 turns retries off
+	MaxAttempts int
+	// wait before the first retry, doubled for every further one
+	BaseDelay time.Duration
+	// longest wait, Retry-After of the server included
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy of a client created without WithRetry.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: 200 * time.Millisecond, MaxDelay: 5 * time.Second}
+
+// Client calls the API. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	retry      RetryPolicy
+	userAgent  string
+	apiKey     string
+
+	// guards the tokens, held while they are renewed so concurrent calls renew them once
+	mu          sync.Mutex
+	tokens      Tokens
+	credentials *credentials
+}
+
+type credentials struct {
+	name     string
+	password string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// NOTE - sends the calls with httpClient instead of a client with a 30s timeout
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// NOTE - logs in with the credentials before the first call and whenever the refresh token expired
+func WithCredentials(name, password string) Option {
+	return func(c *Client) { c.credentials = &credentials{name: name, password: password} }
+}
+
+// NOTE - starts with tokens obtained elsewhere, they are refreshed like the ones of Login
+func WithTokens(tokens Tokens) Option {
+	return func(c *Client) { c.tokens = tokens }
+}
+
+// NOTE - replaces DefaultRetryPolicy
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) { c.retry = policy }
+}
+
+// NOTE - names the school with an API key on every call, for servers that are not reached on the host of the school
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) { c.apiKey = apiKey }
+}
+
+// NOTE - User-Agent of every call, to tell the calling services apart in the access log
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+// NOTE - new client for the API at baseURL, e.g. http://users.internal:8080
+func New(baseURL string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid base URL: %w", err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("client: base URL %q needs a scheme and a host", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    parsed,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		retry:      DefaultRetryPolicy,
+		userAgent:  "sample-project-client",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.retry.MaxAttempts < 1 {
+		c.retry.MaxAttempts = 1
+	}
+
+	return c, nil
+}
+
+// request is one API call, its body is kept so it can be sent again.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	header      http.Header
+	body        []byte
+	contentType string
+	// sent without a token and never renewed, login and refresh themselves
+	anonymous bool
+	// a replay cannot change the outcome, so failures worth retrying are retried
+	retryable bool
+}
+
+// NOTE - request with a JSON body
+func jsonRequest(method, path string, body interface{}) (request, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return request{}, fmt.Errorf("client: encode request: %w", err)
+	}
+	return request{method: method, path: path, body: data, contentType: "application/json"}, nil
+}
+
+// NOTE - sends the request and decodes a successful response into out, error responses become *Error
+func (c *Client) do(ctx context.Context, req request, out interface{}) error {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeError(resp)
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("client: decode %s %s response: %w", req.method, req.path, err)
+	}
+	return nil
+}
+
+// NOTE - sends the request with the current access token. A rejected token is renewed once and the request sent again
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	if req.anonymous {
+		return c.sendWithRetry(ctx, req, "")
+	}
+
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.sendWithRetry(ctx, req, token)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	renewed, err := c.renew(ctx, token)
+	if err != nil {
+		// the caller learns about the rejected token, not about the failed renewal
+		return resp, nil
+	}
+	drain(resp)
+
+	return c.sendWithRetry(ctx, req, renewed)
+}
+
+// NOTE - sends the request until it succeeds, fails for good or runs out of attempts
+func (c *Client) sendWithRetry(ctx context.Context, req request, token string) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := c.sendOnce(ctx, req, token)
+		if !req.retryable || attempt >= c.retry.MaxAttempts || !shouldRetry(ctx, resp, err) {
+			return resp, err
+		}
+
+		delay := c.retry.delay(attempt, resp)
+		if resp != nil {
+			drain(resp)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// NOTE - sends the request once
+func (c *Client) sendOnce(ctx context.Context, req request, token string) (*http.Response, error) {
+	target := *c.baseURL
+	target.Path += req.path
+	target.RawQuery = req.query.Encode()
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target.String(), bytes.NewReader(req.body))
+	if err != nil {
+		return nil, fmt.Errorf("client: build request: %w", err)
+	}
+
+	for name, values := range req.header {
+		httpReq.Header[name] = values
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+	if c.apiKey != "" {
+		httpReq.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	return c.httpClient.Do(httpReq)
+}
+
+// NOTE - reports whether a failure is worth another attempt
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// NOTE - wait before the next attempt, Retry-After when the server sent it, else exponential backoff with jitter
+func (p RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return min(wait, p.MaxDelay)
+		}
+	}
+
+	backoff := min(p.BaseDelay<<(attempt-1), p.MaxDelay)
+	if backoff <= 0 {
+		return 0
+	}
+	// half fixed, half random, so clients that failed together do not retry together
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+// NOTE - parses Retry-After, in seconds or as an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// NOTE - reads the rest of a response that is not used, so its connection is reused
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+	resp.Body.Close()
+}
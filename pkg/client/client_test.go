@@ -0,0 +1,233 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// NOTE - client of a test server that answers with handler, retries are fast and the client is logged in
+func testClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := New(server.URL,
+		WithTokens(Tokens{AccessToken: "access", RefreshToken: "refresh"}),
+		WithRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestUsersPagesUntilShortPage(t *testing.T) {
+	const stored, limit = 5, 2
+	var mu sync.Mutex
+	var pages []int
+
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		mu.Lock()
+		pages = append(pages, page)
+		mu.Unlock()
+
+		var users []User
+		for id := (page-1)*limit + 1; id <= min(page*limit, stored); id++ {
+			users = append(users, User{ID: id})
+		}
+		// the total of one page, as older servers counted it, must not end the listing early
+		writeJSON(w, http.StatusOK, UserPage{Data: users, Meta: PageMeta{Page: page, Limit: limit, Total: len(users), TotalPages: 1}})
+	})
+
+	var ids []int
+	for user, err := range c.Users(context.Background(), ListUsersParams{Limit: limit}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, user.ID)
+	}
+
+	if len(ids) != stored || ids[0] != 1 || ids[stored-1] != stored {
+		t.Errorf("got users %v, want 1 to %d", ids, stored)
+	}
+	if len(pages) != 3 {
+		t.Errorf("fetched pages %v, want 1 to 3", pages)
+	}
+}
+
+func TestUsersStopsAtFirstError(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			writeJSON(w, http.StatusForbidden, map[string]string{"code": "forbidden", "message": "no"})
+			return
+		}
+		writeJSON(w, http.StatusOK, UserPage{Data: []User{{ID: 1}}, Meta: PageMeta{Page: 1, Limit: 1, Total: 2, TotalPages: 2}})
+	})
+
+	var users int
+	var last error
+	for _, err := range c.Users(context.Background(), ListUsersParams{Limit: 1}) {
+		if err != nil {
+			last = err
+			continue
+		}
+		users++
+	}
+
+	if users != 1 || !IsCode(last, "forbidden") {
+		t.Errorf("got %d users and %v, want 1 user and the forbidden error", users, last)
+	}
+}
+
+func TestErrorResponsesMapToTypedErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		code   string
+		is     func(error) bool
+	}{
+		{name: "not found", status: http.StatusNotFound, body: `{"code":"user_not_found","message":"user not found"}`, code: "user_not_found", is: IsNotFound},
+		{name: "precondition failed", status: http.StatusPreconditionFailed, body: `{"code":"version_mismatch","message":"stale"}`, code: "version_mismatch", is: IsPreconditionFailed},
+		{name: "conflict", status: http.StatusConflict, body: `{"code":"user_email_taken","message":"taken"}`, code: "user_email_taken", is: IsConflict},
+		{name: "validation", status: http.StatusUnprocessableEntity, body: `{"code":"validation_failed","message":"invalid","errors":[{"field":"email","code":"email","message":"must be a valid email address"}]}`, code: "validation_failed", is: IsValidation},
+		{name: "bad request", status: http.StatusBadRequest, body: `{"code":"invalid_body","message":"invalid"}`, code: "invalid_body", is: IsValidation},
+		{name: "unauthorized", status: http.StatusUnauthorized, body: `{"code":"invalid_token","message":"expired"}`, code: "invalid_token", is: IsUnauthorized},
+		{name: "proxy page", status: http.StatusNotFound, body: `<html>not found</html>`, is: IsNotFound},
+	}
+
+	for _, tt := range tests {
+		c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == authPath+"/refresh" {
+				// the renewal fails, so the rejected token reaches the caller
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"code": "invalid_token", "message": "expired"})
+				return
+			}
+			w.Header().Set("X-Request-ID", "req-1")
+			w.WriteHeader(tt.status)
+			_, _ = w.Write([]byte(tt.body))
+		})
+
+		_, err := c.GetUser(context.Background(), 1, Projection{})
+		if !tt.is(err) {
+			t.Errorf("%s: got %v", tt.name, err)
+			continue
+		}
+		apiErr := err.(*Error)
+		if apiErr.Code != tt.code || apiErr.RequestID != "req-1" {
+			t.Errorf("%s: got %+v, want code %q and the request id", tt.name, apiErr, tt.code)
+		}
+		if tt.name == "validation" && (len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != "email") {
+			t.Errorf("%s: fields are %+v", tt.name, apiErr.Fields)
+		}
+	}
+}
+
+func TestRetriesOnlyCallsSafeToRepeat(t *testing.T) {
+	tests := []struct {
+		name     string
+		call     func(c *Client) error
+		attempts int
+	}{
+		{name: "read", attempts: 3, call: func(c *Client) error {
+			_, err := c.GetUser(context.Background(), 1, Projection{})
+			return err
+		}},
+		{name: "create with idempotency key", attempts: 3, call: func(c *Client) error {
+			_, err := c.CreateUser(context.Background(), CreateUserRequest{Name: "Noy"})
+			return err
+		}},
+		{name: "conditional update", attempts: 1, call: func(c *Client) error {
+			_, err := c.UpdateUser(context.Background(), 1, 3, UpdateUserRequest{})
+			return err
+		}},
+		{name: "conditional delete", attempts: 1, call: func(c *Client) error {
+			return c.DeleteUser(context.Background(), 1, 3)
+		}},
+	}
+
+	for _, tt := range tests {
+		var mu sync.Mutex
+		var attempts int
+		keys := map[string]bool{}
+
+		c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			attempts++
+			attempt := attempts
+			keys[r.Header.Get("Idempotency-Key")] = true
+			mu.Unlock()
+
+			if attempt < 3 {
+				w.Header().Set("Retry-After", "0")
+				writeJSON(w, http.StatusServiceUnavailable, map[string]string{"code": "unavailable", "message": "try again"})
+				return
+			}
+			writeJSON(w, http.StatusOK, User{ID: 1, Version: 1})
+		})
+
+		err := tt.call(c)
+		if attempts != tt.attempts {
+			t.Errorf("%s: sent %d times, want %d", tt.name, attempts, tt.attempts)
+		}
+		if tt.attempts == 3 && err != nil {
+			t.Errorf("%s: %v after the retries", tt.name, err)
+		}
+		if tt.attempts == 1 && !IsStatus(err, http.StatusServiceUnavailable) {
+			t.Errorf("%s: got %v, want the 503", tt.name, err)
+		}
+		if tt.name == "create with idempotency key" && (len(keys) != 1 || keys[""]) {
+			t.Errorf("%s: sent keys %v, want one key for every attempt", tt.name, keys)
+		}
+	}
+}
+
+func TestConditionalWritesSendIfMatch(t *testing.T) {
+	var ifMatch []string
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		ifMatch = append(ifMatch, r.Header.Get("If-Match"))
+		if r.Header.Get("If-Match") != `"3"` {
+			writeJSON(w, http.StatusPreconditionFailed, map[string]string{"code": "version_mismatch", "message": "stale"})
+			return
+		}
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSON(w, http.StatusOK, User{ID: 1, Version: 4})
+	})
+	ctx := context.Background()
+
+	if user, err := c.UpdateUser(ctx, 1, 3, UpdateUserRequest{}); err != nil || user.Version != 4 {
+		t.Errorf("update at the current version: %+v, %v", user, err)
+	}
+	if _, err := c.PatchUser(ctx, 1, 3, UserPatch{Name: Set("Noy")}); err != nil {
+		t.Errorf("patch at the current version: %v", err)
+	}
+	if _, err := c.PatchUser(ctx, 1, 2, UserPatch{}); !IsPreconditionFailed(err) {
+		t.Errorf("patch at a stale version: got %v, want a failed precondition", err)
+	}
+	if err := c.DeleteUser(ctx, 1, 3); err != nil {
+		t.Errorf("delete at the current version: %v", err)
+	}
+
+	want := []string{`"3"`, `"3"`, `"2"`, `"3"`}
+	for i := range want {
+		if i >= len(ifMatch) || ifMatch[i] != want[i] {
+			t.Fatalf("sent If-Match %v, want %v", ifMatch, want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrNoCredentials is returned when tokens have to be renewed but the client has neither a refresh token nor credentials.
+var ErrNoCredentials = errors.New("client: no refresh token or credentials to renew the access token with")
+
+// Error is an error response of the API, decoded from its error envelope.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Fields     []FieldError
+	// X-Request-ID of the failed request, to find it in the server logs
+	RequestID string
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("api: %d %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("api: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// NOTE - reports whether err is an API error with the status code
+func IsStatus(err error, status int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+// NOTE - reports whether err is an API error with the machine readable code, e.g. user_email_taken
+func IsCode(err error, code string) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// NOTE - reports whether the resource does not exist
+func IsNotFound(err error) bool {
+	return IsStatus(err, http.StatusNotFound)
+}
+
+// NOTE - reports whether the write lost against a concurrent one, read the resource again and retry
+func IsPreconditionFailed(err error) bool {
+	return IsStatus(err, http.StatusPreconditionFailed)
+}
+
+// NOTE - reports whether the write conflicts with the current state, e.g. a taken email
+func IsConflict(err error) bool {
+	return IsStatus(err, http.StatusConflict)
+}
+
+// NOTE - reports whether the request was rejected for its content, Error.Fields says which
+func IsValidation(err error) bool {
+	return IsStatus(err, http.StatusUnprocessableEntity) || IsStatus(err, http.StatusBadRequest)
+}
+
+// NOTE - reports whether the credentials or tokens were rejected
+func IsUnauthorized(err error) bool {
+	return IsStatus(err, http.StatusUnauthorized)
+}
+
+// NOTE - error of a response with a status of 400 or above
+func decodeError(resp *http.Response) error {
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		Message:    http.StatusText(resp.StatusCode),
+		RequestID:  resp.Header.Get("X-Request-ID"),
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if err != nil {
+		return apiErr
+	}
+
+	var envelope struct {
+		Code    string       `json:"code"`
+		Message string       `json:"message"`
+		Errors  []FieldError `json:"errors"`
+	}
+	// proxies in front of the API answer with anything, the status still tells what happened
+	if json.Unmarshal(body, &envelope) == nil && envelope.Code != "" {
+		apiErr.Code = envelope.Code
+		apiErr.Message = envelope.Message
+		apiErr.Fields = envelope.Errors
+	}
+
+	return apiErr
+}
+
+// error bodies are small, anything larger is not the error envelope
+const maxErrorBody = 1 << 20
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+const subjectsPath = "/api/v2/subjects"
+
+// NOTE - every subject, their users only with IncludeUsers
+func (c *Client) ListSubjects(ctx context.Context, projection Projection) ([]Subject, error) {
+	var subjects []Subject
+	req := request{method: http.MethodGet, path: subjectsPath, query: projection.query(), retryable: true}
+	if err := c.do(ctx, req, &subjects); err != nil {
+		return nil, err
+	}
+	return subjects, nil
+}
+
+// NOTE - subject by id
+func (c *Client) GetSubject(ctx context.Context, id int, projection Projection) (*Subject, error) {
+	var subject Subject
+	req := request{method: http.MethodGet, path: subjectPath(id), query: projection.query(), retryable: true}
+	if err := c.do(ctx, req, &subject); err != nil {
+		return nil, err
+	}
+	return &subject, nil
+}
+
+// NOTE - creates a subject. Retries carry the same Idempotency-Key, so the subject is created once
+func (c *Client) CreateSubject(ctx context.Context, create CreateSubjectRequest) (*Subject, error) {
+	req, err := jsonRequest(http.MethodPost, subjectsPath, create)
+	if err != nil {
+		return nil, err
+	}
+	req.header = idempotencyHeader()
+	req.retryable = true
+
+	var subject Subject
+	if err := c.do(ctx, req, &subject); err != nil {
+		return nil, err
+	}
+	return &subject, nil
+}
+
+// NOTE - replaces the subject
+func (c *Client) UpdateSubject(ctx context.Context, id int, update UpdateSubjectRequest) (*Subject, error) {
+	req, err := jsonRequest(http.MethodPut, subjectPath(id), update)
+	if err != nil {
+		return nil, err
+	}
+	req.retryable = true
+
+	var subject Subject
+	if err := c.do(ctx, req, &subject); err != nil {
+		return nil, err
+	}
+	return &subject, nil
+}
+
+// NOTE - merge patches the subject, members left unset in patch are untouched
+func (c *Client) PatchSubject(ctx context.Context, id int, patch SubjectPatch) (*Subject, error) {
+	req, err := jsonRequest(http.MethodPatch, subjectPath(id), patch)
+	if err != nil {
+		return nil, err
+	}
+	req.contentType = "application/merge-patch+json"
+	// applying the same merge patch twice gives the same subject
+	req.retryable = true
+
+	var subject Subject
+	if err := c.do(ctx, req, &subject); err != nil {
+		return nil, err
+	}
+	return &subject, nil
+}
+
+// NOTE - deletes the subject. Not retried, a replay after a lost response would report it missing
+func (c *Client) DeleteSubject(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: subjectPath(id)}, nil)
+}
+
+func subjectPath(id int) string {
+	return subjectsPath + "/" + strconv.Itoa(id)
+}
@@ -0,0 +1,71 @@
+package client
+
+import "sample-project/internal/entity"
+
+// The client speaks the types the handlers render and bind, so both change together.
+type (
+	User                       = entity.User
+	UserPatch                  = entity.UserPatch
+	CreateUserRequest          = entity.CreateUserRequest
+	UpdateUserRequest          = entity.UpdateUserRequest
+	TransitionUserStateRequest = entity.TransitionUserStateRequest
+	UserState                  = entity.UserState
+	UserStateTransition        = entity.UserStateTransition
+	AvatarResponse             = entity.AvatarResponse
+	PageMeta                   = entity.PageMeta
+
+	Subject              = entity.Subject
+	SubjectPatch         = entity.SubjectPatch
+	CreateSubjectRequest = entity.CreateSubjectRequest
+	UpdateSubjectRequest = entity.UpdateSubjectRequest
+
+	Tokens     = entity.Tokens
+	FieldError = entity.FieldError
+)
+
+// User lifecycle states.
+const (
+	UserStatePending   = entity.UserStatePending
+	UserStateActive    = entity.UserStateActive
+	UserStateSuspended = entity.UserStateSuspended
+	UserStateGraduated = entity.UserStateGraduated
+)
+
+// Relations a read can include.
+const (
+	IncludeSubject = entity.IncludeSubject
+	IncludeUsers   = entity.IncludeUsers
+)
+
+// NOTE - sets a member of a UserPatch or SubjectPatch
+func Set[T any](value T) entity.Nullable[T] {
+	return entity.NewNullable(value)
+}
+
+// NOTE - clears a member of a UserPatch, e.g. the subject of a user
+func Clear[T any]() entity.Nullable[T] {
+	return entity.Nullable[T]{Present: true, Null: true}
+}
+
+// Projection picks the fields and relations a read returns. Zero values read every field without relations.
+type Projection struct {
+	Fields  []string
+	Include []string
+}
+
+// UserPage is one page of users.
+type UserPage struct {
+	Data []User   `json:"data"`
+	Meta PageMeta `json:"meta"`
+}
+
+// ListUsersParams filters and pages a user listing. Zero values are left to the server.
+type ListUsersParams struct {
+	Page      int
+	Limit     int
+	Name      string
+	StartDate string
+	EndDate   string
+	State     UserState
+	Projection
+}
@@ -0,0 +1,214 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const usersPath = "/api/v2/users"
+
+// NOTE - one page of users
+func (c *Client) ListUsers(ctx context.Context, params ListUsersParams) (*UserPage, error) {
+	query := params.Projection.query()
+	setQuery(query, "page", params.Page)
+	setQuery(query, "limit", params.Limit)
+	setQuery(query, "name", params.Name)
+	setQuery(query, "startDate", params.StartDate)
+	setQuery(query, "endDate", params.EndDate)
+	setQuery(query, "state", string(params.State))
+
+	var page UserPage
+	req := request{method: http.MethodGet, path: usersPath, query: query, retryable: true}
+	if err := c.do(ctx, req, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// NOTE - every user matching params, fetched page by page from params.Page on while the loop asks for more.
+// It ends after the first page shorter than its limit. The iteration stops at the first error, which is yielded with a zero User
+func (c *Client) Users(ctx context.Context, params ListUsersParams) iter.Seq2[User, error] {
+	return func(yield func(User, error) bool) {
+		if params.Page < 1 {
+			params.Page = 1
+		}
+
+		for {
+			page, err := c.ListUsers(ctx, params)
+			if err != nil {
+				yield(User{}, err)
+				return
+			}
+
+			for _, user := range page.Data {
+				if !yield(user, nil) {
+					return
+				}
+			}
+
+			// a page shorter than its limit is the last one, whatever TotalPages says
+			if len(page.Data) == 0 || len(page.Data) < page.Meta.Limit {
+				return
+			}
+			params.Page++
+		}
+	}
+}
+
+// NOTE - user by id, its Version is what UpdateUser, PatchUser and DeleteUser expect
+func (c *Client) GetUser(ctx context.Context, id int, projection Projection) (*User, error) {
+	var user User
+	req := request{method: http.MethodGet, path: userPath(id), query: projection.query(), retryable: true}
+	if err := c.do(ctx, req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// NOTE - creates a user. Retries carry the same Idempotency-Key, so the user is created once
+func (c *Client) CreateUser(ctx context.Context, create CreateUserRequest) (*User, error) {
+	req, err := jsonRequest(http.MethodPost, usersPath, create)
+	if err != nil {
+		return nil, err
+	}
+	req.header = idempotencyHeader()
+	req.retryable = true
+
+	var user User
+	if err := c.do(ctx, req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// NOTE - replaces the user at version. Fails with IsPreconditionFailed when it changed since
+func (c *Client) UpdateUser(ctx context.Context, id, version int, update UpdateUserRequest) (*User, error) {
+	req, err := jsonRequest(http.MethodPut, userPath(id), update)
+	if err != nil {
+		return nil, err
+	}
+	// a replay after a lost response fails its precondition, so conditional writes are not retried
+	req.header = ifMatchHeader(version)
+
+	var user User
+	if err := c.do(ctx, req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// NOTE - merge patches the user at version, members left unset in patch are untouched.
+// Fails with IsPreconditionFailed when it changed since
+func (c *Client) PatchUser(ctx context.Context, id, version int, patch UserPatch) (*User, error) {
+	req, err := jsonRequest(http.MethodPatch, userPath(id), patch)
+	if err != nil {
+		return nil, err
+	}
+	req.contentType = "application/merge-patch+json"
+	req.header = ifMatchHeader(version)
+
+	var user User
+	if err := c.do(ctx, req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// NOTE - deletes the user at version. Fails with IsPreconditionFailed when it changed since
+func (c *Client) DeleteUser(ctx context.Context, id, version int) error {
+	req := request{method: http.MethodDelete, path: userPath(id), header: ifMatchHeader(version)}
+	return c.do(ctx, req, nil)
+}
+
+// NOTE - uploads the avatar of a user, a JPEG, PNG or GIF of at most 5MB
+func (c *Client) UploadAvatar(ctx context.Context, id int, filename string, image io.Reader) (*AvatarResponse, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("avatar", filename)
+	if err != nil {
+		return nil, fmt.Errorf("client: encode avatar: %w", err)
+	}
+	if _, err := io.Copy(part, image); err != nil {
+		return nil, fmt.Errorf("client: read avatar: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return nil, fmt.Errorf("client: encode avatar: %w", err)
+	}
+
+	var avatar AvatarResponse
+	req := request{
+		method:      http.MethodPut,
+		path:        userPath(id) + "/avatar",
+		body:        body.Bytes(),
+		contentType: form.FormDataContentType(),
+		retryable:   true,
+	}
+	if err := c.do(ctx, req, &avatar); err != nil {
+		return nil, err
+	}
+	return &avatar, nil
+}
+
+// NOTE - moves the user to another lifecycle state, needs a logged in client
+func (c *Client) TransitionUserState(ctx context.Context, id int, transition TransitionUserStateRequest) (*User, error) {
+	req, err := jsonRequest(http.MethodPost, userPath(id)+"/state", transition)
+	if err != nil {
+		return nil, err
+	}
+
+	var user User
+	if err := c.do(ctx, req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// NOTE - lifecycle history of the user, newest first
+func (c *Client) UserStateTransitions(ctx context.Context, id int) ([]UserStateTransition, error) {
+	var transitions []UserStateTransition
+	req := request{method: http.MethodGet, path: userPath(id) + "/state-transitions", retryable: true}
+	if err := c.do(ctx, req, &transitions); err != nil {
+		return nil, err
+	}
+	return transitions, nil
+}
+
+func userPath(id int) string {
+	return usersPath + "/" + strconv.Itoa(id)
+}
+
+// NOTE - If-Match of the version a conditional write expects
+func ifMatchHeader(version int) http.Header {
+	return http.Header{"If-Match": []string{fmt.Sprintf(`"%d"`, version)}}
+}
+
+// NOTE - fresh Idempotency-Key, kept for every attempt of one call
+func idempotencyHeader() http.Header {
+	return http.Header{"Idempotency-Key": []string{uuid.NewString()}}
+}
+
+// NOTE - fields and include query of the projection
+func (p Projection) query() url.Values {
+	query := url.Values{}
+	setQuery(query, "fields", strings.Join(p.Fields, ","))
+	setQuery(query, "include", strings.Join(p.Include, ","))
+	return query
+}
+
+// NOTE - sets a query parameter unless value is the zero value
+func setQuery[T comparable](query url.Values, key string, value T) {
+	var zero T
+	if value != zero {
+		query.Set(key, fmt.Sprint(value))
+	}
+}
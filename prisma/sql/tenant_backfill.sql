@@ -0,0 +1,61 @@
+-- Moves a database written before schools existed to the tenant schema. prisma db push cannot add the
+-- NOT NULL tenant_id columns to tables that already hold rows, so this runs first:
+--
+--   prisma db execute --file prisma/sql/tenant_backfill.sql --schema prisma/schema.prisma
+--   prisma db push
+--
+-- Every existing row goes to the "default" school, rename it or move rows to other schools afterwards.
+-- The script can be run again, rows that already name a school are left alone.
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS tenants (
+  id         SERIAL PRIMARY KEY,
+  slug       TEXT NOT NULL,
+  name       TEXT NOT NULL,
+  host       TEXT,
+  key_hash   TEXT,
+  created_at TIMESTAMPTZ(6) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS tenants_slug_key ON tenants (slug);
+CREATE UNIQUE INDEX IF NOT EXISTS tenants_host_key ON tenants (host);
+CREATE UNIQUE INDEX IF NOT EXISTS tenants_key_hash_key ON tenants (key_hash);
+
+INSERT INTO tenants (slug, name) VALUES ('default', 'Default school') ON CONFLICT (slug) DO NOTHING;
+
+-- subjects first, users follow the school of their subject
+ALTER TABLE subjects ADD COLUMN IF NOT EXISTS tenant_id INTEGER;
+UPDATE subjects SET tenant_id = (SELECT id FROM tenants WHERE slug = 'default') WHERE tenant_id IS NULL;
+ALTER TABLE subjects ALTER COLUMN tenant_id SET NOT NULL;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id INTEGER;
+UPDATE users SET tenant_id = subjects.tenant_id
+  FROM subjects
+  WHERE users.tenant_id IS NULL AND users.subject_id = subjects.id;
+UPDATE users SET tenant_id = (SELECT id FROM tenants WHERE slug = 'default') WHERE tenant_id IS NULL;
+ALTER TABLE users ALTER COLUMN tenant_id SET NOT NULL;
+
+-- the foreign key of users names the tenant of the subject too, a user linked across schools has to be
+-- fixed by hand, guessing which school is right would move data between schools
+DO $$
+DECLARE
+  crossed INTEGER;
+BEGIN
+  SELECT COUNT(*) INTO crossed
+    FROM users JOIN subjects ON subjects.id = users.subject_id
+    WHERE subjects.tenant_id <> users.tenant_id;
+  IF crossed > 0 THEN
+    RAISE EXCEPTION '% users are linked to a subject of another school, relink or unlink them and run this again', crossed;
+  END IF;
+END $$;
+
+-- the privacy log becomes one chain per school, a record belongs to the school of its user. A log written
+-- while several schools shared one chain verifies per school only up to the first record linked to
+-- another school's, a database from before schools has a single school and keeps its chain intact
+ALTER TABLE privacy_request_records ADD COLUMN IF NOT EXISTS tenant_id INTEGER;
+UPDATE privacy_request_records SET tenant_id = users.tenant_id
+  FROM users
+  WHERE privacy_request_records.tenant_id IS NULL AND privacy_request_records.user_id = users.id;
+UPDATE privacy_request_records SET tenant_id = (SELECT id FROM tenants WHERE slug = 'default') WHERE tenant_id IS NULL;
+ALTER TABLE privacy_request_records ALTER COLUMN tenant_id SET NOT NULL;
+
+COMMIT;